@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -31,14 +32,26 @@ func main() {
 	// Initialize services
 	discoveryService := services.NewDiscoveryService(config)
 	watchlistService := services.NewWatchlistService()
-	recommendationService := services.NewRecommendationService(discoveryService, watchlistService)
+	historyService := services.NewHistoryService(config)
+	parentalService := services.NewParentalControlService(config, discoveryService)
+	profileService := services.NewProfileService(parentalService)
+	recommendationService := services.NewRecommendationService(discoveryService, watchlistService, historyService, parentalService)
 	genreService := services.NewGenreService(config)
+	peopleService := services.NewPeopleService(config, watchlistService, parentalService)
+	collectionService := services.NewCollectionService(config, watchlistService, parentalService)
+	calendarService := services.NewCalendarService(config, watchlistService, parentalService)
+	savedSearchService := services.NewSavedSearchService(config, discoveryService)
+
+	// Re-run saved searches in the background until shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go savedSearchService.Run(ctx)
 
 	// Initialize handlers
-	handlers := api.NewHandlers(discoveryService, watchlistService, recommendationService, genreService)
+	handlers := api.NewHandlers(discoveryService, watchlistService, recommendationService, genreService, peopleService, collectionService, calendarService, savedSearchService, historyService, parentalService, profileService)
 
 	// Setup router
-	router := api.SetupRouter(handlers)
+	router := api.SetupRouter(handlers, config.Server.RequestTimeout)
 
 	// Start server
 	addr := fmt.Sprintf("%s:%s", config.Server.Host, config.Server.Port)
@@ -54,6 +67,7 @@ func main() {
 		<-sigChan
 
 		log.Println("Shutting down server...")
+		cancel()
 		if err := server.Close(); err != nil {
 			log.Printf("Error during server shutdown: %v", err)
 		}
@@ -67,5 +81,10 @@ func main() {
 		log.Fatalf("Server failed to start: %v", err)
 	}
 
+	// Keep what the search index learned for the next run
+	if err := discoveryService.SaveSearchIndex(); err != nil {
+		log.Printf("Failed to save search index: %v", err)
+	}
+
 	log.Println("Server stopped")
 }
@@ -10,17 +10,28 @@ import (
 
 // Config holds all configuration for the application
 type Config struct {
-	Server ServerConfig
-	TMDB   TMDBConfig
-	OMDB   OMDBConfig
-	Cache  CacheConfig
-	Rate   RateLimitConfig
+	Server        ServerConfig
+	TMDB          TMDBConfig
+	OMDB          OMDBConfig
+	YouTube       YouTubeConfig
+	Cache         CacheConfig
+	Rate          RateLimitConfig
+	Retry         RetryConfig
+	Breaker       BreakerConfig
+	Enrichment    EnrichmentConfig
+	Scoring       ScoringConfig
+	Autocomplete  AutocompleteConfig
+	SearchIndex   SearchIndexConfig
+	SavedSearches SavedSearchConfig
+	History       HistoryConfig
+	Parental      ParentalConfig
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	Port string
-	Host string
+	Port           string
+	Host           string
+	RequestTimeout time.Duration
 }
 
 // TMDBConfig holds TMDB API configuration
@@ -35,6 +46,13 @@ type OMDBConfig struct {
 	BaseURL string
 }
 
+// YouTubeConfig holds YouTube Data API configuration
+type YouTubeConfig struct {
+	APIKey     string
+	BaseURL    string
+	DailyQuota int // Quota units to spend per day; a search costs 100
+}
+
 // CacheConfig holds cache configuration
 type CacheConfig struct {
 	Duration time.Duration
@@ -43,6 +61,67 @@ type CacheConfig struct {
 // RateLimitConfig holds rate limiting configuration
 type RateLimitConfig struct {
 	RequestsPerMinute int
+	Burst             int
+}
+
+// RetryConfig holds retry configuration for upstream API calls
+type RetryConfig struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	MaxElapsed  time.Duration
+}
+
+// BreakerConfig holds circuit breaker configuration for upstream APIs
+type BreakerConfig struct {
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+// AutocompleteConfig holds configuration for search autocomplete
+type AutocompleteConfig struct {
+	Timeout   time.Duration // Budget for the TMDB lookup; the local index answers alone after it
+	IndexSize int           // Titles and people kept in the local prefix index
+}
+
+// SearchIndexConfig holds configuration for the local search index
+type SearchIndexConfig struct {
+	Path         string        // File the index is saved to; empty keeps it in memory only
+	SaveInterval time.Duration // Minimum time between saves while titles are being indexed
+}
+
+// SavedSearchConfig holds configuration for re-running saved searches
+type SavedSearchConfig struct {
+	Interval time.Duration // Time between runs of each saved search
+}
+
+// HistoryConfig holds configuration for users' search and view history
+type HistoryConfig struct {
+	MaxEntries int           // Searches and views kept per user
+	Retention  time.Duration // How long searches and views are kept
+}
+
+// ParentalConfig holds configuration for parental controls
+type ParentalConfig struct {
+	UnknownCertifications string // strict or lenient, for profiles that don't choose
+}
+
+// EnrichmentConfig holds configuration for enriching search results with OMDB data
+type EnrichmentConfig struct {
+	Concurrency int
+	Timeout     time.Duration
+}
+
+// ScoringConfig holds the formula for the composite score. Weights set how
+// much each source counts; ratings backed by vote counts are shrunk towards
+// PriorMean (0-100) as if PriorVotes extra votes had been cast at that value.
+type ScoringConfig struct {
+	WeightTMDB           float64
+	WeightIMDb           float64
+	WeightRottenTomatoes float64
+	WeightMetacritic     float64
+	PriorMean            float64
+	PriorVotes           int
 }
 
 // LoadConfig loads configuration from environment variables
@@ -52,8 +131,9 @@ func LoadConfig() (*Config, error) {
 
 	config := &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
-			Host: getEnv("HOST", "localhost"),
+			Port:           getEnv("PORT", "8080"),
+			Host:           getEnv("HOST", "localhost"),
+			RequestTimeout: time.Duration(getEnvAsInt("REQUEST_TIMEOUT_SECONDS", 20)) * time.Second,
 		},
 		TMDB: TMDBConfig{
 			APIKey:  getEnv("TMDB_API_KEY", ""),
@@ -63,11 +143,57 @@ func LoadConfig() (*Config, error) {
 			APIKey:  getEnv("OMDB_API_KEY", ""),
 			BaseURL: getEnv("OMDB_BASE_URL", "http://www.omdbapi.com"),
 		},
+		YouTube: YouTubeConfig{
+			APIKey:     getEnv("YOUTUBE_API_KEY", ""),
+			BaseURL:    getEnv("YOUTUBE_BASE_URL", "https://www.googleapis.com/youtube/v3"),
+			DailyQuota: getEnvAsInt("YOUTUBE_DAILY_QUOTA", 10000),
+		},
 		Cache: CacheConfig{
 			Duration: time.Duration(getEnvAsInt("CACHE_DURATION_MINUTES", 30)) * time.Minute,
 		},
 		Rate: RateLimitConfig{
 			RequestsPerMinute: getEnvAsInt("RATE_LIMIT_REQUESTS_PER_MINUTE", 60),
+			Burst:             getEnvAsInt("RATE_LIMIT_BURST", 10),
+		},
+		Retry: RetryConfig{
+			MaxAttempts: getEnvAsInt("UPSTREAM_RETRY_MAX_ATTEMPTS", 3),
+			BaseDelay:   time.Duration(getEnvAsInt("UPSTREAM_RETRY_BASE_DELAY_MS", 200)) * time.Millisecond,
+			MaxDelay:    time.Duration(getEnvAsInt("UPSTREAM_RETRY_MAX_DELAY_MS", 5000)) * time.Millisecond,
+			MaxElapsed:  time.Duration(getEnvAsInt("UPSTREAM_RETRY_MAX_ELAPSED_SECONDS", 15)) * time.Second,
+		},
+		Breaker: BreakerConfig{
+			FailureThreshold: getEnvAsInt("CIRCUIT_BREAKER_FAILURE_THRESHOLD", 5),
+			Cooldown:         time.Duration(getEnvAsInt("CIRCUIT_BREAKER_COOLDOWN_SECONDS", 30)) * time.Second,
+		},
+		Enrichment: EnrichmentConfig{
+			Concurrency: getEnvAsInt("OMDB_ENRICHMENT_CONCURRENCY", 5),
+			Timeout:     time.Duration(getEnvAsInt("OMDB_ENRICHMENT_TIMEOUT_MS", 3000)) * time.Millisecond,
+		},
+		Scoring: ScoringConfig{
+			WeightTMDB:           getEnvAsFloat("SCORE_WEIGHT_TMDB", 1),
+			WeightIMDb:           getEnvAsFloat("SCORE_WEIGHT_IMDB", 1),
+			WeightRottenTomatoes: getEnvAsFloat("SCORE_WEIGHT_ROTTEN_TOMATOES", 1),
+			WeightMetacritic:     getEnvAsFloat("SCORE_WEIGHT_METACRITIC", 1),
+			PriorMean:            getEnvAsFloat("SCORE_PRIOR_MEAN", 60),
+			PriorVotes:           getEnvAsInt("SCORE_PRIOR_VOTES", 500),
+		},
+		Autocomplete: AutocompleteConfig{
+			Timeout:   time.Duration(getEnvAsInt("AUTOCOMPLETE_TIMEOUT_MS", 250)) * time.Millisecond,
+			IndexSize: getEnvAsInt("AUTOCOMPLETE_INDEX_SIZE", 10000),
+		},
+		SearchIndex: SearchIndexConfig{
+			Path:         getEnv("SEARCH_INDEX_PATH", "data/search_index.json"),
+			SaveInterval: time.Duration(getEnvAsInt("SEARCH_INDEX_SAVE_INTERVAL_SECONDS", 60)) * time.Second,
+		},
+		SavedSearches: SavedSearchConfig{
+			Interval: time.Duration(getEnvAsInt("SAVED_SEARCH_INTERVAL_MINUTES", 360)) * time.Minute,
+		},
+		History: HistoryConfig{
+			MaxEntries: getEnvAsInt("HISTORY_MAX_ENTRIES", 50),
+			Retention:  time.Duration(getEnvAsInt("HISTORY_RETENTION_DAYS", 90)) * 24 * time.Hour,
+		},
+		Parental: ParentalConfig{
+			UnknownCertifications: getEnv("PARENTAL_UNKNOWN_CERTIFICATIONS", "strict"),
 		},
 	}
 
@@ -91,3 +217,13 @@ func getEnvAsInt(key string, fallback int) int {
 	}
 	return fallback
 }
+
+// getEnvAsFloat gets an environment variable as float with a fallback value
+func getEnvAsFloat(key string, fallback float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return fallback
+}
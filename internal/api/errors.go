@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"movie-discovery-app/internal/services"
+)
+
+// serviceErrorStatuses maps the services' sentinel errors to the status they
+// are reported with, checked in order
+var serviceErrorStatuses = []struct {
+	err    error
+	status int
+}{
+	{context.DeadlineExceeded, http.StatusGatewayTimeout},
+	{services.ErrTitleBlocked, http.StatusForbidden},
+	{services.ErrProfileForbidden, http.StatusForbidden},
+	{services.ErrUpstreamNotFound, http.StatusNotFound},
+	{services.ErrNoTrailer, http.StatusNotFound},
+	{services.ErrFeedNotFound, http.StatusNotFound},
+	{services.ErrSavedSearchNotFound, http.StatusNotFound},
+	{services.ErrProfileNotFound, http.StatusNotFound},
+	{services.ErrSavedSearchLimit, http.StatusConflict},
+	{services.ErrProfileLimit, http.StatusConflict},
+	{services.ErrProfileNameTaken, http.StatusConflict},
+	{services.ErrOwnerPINNotSet, http.StatusConflict},
+	{services.ErrUpstreamRateLimited, http.StatusTooManyRequests},
+	{services.ErrUpstreamUnavailable, http.StatusServiceUnavailable},
+}
+
+// writeServiceError writes an error response for a failed service call, with
+// 400 for an invalid search query, the status in serviceErrorStatuses for a
+// known error and 500 for anything else
+func writeServiceError(w http.ResponseWriter, message string, err error) {
+	status := http.StatusInternalServerError
+
+	var queryErr *services.QueryError
+	if errors.As(err, &queryErr) {
+		status = http.StatusBadRequest
+	} else {
+		for _, mapping := range serviceErrorStatuses {
+			if errors.Is(err, mapping.err) {
+				status = mapping.status
+				break
+			}
+		}
+	}
+
+	var upstreamErr *services.UpstreamError
+	if status == http.StatusTooManyRequests && errors.As(err, &upstreamErr) && upstreamErr.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int((upstreamErr.RetryAfter+time.Second-1)/time.Second)))
+	}
+
+	http.Error(w, fmt.Sprintf("%s: %v", message, err), status)
+}
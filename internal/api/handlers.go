@@ -1,11 +1,14 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"movie-discovery-app/internal/models"
 	"movie-discovery-app/internal/services"
@@ -19,20 +22,36 @@ type Handlers struct {
 	watchlistService      *services.WatchlistService
 	recommendationService *services.RecommendationService
 	genreService          *services.GenreService
+	peopleService         *services.PeopleService
+	collectionService     *services.CollectionService
+	calendarService       *services.CalendarService
+	savedSearchService    *services.SavedSearchService
+	historyService        *services.HistoryService
+	parentalService       *services.ParentalControlService
+	profileService        *services.ProfileService
 }
 
 // NewHandlers creates a new handlers instance
-func NewHandlers(discoveryService *services.DiscoveryService, watchlistService *services.WatchlistService, recommendationService *services.RecommendationService, genreService *services.GenreService) *Handlers {
+func NewHandlers(discoveryService *services.DiscoveryService, watchlistService *services.WatchlistService, recommendationService *services.RecommendationService, genreService *services.GenreService, peopleService *services.PeopleService, collectionService *services.CollectionService, calendarService *services.CalendarService, savedSearchService *services.SavedSearchService, historyService *services.HistoryService, parentalService *services.ParentalControlService, profileService *services.ProfileService) *Handlers {
 	return &Handlers{
 		discoveryService:      discoveryService,
 		watchlistService:      watchlistService,
 		recommendationService: recommendationService,
 		genreService:          genreService,
+		peopleService:         peopleService,
+		collectionService:     collectionService,
+		calendarService:       calendarService,
+		savedSearchService:    savedSearchService,
+		historyService:        historyService,
+		parentalService:       parentalService,
+		profileService:        profileService,
 	}
 }
 
 // SearchMovies handles movie search requests
 func (h *Handlers) SearchMovies(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
 	query := r.URL.Query().Get("q")
 	if query == "" {
 		http.Error(w, "Query parameter 'q' is required", http.StatusBadRequest)
@@ -60,18 +79,26 @@ func (h *Handlers) SearchMovies(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Search movies
-	results, err := h.discoveryService.SearchMovies(query, page)
+	results, err := h.discoveryService.SearchMovies(r.Context(), query, page)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Search failed: %v", err), http.StatusInternalServerError)
+		writeServiceError(w, "Search failed", err)
+		return
+	}
+	if results, err = h.filterSearchResult(r.Context(), profileID, "movie", results); err != nil {
+		writeServiceError(w, "Search failed", err)
 		return
 	}
 
+	h.historyService.RecordSearch(profileID, "movie", query)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(results)
 }
 
 // SearchTVShows handles TV show search requests
 func (h *Handlers) SearchTVShows(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
 	query := r.URL.Query().Get("q")
 	if query == "" {
 		http.Error(w, "Query parameter 'q' is required", http.StatusBadRequest)
@@ -99,18 +126,75 @@ func (h *Handlers) SearchTVShows(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Search TV shows
-	results, err := h.discoveryService.SearchTVShows(query, page)
+	results, err := h.discoveryService.SearchTVShows(r.Context(), query, page)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Search failed: %v", err), http.StatusInternalServerError)
+		writeServiceError(w, "Search failed", err)
+		return
+	}
+	if results, err = h.filterSearchResult(r.Context(), profileID, "tv", results); err != nil {
+		writeServiceError(w, "Search failed", err)
 		return
 	}
 
+	h.historyService.RecordSearch(profileID, "tv", query)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(results)
 }
 
+// Autocomplete handles search-as-you-type suggestion requests
+func (h *Handlers) Autocomplete(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "Query parameter 'q' is required", http.StatusBadRequest)
+		return
+	}
+
+	// Validate query
+	if err := h.discoveryService.ValidateAutocompleteQuery(query); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := services.DefaultAutocompleteLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 || l > services.MaxAutocompleteLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", services.MaxAutocompleteLimit), http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	// Parental controls are checked within what is left of the autocomplete
+	// budget
+	deadline := time.Now().Add(h.discoveryService.AutocompleteTimeout())
+	suggestions, err := h.discoveryService.Autocomplete(r.Context(), query, limit)
+	if err != nil {
+		writeServiceError(w, "Autocomplete failed", err)
+		return
+	}
+
+	filterCtx, cancel := context.WithDeadline(r.Context(), deadline)
+	defer cancel()
+	kept, complete, err := h.parentalService.FilterSuggestions(filterCtx, profileID, suggestions.Suggestions)
+	if err != nil {
+		writeServiceError(w, "Autocomplete failed", err)
+		return
+	}
+	suggestions.Suggestions = kept
+	suggestions.Partial = suggestions.Partial || !complete
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suggestions)
+}
+
 // GetMovieDetails handles movie details requests
 func (h *Handlers) GetMovieDetails(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
 	vars := mux.Vars(r)
 	movieIDStr := vars["id"]
 
@@ -120,18 +204,32 @@ func (h *Handlers) GetMovieDetails(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	movie, err := h.discoveryService.GetMovieDetails(movieID)
+	include, err := parseInclude(r, "movie")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	movie, err := h.discoveryService.GetMovieDetails(r.Context(), movieID, include)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get movie details: %v", err), http.StatusInternalServerError)
+		writeServiceError(w, "Failed to get movie details", err)
+		return
+	}
+	if err := h.parentalService.CheckMovie(r.Context(), profileID, movie); err != nil {
+		writeServiceError(w, "Movie not available", err)
 		return
 	}
 
+	h.historyService.RecordMovieView(profileID, movie)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(movie)
 }
 
 // GetTVShowDetails handles TV show details requests
 func (h *Handlers) GetTVShowDetails(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
 	vars := mux.Vars(r)
 	tvIDStr := vars["id"]
 
@@ -141,18 +239,296 @@ func (h *Handlers) GetTVShowDetails(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tvShow, err := h.discoveryService.GetTVShowDetails(tvID)
+	include, err := parseInclude(r, "tv")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tvShow, err := h.discoveryService.GetTVShowDetails(r.Context(), tvID, include)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get TV show details: %v", err), http.StatusInternalServerError)
+		writeServiceError(w, "Failed to get TV show details", err)
+		return
+	}
+	if err := h.parentalService.CheckTVShow(r.Context(), profileID, tvShow); err != nil {
+		writeServiceError(w, "TV show not available", err)
 		return
 	}
 
+	h.historyService.RecordTVShowView(profileID, tvShow)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(tvShow)
 }
 
+// parseInclude reads the include query parameter of a details request.
+// Without the parameter every section is included.
+func parseInclude(r *http.Request, mediaType string) ([]string, error) {
+	if !r.URL.Query().Has("include") {
+		if mediaType == "tv" {
+			return services.TVDetailSections, nil
+		}
+		return services.MovieDetailSections, nil
+	}
+
+	return services.ParseDetailSections(mediaType, r.URL.Query().Get("include"))
+}
+
+// filterSearchResult hides the results the user's parental controls don't
+// allow, leaving the service's result untouched
+func (h *Handlers) filterSearchResult(ctx context.Context, profileID, mediaType string, results *models.SearchResult) (*models.SearchResult, error) {
+	filtered, err := h.parentalService.FilterResults(ctx, profileID, mediaType, results.Results)
+	if err != nil {
+		return nil, err
+	}
+
+	response := *results
+	response.Results = filtered
+	return &response, nil
+}
+
+// SearchPeople handles people search requests
+func (h *Handlers) SearchPeople(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "Query parameter 'q' is required", http.StatusBadRequest)
+		return
+	}
+
+	// Validate query
+	if err := h.discoveryService.ValidateSearchQuery(query); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Parse page parameter
+	page := 1
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil {
+			page = p
+		}
+	}
+
+	// Validate page
+	if err := h.discoveryService.ValidatePage(page); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.peopleService.SearchPeople(r.Context(), query, page)
+	if err != nil {
+		writeServiceError(w, "Search failed", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// GetPerson handles person details requests
+func (h *Handlers) GetPerson(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	personIDStr := vars["id"]
+
+	personID, err := strconv.Atoi(personIDStr)
+	if err != nil {
+		http.Error(w, "Invalid person ID", http.StatusBadRequest)
+		return
+	}
+
+	profileID := currentProfile(r).Key()
+
+	person, err := h.peopleService.GetPerson(r.Context(), profileID, personID)
+	if err != nil {
+		writeServiceError(w, "Failed to get person details", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(person)
+}
+
+// GetCollection handles collection requests
+func (h *Handlers) GetCollection(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	collectionIDStr := vars["id"]
+
+	collectionID, err := strconv.Atoi(collectionIDStr)
+	if err != nil {
+		http.Error(w, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+
+	profileID := currentProfile(r).Key()
+
+	collection, err := h.collectionService.GetCollection(r.Context(), profileID, collectionID)
+	if err != nil {
+		writeServiceError(w, "Failed to get collection", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(collection)
+}
+
+// AddCollectionToWatchlist handles adding the rest of a collection to the
+// watchlist
+func (h *Handlers) AddCollectionToWatchlist(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	collectionIDStr := vars["id"]
+
+	collectionID, err := strconv.Atoi(collectionIDStr)
+	if err != nil {
+		http.Error(w, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+
+	profileID := currentProfile(r).Key()
+
+	added, collection, err := h.collectionService.AddCollectionToWatchlist(r.Context(), profileID, collectionID)
+	if err != nil {
+		writeServiceError(w, "Failed to add collection to watchlist", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"added":      added,
+		"collection": collection,
+	})
+}
+
+// GetCalendar handles release calendar requests
+func (h *Handlers) GetCalendar(w http.ResponseWriter, r *http.Request) {
+	query, ok := h.parseCalendarQuery(w, r)
+	if !ok {
+		return
+	}
+
+	profileID := currentProfile(r).Key()
+
+	calendar, err := h.calendarService.GetCalendar(r.Context(), profileID, query)
+	if err != nil {
+		writeServiceError(w, "Failed to get calendar", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(calendar)
+}
+
+// GetCalendarICS handles release calendar requests in iCalendar format
+func (h *Handlers) GetCalendarICS(w http.ResponseWriter, r *http.Request) {
+	query, ok := h.parseCalendarQuery(w, r)
+	if !ok {
+		return
+	}
+
+	profileID := currentProfile(r).Key()
+
+	data, err := h.calendarService.GetCalendarICS(r.Context(), profileID, query)
+	if err != nil {
+		writeServiceError(w, "Failed to get calendar", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=calendar.ics")
+	w.Write(data)
+}
+
+// GetWatchlistFeedURL handles requests for the secret URL of the user's
+// watchlist calendar feed
+func (h *Handlers) GetWatchlistFeedURL(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	token, err := h.calendarService.WatchlistFeedToken(profileID)
+	if err != nil {
+		writeServiceError(w, "Failed to get feed URL", err)
+		return
+	}
+
+	writeFeedURL(w, r, token)
+}
+
+// RotateWatchlistFeedURL handles replacing the user's watchlist feed URL,
+// revoking the old one
+func (h *Handlers) RotateWatchlistFeedURL(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	token, err := h.calendarService.RotateWatchlistFeedToken(profileID)
+	if err != nil {
+		writeServiceError(w, "Failed to rotate feed URL", err)
+		return
+	}
+
+	writeFeedURL(w, r, token)
+}
+
+// writeFeedURL writes the absolute URL of a watchlist feed
+func writeFeedURL(w http.ResponseWriter, r *http.Request, token string) {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"token": token,
+		"url":   fmt.Sprintf("%s://%s/api/v1/feeds/%s/watchlist.ics", scheme, r.Host, token),
+	})
+}
+
+// GetWatchlistFeed handles watchlist calendar feed requests. The token in
+// the URL identifies the user.
+func (h *Handlers) GetWatchlistFeed(w http.ResponseWriter, r *http.Request) {
+	token := mux.Vars(r)["token"]
+
+	region, err := services.NormalizeRegion(r.URL.Query().Get("region"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, err := h.calendarService.GetWatchlistFeed(r.Context(), token, region)
+	if err != nil {
+		writeServiceError(w, "Failed to get feed", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", "inline; filename=watchlist.ics")
+	w.Write(data)
+}
+
+// parseCalendarQuery reads the calendar query parameters, writing a 400
+// response if they are invalid
+func (h *Handlers) parseCalendarQuery(w http.ResponseWriter, r *http.Request) (services.CalendarQuery, bool) {
+	params := r.URL.Query()
+
+	watchlistOnly := false
+	if watchlistStr := params.Get("watchlist"); watchlistStr != "" {
+		parsed, err := strconv.ParseBool(watchlistStr)
+		if err != nil {
+			http.Error(w, "Invalid watchlist parameter", http.StatusBadRequest)
+			return services.CalendarQuery{}, false
+		}
+		watchlistOnly = parsed
+	}
+
+	query, err := h.calendarService.ParseCalendarQuery(params.Get("region"), params.Get("from"), params.Get("to"), watchlistOnly)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return services.CalendarQuery{}, false
+	}
+
+	return query, true
+}
+
 // GetTrendingMovies handles trending movies requests
 func (h *Handlers) GetTrendingMovies(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
 	timeWindow := r.URL.Query().Get("time_window")
 	if timeWindow == "" {
 		timeWindow = "week"
@@ -172,9 +548,16 @@ func (h *Handlers) GetTrendingMovies(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	results, err := h.discoveryService.GetTrendingMovies(timeWindow, page)
+	trending, err := h.discoveryService.GetTrendingMovies(r.Context(), timeWindow, page)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get trending movies: %v", err), http.StatusInternalServerError)
+		writeServiceError(w, "Failed to get trending movies", err)
+		return
+	}
+
+	// Filter a copy, since trending results are cached
+	results := *trending
+	if results.Results, err = h.parentalService.FilterResults(r.Context(), profileID, "movie", trending.Results); err != nil {
+		writeServiceError(w, "Failed to get trending movies", err)
 		return
 	}
 
@@ -191,7 +574,7 @@ func (h *Handlers) AddToWatchlist(w http.ResponseWriter, r *http.Request) {
 
 	// For demo purposes, we'll use a default user ID
 	// In a real app, this would come from authentication
-	userID := "default_user"
+	profileID := currentProfile(r).Key()
 
 	var item models.WatchlistItem
 	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
@@ -199,7 +582,7 @@ func (h *Handlers) AddToWatchlist(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.watchlistService.AddToWatchlist(userID, item); err != nil {
+	if err := h.watchlistService.AddToWatchlist(profileID, item); err != nil {
 		log.Printf("Watchlist validation error: %v, Item: %+v", err, item)
 		http.Error(w, fmt.Sprintf("Failed to add to watchlist: %v", err), http.StatusBadRequest)
 		return
@@ -216,12 +599,12 @@ func (h *Handlers) RemoveFromWatchlist(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := "default_user"
+	profileID := currentProfile(r).Key()
 	vars := mux.Vars(r)
 	itemID := vars["id"]
 	itemType := vars["type"]
 
-	if err := h.watchlistService.RemoveFromWatchlist(userID, itemID, itemType); err != nil {
+	if err := h.watchlistService.RemoveFromWatchlist(profileID, itemID, itemType); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to remove from watchlist: %v", err), http.StatusBadRequest)
 		return
 	}
@@ -232,9 +615,9 @@ func (h *Handlers) RemoveFromWatchlist(w http.ResponseWriter, r *http.Request) {
 
 // GetWatchlist handles getting user's watchlist
 func (h *Handlers) GetWatchlist(w http.ResponseWriter, r *http.Request) {
-	userID := "default_user"
+	profileID := currentProfile(r).Key()
 
-	watchlist, err := h.watchlistService.GetWatchlist(userID)
+	watchlist, err := h.watchlistService.GetWatchlist(profileID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get watchlist: %v", err), http.StatusInternalServerError)
 		return
@@ -251,7 +634,7 @@ func (h *Handlers) MarkAsWatched(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := "default_user"
+	profileID := currentProfile(r).Key()
 	vars := mux.Vars(r)
 	itemID := vars["id"]
 	itemType := vars["type"]
@@ -262,7 +645,7 @@ func (h *Handlers) MarkAsWatched(w http.ResponseWriter, r *http.Request) {
 	}
 	json.NewDecoder(r.Body).Decode(&requestBody)
 
-	if err := h.watchlistService.MarkAsWatched(userID, itemID, itemType, requestBody.Rating); err != nil {
+	if err := h.watchlistService.MarkAsWatched(profileID, itemID, itemType, requestBody.Rating); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to mark as watched: %v", err), http.StatusBadRequest)
 		return
 	}
@@ -273,9 +656,9 @@ func (h *Handlers) MarkAsWatched(w http.ResponseWriter, r *http.Request) {
 
 // GetWatchlistStats handles getting watchlist statistics
 func (h *Handlers) GetWatchlistStats(w http.ResponseWriter, r *http.Request) {
-	userID := "default_user"
+	profileID := currentProfile(r).Key()
 
-	stats, err := h.watchlistService.GetWatchlistStats(userID)
+	stats, err := h.watchlistService.GetWatchlistStats(profileID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get watchlist stats: %v", err), http.StatusInternalServerError)
 		return
@@ -292,12 +675,12 @@ func (h *Handlers) MarkAsUnwatched(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := "default_user"
+	profileID := currentProfile(r).Key()
 	vars := mux.Vars(r)
 	itemID := vars["id"]
 	itemType := vars["type"]
 
-	if err := h.watchlistService.MarkAsUnwatched(userID, itemID, itemType); err != nil {
+	if err := h.watchlistService.MarkAsUnwatched(profileID, itemID, itemType); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to mark as unwatched: %v", err), http.StatusBadRequest)
 		return
 	}
@@ -308,9 +691,9 @@ func (h *Handlers) MarkAsUnwatched(w http.ResponseWriter, r *http.Request) {
 
 // ExportWatchlistAsJSON handles exporting watchlist as JSON
 func (h *Handlers) ExportWatchlistAsJSON(w http.ResponseWriter, r *http.Request) {
-	userID := "default_user"
+	profileID := currentProfile(r).Key()
 
-	data, err := h.watchlistService.ExportWatchlist(userID)
+	data, err := h.watchlistService.ExportWatchlist(profileID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to export watchlist: %v", err), http.StatusInternalServerError)
 		return
@@ -323,9 +706,9 @@ func (h *Handlers) ExportWatchlistAsJSON(w http.ResponseWriter, r *http.Request)
 
 // ExportWatchlistAsCSV handles exporting watchlist as CSV
 func (h *Handlers) ExportWatchlistAsCSV(w http.ResponseWriter, r *http.Request) {
-	userID := "default_user"
+	profileID := currentProfile(r).Key()
 
-	data, err := h.watchlistService.ExportWatchlistAsCSV(userID)
+	data, err := h.watchlistService.ExportWatchlistAsCSV(profileID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to export watchlist as CSV: %v", err), http.StatusInternalServerError)
 		return
@@ -338,9 +721,9 @@ func (h *Handlers) ExportWatchlistAsCSV(w http.ResponseWriter, r *http.Request)
 
 // ExportWatchlistAsPDF handles exporting watchlist as PDF
 func (h *Handlers) ExportWatchlistAsPDF(w http.ResponseWriter, r *http.Request) {
-	userID := "default_user"
+	profileID := currentProfile(r).Key()
 
-	data, err := h.watchlistService.ExportWatchlistAsPDF(userID)
+	data, err := h.watchlistService.ExportWatchlistAsPDF(profileID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to export watchlist as PDF: %v", err), http.StatusInternalServerError)
 		return
@@ -366,16 +749,16 @@ func (h *Handlers) GetTrailers(w http.ResponseWriter, r *http.Request) {
 	var trailers []models.YouTubeVideo
 	switch mediaType {
 	case "movie":
-		trailers, err = h.discoveryService.GetMovieTrailers(mediaID)
+		trailers, err = h.discoveryService.GetMovieTrailers(r.Context(), mediaID)
 	case "tv":
-		trailers, err = h.discoveryService.GetTVTrailers(mediaID)
+		trailers, err = h.discoveryService.GetTVTrailers(r.Context(), mediaID)
 	default:
 		http.Error(w, "Invalid media type", http.StatusBadRequest)
 		return
 	}
 
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get trailers: %v", err), http.StatusInternalServerError)
+		writeServiceError(w, "Failed to get trailers", err)
 		return
 	}
 
@@ -395,9 +778,9 @@ func (h *Handlers) GetOfficialTrailer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	trailer, err := h.discoveryService.GetOfficialTrailer(mediaID, mediaType)
+	trailer, err := h.discoveryService.GetOfficialTrailer(r.Context(), mediaID, mediaType)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get official trailer: %v", err), http.StatusInternalServerError)
+		writeServiceError(w, "Failed to get official trailer", err)
 		return
 	}
 
@@ -417,9 +800,9 @@ func (h *Handlers) GetWatchProviders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	providers, err := h.discoveryService.GetWatchProviders(mediaID, mediaType)
+	providers, err := h.discoveryService.GetWatchProviders(r.Context(), mediaID, mediaType)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get watch providers: %v", err), http.StatusInternalServerError)
+		writeServiceError(w, "Failed to get watch providers", err)
 		return
 	}
 
@@ -444,9 +827,9 @@ func (h *Handlers) GetStreamingServices(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	services, err := h.discoveryService.GetStreamingServices(mediaID, mediaType, region)
+	services, err := h.discoveryService.GetStreamingServices(r.Context(), mediaID, mediaType, region)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get streaming services: %v", err), http.StatusInternalServerError)
+		writeServiceError(w, "Failed to get streaming services", err)
 		return
 	}
 
@@ -456,19 +839,86 @@ func (h *Handlers) GetStreamingServices(w http.ResponseWriter, r *http.Request)
 
 // HealthCheck handles health check requests
 func (h *Handlers) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	upstreams := h.discoveryService.UpstreamStatus()
+
+	// Report degraded while any upstream breaker is not closed
+	status := "healthy"
+	for _, upstream := range upstreams {
+		if upstream.State != services.BreakerClosed.String() {
+			status = "degraded"
+			break
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"status":  "healthy",
-		"service": "movie-discovery-app",
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":        status,
+		"service":       "movie-discovery-app",
+		"upstreams":     upstreams,
+		"youtube_quota": h.discoveryService.YouTubeQuota(),
 	})
 }
 
+// Metrics exposes upstream circuit breaker and rate limiter state in the
+// Prometheus text format
+func (h *Handlers) Metrics(w http.ResponseWriter, r *http.Request) {
+	upstreams := h.discoveryService.UpstreamStatus()
+	states := []services.BreakerState{services.BreakerClosed, services.BreakerOpen, services.BreakerHalfOpen}
+
+	var b strings.Builder
+	b.WriteString("# HELP upstream_circuit_breaker_state Circuit breaker state per upstream (1 for the current state).\n")
+	b.WriteString("# TYPE upstream_circuit_breaker_state gauge\n")
+	for _, upstream := range upstreams {
+		for _, state := range states {
+			value := 0
+			if upstream.State == state.String() {
+				value = 1
+			}
+			fmt.Fprintf(&b, "upstream_circuit_breaker_state{upstream=%q,state=%q} %d\n", upstream.Name, state.String(), value)
+		}
+	}
+
+	counters := []struct {
+		name  string
+		help  string
+		value func(services.UpstreamStatus) int64
+	}{
+		{"upstream_circuit_breaker_opens_total", "Number of times the circuit breaker opened.", func(u services.UpstreamStatus) int64 { return u.Opens }},
+		{"upstream_circuit_breaker_rejected_total", "Calls short-circuited by the circuit breaker.", func(u services.UpstreamStatus) int64 { return u.Rejected }},
+		{"upstream_requests_failed_total", "Upstream calls that failed with an availability error.", func(u services.UpstreamStatus) int64 { return u.Failures }},
+		{"upstream_requests_succeeded_total", "Upstream calls that were answered.", func(u services.UpstreamStatus) int64 { return u.Successes }},
+	}
+	for _, counter := range counters {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", counter.name, counter.help, counter.name)
+		for _, upstream := range upstreams {
+			fmt.Fprintf(&b, "%s{upstream=%q} %d\n", counter.name, upstream.Name, counter.value(upstream))
+		}
+	}
+
+	b.WriteString("# HELP upstream_rate_limit_tokens Rate limiter tokens currently available.\n")
+	b.WriteString("# TYPE upstream_rate_limit_tokens gauge\n")
+	for _, upstream := range upstreams {
+		fmt.Fprintf(&b, "upstream_rate_limit_tokens{upstream=%q} %d\n", upstream.Name, upstream.RateLimitRemaining)
+	}
+
+	quota := h.discoveryService.YouTubeQuota()
+	b.WriteString("# HELP youtube_quota_used_units YouTube Data API quota units spent today.\n")
+	b.WriteString("# TYPE youtube_quota_used_units gauge\n")
+	fmt.Fprintf(&b, "youtube_quota_used_units %d\n", quota.Used)
+	b.WriteString("# HELP youtube_quota_budget_units YouTube Data API daily quota budget.\n")
+	b.WriteString("# TYPE youtube_quota_budget_units gauge\n")
+	fmt.Fprintf(&b, "youtube_quota_budget_units %d\n", quota.Budget)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(b.String()))
+}
+
 // CORS middleware
 func (h *Handlers) EnableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Profile-ID, X-Profile-PIN")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -479,6 +929,86 @@ func (h *Handlers) EnableCORS(next http.Handler) http.Handler {
 	})
 }
 
+// RequestTimeout bounds each request's context by budget, so upstream calls
+// are cancelled when the client disconnects or the budget runs out
+func (h *Handlers) RequestTimeout(budget time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if budget <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), budget)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Localize puts each request's language and region in its context, so TMDB
+// data is fetched in them. Without a language parameter the language is
+// taken from the Accept-Language header.
+func (h *Handlers) Localize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale, err := services.ParseLocale(r.URL.Query().Get("language"), r.URL.Query().Get("region"), r.Header.Get("Accept-Language"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Language")
+		if locale.Language != "" {
+			w.Header().Set("Content-Language", locale.Language)
+		}
+		next.ServeHTTP(w, r.WithContext(services.WithLocale(r.Context(), locale)))
+	})
+}
+
+// SelectProfile puts the profile each request is made as in its context. The
+// X-Profile-ID header selects one of the account's profiles for a single
+// request; without it the profile last switched to is used. While a kid
+// profile is active, selecting another one takes the owner PIN.
+func (h *Handlers) SelectProfile(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		profile := h.profileService.ActiveProfile(accountID(r))
+		if header := r.Header.Get("X-Profile-ID"); header != "" {
+			id, err := strconv.Atoi(header)
+			if err != nil {
+				http.Error(w, "X-Profile-ID must be a profile ID", http.StatusBadRequest)
+				return
+			}
+			if profile, err = h.profileService.SelectProfile(accountID(r), id, ownerPIN(r)); err != nil {
+				writeServiceError(w, "Failed to select profile", err)
+				return
+			}
+		}
+
+		w.Header().Add("Vary", "X-Profile-ID")
+		w.Header().Set("X-Profile-ID", strconv.Itoa(profile.ID))
+		next.ServeHTTP(w, r.WithContext(services.WithProfile(r.Context(), profile)))
+	})
+}
+
+// defaultAccountID is the account every request is made for until the API
+// has authentication
+const defaultAccountID = "default_user"
+
+// accountID returns the account a request is made for
+func accountID(r *http.Request) string {
+	return defaultAccountID
+}
+
+// ownerPIN returns the owner PIN sent with a request, if any
+func ownerPIN(r *http.Request) string {
+	return r.Header.Get("X-Profile-PIN")
+}
+
+// currentProfile returns the profile a request is made as; see SelectProfile
+func currentProfile(r *http.Request) models.Profile {
+	return services.ProfileFromContext(r.Context())
+}
+
 // LoggingMiddleware logs HTTP requests
 func (h *Handlers) LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -489,7 +1019,7 @@ func (h *Handlers) LoggingMiddleware(next http.Handler) http.Handler {
 
 // GetRecommendations handles recommendation requests
 func (h *Handlers) GetRecommendations(w http.ResponseWriter, r *http.Request) {
-	userID := "default_user"
+	profileID := currentProfile(r).Key()
 
 	// Parse limit parameter
 	limit := 20
@@ -499,9 +1029,9 @@ func (h *Handlers) GetRecommendations(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	recommendations, err := h.recommendationService.GetRecommendations(userID, limit)
+	recommendations, err := h.recommendationService.GetRecommendations(r.Context(), profileID, limit)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get recommendations: %v", err), http.StatusInternalServerError)
+		writeServiceError(w, "Failed to get recommendations", err)
 		return
 	}
 
@@ -511,9 +1041,9 @@ func (h *Handlers) GetRecommendations(w http.ResponseWriter, r *http.Request) {
 
 // GetMovieGenres handles movie genres requests
 func (h *Handlers) GetMovieGenres(w http.ResponseWriter, r *http.Request) {
-	genres, err := h.genreService.GetMovieGenres()
+	genres, err := h.genreService.GetMovieGenres(r.Context())
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get movie genres: %v", err), http.StatusInternalServerError)
+		writeServiceError(w, "Failed to get movie genres", err)
 		return
 	}
 
@@ -523,9 +1053,9 @@ func (h *Handlers) GetMovieGenres(w http.ResponseWriter, r *http.Request) {
 
 // GetTVGenres handles TV show genres requests
 func (h *Handlers) GetTVGenres(w http.ResponseWriter, r *http.Request) {
-	genres, err := h.genreService.GetTVGenres()
+	genres, err := h.genreService.GetTVGenres(r.Context())
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get TV genres: %v", err), http.StatusInternalServerError)
+		writeServiceError(w, "Failed to get TV genres", err)
 		return
 	}
 
@@ -533,9 +1063,37 @@ func (h *Handlers) GetTVGenres(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(genres)
 }
 
-// DiscoverByGenre handles genre-based discovery requests
-func (h *Handlers) DiscoverByGenre(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
+// Discover handles discovery requests with genre, runtime, language,
+// certification, keyword, people and watch provider filters
+func (h *Handlers) Discover(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+	mediaType := mux.Vars(r)["mediaType"]
+
+	filters, page, err := services.ParseDiscoveryFilters(mediaType, r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.genreService.Discover(r.Context(), mediaType, page, filters)
+	if err != nil {
+		writeServiceError(w, "Failed to discover titles", err)
+		return
+	}
+	if results, err = h.filterSearchResult(r.Context(), profileID, mediaType, results); err != nil {
+		writeServiceError(w, "Failed to discover titles", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// DiscoverByGenre handles genre-based discovery requests
+func (h *Handlers) DiscoverByGenre(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	vars := mux.Vars(r)
 	genreIDStr := vars["genreId"]
 
 	genreID, err := strconv.Atoi(genreIDStr)
@@ -556,60 +1114,619 @@ func (h *Handlers) DiscoverByGenre(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse page parameter
-	page := 1
-	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil {
-			page = p
-		}
+	mediaType := "movie"
+	if contentType == "tv" {
+		mediaType = "tv"
 	}
 
-	// Parse filters
-	filters := services.GetDefaultFilters()
-	if sortBy := r.URL.Query().Get("sort_by"); sortBy != "" {
-		filters.SortBy = sortBy
-	}
-	if minRatingStr := r.URL.Query().Get("min_rating"); minRatingStr != "" {
-		if minRating, err := strconv.ParseFloat(minRatingStr, 64); err == nil {
-			filters.MinRating = minRating
-		}
-	}
-	if maxRatingStr := r.URL.Query().Get("max_rating"); maxRatingStr != "" {
-		if maxRating, err := strconv.ParseFloat(maxRatingStr, 64); err == nil {
-			filters.MaxRating = maxRating
-		}
-	}
-	if minYearStr := r.URL.Query().Get("min_year"); minYearStr != "" {
-		if minYear, err := strconv.Atoi(minYearStr); err == nil {
-			filters.MinYear = minYear
-		}
-	}
-	if maxYearStr := r.URL.Query().Get("max_year"); maxYearStr != "" {
-		if maxYear, err := strconv.Atoi(maxYearStr); err == nil {
-			filters.MaxYear = maxYear
-		}
+	// Parse and validate the page and filters as discover does
+	filters, page, err := services.ParseDiscoveryFilters(mediaType, r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	// Validate filters
-	filters.Validate()
-
 	// Call appropriate discovery method based on content type
 	var results *models.SearchResult
 	switch contentType {
 	case "movies":
-		results, err = h.genreService.DiscoverMoviesByGenre(genreID, page, filters)
+		results, err = h.genreService.DiscoverMoviesByGenre(r.Context(), genreID, page, filters)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to discover movies: %v", err), http.StatusInternalServerError)
+			writeServiceError(w, "Failed to discover movies", err)
 			return
 		}
 	case "tv":
-		results, err = h.genreService.DiscoverTVShowsByGenre(genreID, page, filters)
+		results, err = h.genreService.DiscoverTVShowsByGenre(r.Context(), genreID, page, filters)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to discover TV shows: %v", err), http.StatusInternalServerError)
+			writeServiceError(w, "Failed to discover TV shows", err)
 			return
 		}
 	}
 
+	if results, err = h.filterSearchResult(r.Context(), profileID, mediaType, results); err != nil {
+		writeServiceError(w, "Failed to discover titles", err)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(results)
 }
+
+// SearchGenreByKeyword handles keyword-scoped genre search requests
+func (h *Handlers) SearchGenreByKeyword(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	genreID, err := strconv.Atoi(mux.Vars(r)["genreId"])
+	if err != nil {
+		http.Error(w, "Invalid genre ID", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "Query parameter 'q' is required", http.StatusBadRequest)
+		return
+	}
+
+	// Validate query
+	if err := h.discoveryService.ValidateSearchQuery(query); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Parse content type parameter (movies or tv)
+	mediaType := "movie"
+	switch r.URL.Query().Get("type") {
+	case "", "movies":
+	case "tv":
+		mediaType = "tv"
+	default:
+		http.Error(w, "Invalid content type. Must be 'movies' or 'tv'", http.StatusBadRequest)
+		return
+	}
+
+	// Parse page parameter
+	page := 1
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil {
+			page = p
+		}
+	}
+
+	// Validate page
+	if err := h.discoveryService.ValidatePage(page); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.genreService.SearchByGenreAndKeyword(r.Context(), genreID, query, mediaType, page)
+	if err != nil {
+		writeServiceError(w, "Search failed", err)
+		return
+	}
+	if results.Results != nil {
+		// Filter a copy of the search so the service's result is untouched
+		search := *results
+		if search.Results, err = h.filterSearchResult(r.Context(), profileID, mediaType, results.Results); err != nil {
+			writeServiceError(w, "Search failed", err)
+			return
+		}
+		results = &search
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// GetSavedSearches handles requests for the user's saved searches
+func (h *Handlers) GetSavedSearches(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.savedSearchService.GetSavedSearches(profileID))
+}
+
+// CreateSavedSearch handles saving a search to be told about new matches
+func (h *Handlers) CreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	search, ok := h.parseSavedSearch(w, r)
+	if !ok {
+		return
+	}
+
+	saved, err := h.savedSearchService.CreateSavedSearch(profileID, search)
+	if err != nil {
+		writeServiceError(w, "Failed to save search", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(saved)
+}
+
+// GetSavedSearch handles requests for one of the user's saved searches
+func (h *Handlers) GetSavedSearch(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid saved search ID", http.StatusBadRequest)
+		return
+	}
+
+	saved, err := h.savedSearchService.GetSavedSearch(profileID, id)
+	if err != nil {
+		writeServiceError(w, "Failed to get saved search", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// UpdateSavedSearch handles changing one of the user's saved searches
+func (h *Handlers) UpdateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid saved search ID", http.StatusBadRequest)
+		return
+	}
+
+	search, ok := h.parseSavedSearch(w, r)
+	if !ok {
+		return
+	}
+
+	saved, err := h.savedSearchService.UpdateSavedSearch(profileID, id, search)
+	if err != nil {
+		writeServiceError(w, "Failed to update saved search", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// DeleteSavedSearch handles removing one of the user's saved searches
+func (h *Handlers) DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid saved search ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.savedSearchService.DeleteSavedSearch(profileID, id); err != nil {
+		writeServiceError(w, "Failed to delete saved search", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// GetSavedSearchInbox handles requests for titles that newly matched the
+// user's saved searches
+func (h *Handlers) GetSavedSearchInbox(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	inbox := h.savedSearchService.GetInbox(profileID)
+	matches, err := h.parentalService.FilterSavedSearchMatches(r.Context(), profileID, inbox.Matches)
+	if err != nil {
+		writeServiceError(w, "Failed to get saved search inbox", err)
+		return
+	}
+	inbox.Matches = matches
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(inbox)
+}
+
+// ClearSavedSearchInbox handles emptying the user's saved search inbox
+func (h *Handlers) ClearSavedSearchInbox(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	h.savedSearchService.ClearInbox(profileID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// parseSavedSearch reads a saved search from the request body, writing a
+// 400 response if it is invalid
+func (h *Handlers) parseSavedSearch(w http.ResponseWriter, r *http.Request) (models.SavedSearch, bool) {
+	var search models.SavedSearch
+	if err := json.NewDecoder(r.Body).Decode(&search); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return search, false
+	}
+
+	// Searches run in the request's language and region unless given their own
+	locale := services.LocaleFromContext(r.Context())
+	if search.Language == "" {
+		search.Language = locale.Language
+	}
+	if search.Region == "" {
+		search.Region = locale.Region
+	}
+
+	search, err := h.savedSearchService.ParseSavedSearch(search)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return search, false
+	}
+	return search, true
+}
+
+// GetHistory handles requests for the user's recent searches and views
+func (h *Handlers) GetHistory(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.historyService.GetHistory(profileID))
+}
+
+// ClearHistory handles removing the user's recent searches and views
+func (h *Handlers) ClearHistory(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	h.historyService.ClearSearches(profileID)
+	h.historyService.ClearViews(profileID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// GetRecentSearches handles requests for the user's recent searches
+func (h *Handlers) GetRecentSearches(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.historyService.GetRecentSearches(profileID))
+}
+
+// ClearRecentSearches handles removing the user's recent searches
+func (h *Handlers) ClearRecentSearches(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	h.historyService.ClearSearches(profileID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// GetRecentViews handles requests for the user's recently viewed titles
+func (h *Handlers) GetRecentViews(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.historyService.GetRecentViews(profileID))
+}
+
+// ClearRecentViews handles removing the user's recently viewed titles
+func (h *Handlers) ClearRecentViews(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	h.historyService.ClearViews(profileID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// UpdateHistorySettings handles turning the user's history on or off.
+// Turning it off also removes the history recorded so far.
+func (h *Handlers) UpdateHistorySettings(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	var settings struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if settings.Enabled == nil {
+		http.Error(w, "enabled is required", http.StatusBadRequest)
+		return
+	}
+
+	h.historyService.SetEnabled(profileID, *settings.Enabled)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.HistorySettings{Enabled: *settings.Enabled})
+}
+
+// GetParentalControls handles requests for the profile's parental controls
+func (h *Handlers) GetParentalControls(w http.ResponseWriter, r *http.Request) {
+	profileID := currentProfile(r).Key()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.parentalService.GetControls(profileID))
+}
+
+// UpdateParentalControls handles replacing the profile's parental controls.
+// Controls without any certification limits turn filtering off. Kid
+// profiles can't change their own controls; the account owner changes them
+// through the profile.
+func (h *Handlers) UpdateParentalControls(w http.ResponseWriter, r *http.Request) {
+	profile := currentProfile(r)
+	if profile.Kid {
+		writeServiceError(w, "Failed to update parental controls", fmt.Errorf("%w: kid profiles can't change their parental controls", services.ErrProfileForbidden))
+		return
+	}
+	profileID := profile.Key()
+
+	var controls models.ParentalControls
+	if err := json.NewDecoder(r.Body).Decode(&controls); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	controls, err := services.ParseParentalControls(controls)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.parentalService.SetControls(profileID, controls)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.parentalService.GetControls(profileID))
+}
+
+// GetProfiles handles requests for the account's profiles
+func (h *Handlers) GetProfiles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.profileService.GetProfiles(accountID(r)))
+}
+
+// CreateProfile handles adding a profile to the account, which only the
+// owner's profile can do
+func (h *Handlers) CreateProfile(w http.ResponseWriter, r *http.Request) {
+	if err := h.profileService.RequireOwner(accountID(r), currentProfile(r), ownerPIN(r)); err != nil {
+		writeServiceError(w, "Failed to create profile", err)
+		return
+	}
+
+	profile, ok := parseProfile(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.profileService.CreateProfile(accountID(r), profile)
+	if err != nil {
+		writeServiceError(w, "Failed to create profile", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// GetProfile handles requests for one of the account's profiles
+func (h *Handlers) GetProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid profile ID", http.StatusBadRequest)
+		return
+	}
+
+	profile, err := h.profileService.GetProfile(accountID(r), id)
+	if err != nil {
+		writeServiceError(w, "Failed to get profile", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+// UpdateProfile handles changing one of the account's profiles, which only
+// the owner's profile can do
+func (h *Handlers) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid profile ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.profileService.RequireOwner(accountID(r), currentProfile(r), ownerPIN(r)); err != nil {
+		writeServiceError(w, "Failed to update profile", err)
+		return
+	}
+
+	profile, ok := parseProfile(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.profileService.UpdateProfile(accountID(r), id, profile)
+	if err != nil {
+		writeServiceError(w, "Failed to update profile", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteProfile handles removing one of the account's profiles and
+// everything kept for it, which only the owner's profile can do
+func (h *Handlers) DeleteProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid profile ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.profileService.RequireOwner(accountID(r), currentProfile(r), ownerPIN(r)); err != nil {
+		writeServiceError(w, "Failed to delete profile", err)
+		return
+	}
+
+	profile, err := h.profileService.DeleteProfile(accountID(r), id)
+	if err != nil {
+		writeServiceError(w, "Failed to delete profile", err)
+		return
+	}
+
+	profileID := profile.Key()
+	h.watchlistService.DeleteWatchlist(profileID)
+	h.historyService.DeleteHistory(profileID)
+	h.savedSearchService.DeleteSavedSearches(profileID)
+	h.parentalService.SetControls(profileID, models.ParentalControls{})
+	h.calendarService.RevokeWatchlistFeedToken(profileID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// SwitchProfile handles switching the account to one of its profiles for
+// requests that don't select a profile themselves, which takes the owner PIN
+// when switching away from a kid profile
+func (h *Handlers) SwitchProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid profile ID", http.StatusBadRequest)
+		return
+	}
+
+	profile, err := h.profileService.SwitchProfile(accountID(r), id, ownerPIN(r))
+	if err != nil {
+		writeServiceError(w, "Failed to switch profile", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+// SetOwnerPIN handles the account owner setting or changing the PIN that
+// guards owner actions and kid profiles
+func (h *Handlers) SetOwnerPIN(w http.ResponseWriter, r *http.Request) {
+	if err := h.profileService.RequireOwner(accountID(r), currentProfile(r), ownerPIN(r)); err != nil {
+		writeServiceError(w, "Failed to set owner PIN", err)
+		return
+	}
+
+	var request struct {
+		PIN string `json:"pin"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	pin, err := services.ParseOwnerPIN(request.PIN)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.profileService.SetOwnerPIN(accountID(r), pin)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// GetProfileParentalControls handles the account owner's requests for a
+// profile's parental controls
+func (h *Handlers) GetProfileParentalControls(w http.ResponseWriter, r *http.Request) {
+	profile, ok := h.ownedProfile(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.parentalService.GetControls(profile.Key()))
+}
+
+// UpdateProfileParentalControls handles the account owner replacing a
+// profile's parental controls, including a kid profile's
+func (h *Handlers) UpdateProfileParentalControls(w http.ResponseWriter, r *http.Request) {
+	profile, ok := h.ownedProfile(w, r)
+	if !ok {
+		return
+	}
+
+	var controls models.ParentalControls
+	if err := json.NewDecoder(r.Body).Decode(&controls); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	controls, err := services.ParseParentalControls(controls)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.parentalService.SetControls(profile.Key(), controls)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.parentalService.GetControls(profile.Key()))
+}
+
+// GetHouseholdStats handles the account owner's requests for the watchlist
+// statistics of every profile and of the household as a whole
+func (h *Handlers) GetHouseholdStats(w http.ResponseWriter, r *http.Request) {
+	if err := h.profileService.RequireOwner(accountID(r), currentProfile(r), ownerPIN(r)); err != nil {
+		writeServiceError(w, "Failed to get household stats", err)
+		return
+	}
+
+	stats, err := h.watchlistService.GetHouseholdStats(h.profileService.GetProfiles(accountID(r)))
+	if err != nil {
+		writeServiceError(w, "Failed to get household stats", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// ownedProfile reads the profile a request is about, writing an error
+// response and returning false unless it is made as the account owner's
+// profile
+func (h *Handlers) ownedProfile(w http.ResponseWriter, r *http.Request) (models.Profile, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid profile ID", http.StatusBadRequest)
+		return models.Profile{}, false
+	}
+
+	if err := h.profileService.RequireOwner(accountID(r), currentProfile(r), ownerPIN(r)); err != nil {
+		writeServiceError(w, "Failed to access profile", err)
+		return models.Profile{}, false
+	}
+
+	profile, err := h.profileService.GetProfile(accountID(r), id)
+	if err != nil {
+		writeServiceError(w, "Failed to access profile", err)
+		return models.Profile{}, false
+	}
+	return profile, true
+}
+
+// parseProfile decodes and validates a profile from a request body, writing
+// a 400 response and returning false if it is invalid
+func parseProfile(w http.ResponseWriter, r *http.Request) (models.Profile, bool) {
+	var profile models.Profile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return profile, false
+	}
+
+	profile, err := services.ParseProfile(profile)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return profile, false
+	}
+	return profile, true
+}
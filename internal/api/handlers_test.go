@@ -2,15 +2,21 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"movie-discovery-app/configs"
 	"movie-discovery-app/internal/models"
 	"movie-discovery-app/internal/services"
+
+	"github.com/gorilla/mux"
 )
 
 func setupTestHandlers() *Handlers {
@@ -33,10 +39,17 @@ func setupTestHandlers() *Handlers {
 
 	discoveryService := services.NewDiscoveryService(config)
 	watchlistService := services.NewWatchlistService()
-	recommendationService := services.NewRecommendationService(discoveryService, watchlistService)
+	historyService := services.NewHistoryService(config)
+	parentalService := services.NewParentalControlService(config, discoveryService)
+	profileService := services.NewProfileService(parentalService)
+	recommendationService := services.NewRecommendationService(discoveryService, watchlistService, historyService, parentalService)
 	genreService := services.NewGenreService(config)
+	peopleService := services.NewPeopleService(config, watchlistService, parentalService)
+	collectionService := services.NewCollectionService(config, watchlistService, parentalService)
+	calendarService := services.NewCalendarService(config, watchlistService, parentalService)
+	savedSearchService := services.NewSavedSearchService(config, discoveryService)
 
-	return NewHandlers(discoveryService, watchlistService, recommendationService, genreService)
+	return NewHandlers(discoveryService, watchlistService, recommendationService, genreService, peopleService, collectionService, calendarService, savedSearchService, historyService, parentalService, profileService)
 }
 
 func TestHandlers_HealthCheck(t *testing.T) {
@@ -56,7 +69,7 @@ func TestHandlers_HealthCheck(t *testing.T) {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 	}
 
-	var response map[string]string
+	var response map[string]interface{}
 	err = json.Unmarshal(rr.Body.Bytes(), &response)
 	if err != nil {
 		t.Errorf("Failed to parse response: %v", err)
@@ -69,6 +82,37 @@ func TestHandlers_HealthCheck(t *testing.T) {
 	if response["service"] != "movie-discovery-app" {
 		t.Errorf("Expected service 'movie-discovery-app', got '%s'", response["service"])
 	}
+
+	upstreams, ok := response["upstreams"].([]interface{})
+	if !ok || len(upstreams) != 4 {
+		t.Errorf("Expected 4 upstreams in health response, got %v", response["upstreams"])
+	}
+
+	quota, ok := response["youtube_quota"].(map[string]interface{})
+	if !ok || quota["budget"] == nil || quota["used"] == nil || quota["resets_at"] == nil {
+		t.Errorf("Expected YouTube quota in health response, got %v", response["youtube_quota"])
+	}
+}
+
+func TestHandlers_Metrics(t *testing.T) {
+	handlers := setupTestHandlers()
+
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.Metrics(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	expected := `upstream_circuit_breaker_state{upstream="omdb",state="closed"} 1`
+	if !strings.Contains(rr.Body.String(), expected) {
+		t.Errorf("Expected metrics to contain %q, got:\n%s", expected, rr.Body.String())
+	}
 }
 
 func TestHandlers_SearchMovies_InvalidQuery(t *testing.T) {
@@ -117,6 +161,459 @@ func TestHandlers_SearchMovies_InvalidQuery(t *testing.T) {
 	}
 }
 
+func TestHandlers_GetMovieDetails_InvalidInclude(t *testing.T) {
+	handlers := setupTestHandlers()
+
+	req, err := http.NewRequest("GET", "/api/v1/movies/27205?include=credits,reviews", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{"id": "27205"})
+
+	rr := httptest.NewRecorder()
+	handlers.GetMovieDetails(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+}
+
+func TestHandlers_SearchPeople_MissingQuery(t *testing.T) {
+	handlers := setupTestHandlers()
+
+	req, err := http.NewRequest("GET", "/api/v1/people/search", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.SearchPeople(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+}
+
+func TestHandlers_GetCalendar_InvalidRange(t *testing.T) {
+	handlers := setupTestHandlers()
+
+	for _, query := range []string{"region=USA", "from=2024-03-01&to=2024-02-01", "watchlist=maybe"} {
+		req, err := http.NewRequest("GET", "/api/v1/calendar?"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		handlers.GetCalendar(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", query, status, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestHandlers_SearchMovies_InvalidStructuredQuery(t *testing.T) {
+	handlers := setupTestHandlers()
+
+	req, err := http.NewRequest("GET", "/api/v1/search/movies?q=directr:Villeneuve", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.SearchMovies(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+	if !strings.Contains(rr.Body.String(), `did you mean "director"?`) {
+		t.Errorf("Expected a suggestion in the error, got %q", rr.Body.String())
+	}
+}
+
+func TestHandlers_Discover_InvalidFilters(t *testing.T) {
+	handlers := setupTestHandlers()
+
+	for _, query := range []string{"genres=action", "min_runtime=150&max_runtime=90", "providers=8", "cast=6193"} {
+		req, err := http.NewRequest("GET", "/api/v1/discover/tv?"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = mux.SetURLVars(req, map[string]string{"mediaType": "tv"})
+
+		rr := httptest.NewRecorder()
+		handlers.Discover(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", query, status, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestHandlers_DiscoverByGenre_InvalidFilters(t *testing.T) {
+	handlers := setupTestHandlers()
+
+	for _, query := range []string{"min_year=recent", "max_rating=high", "min_runtime=150&max_runtime=90", "page=0", "type=tv&cast=6193"} {
+		req, err := http.NewRequest("GET", "/api/v1/discover/genre/28?"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = mux.SetURLVars(req, map[string]string{"genreId": "28"})
+
+		rr := httptest.NewRecorder()
+		handlers.DiscoverByGenre(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", query, status, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestHandlers_Autocomplete_InvalidLimit(t *testing.T) {
+	handlers := setupTestHandlers()
+
+	for _, query := range []string{"q=dark&limit=0", "q=dark&limit=21", "q=dark&limit=five", "limit=5"} {
+		req, err := http.NewRequest("GET", "/api/v1/autocomplete?"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		handlers.Autocomplete(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", query, status, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestHandlers_GetWatchlistFeed_UnknownToken(t *testing.T) {
+	handlers := setupTestHandlers()
+
+	req, err := http.NewRequest("GET", "/api/v1/feeds/deadbeef/watchlist.ics", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{"token": "deadbeef"})
+
+	rr := httptest.NewRecorder()
+	handlers.GetWatchlistFeed(rr, req)
+
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+	}
+}
+
+func TestHandlers_SavedSearches(t *testing.T) {
+	handlers := setupTestHandlers()
+
+	for _, body := range []string{`{"name": "Sci-fi"`, `{"name": "Sci-fi", "media_type": "movie"}`, `{"name": "Sci-fi", "media_type": "movie", "discover": "min_rating=high"}`} {
+		req, err := http.NewRequest("POST", "/api/v1/saved-searches", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		handlers.CreateSavedSearch(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", body, status, http.StatusBadRequest)
+		}
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/saved-searches", bytes.NewBufferString(`{"name": "Sci-fi", "media_type": "movie", "discover": "genres=878"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handlers.CreateSavedSearch(rr, req)
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+
+	var saved models.SavedSearch
+	if err := json.Unmarshal(rr.Body.Bytes(), &saved); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		id     string
+		status int
+	}{
+		{strconv.Itoa(saved.ID), http.StatusOK},
+		{"999", http.StatusNotFound},
+	} {
+		req, err := http.NewRequest("GET", "/api/v1/saved-searches/"+tt.id, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+
+		rr := httptest.NewRecorder()
+		handlers.GetSavedSearch(rr, req)
+
+		if status := rr.Code; status != tt.status {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", tt.id, status, tt.status)
+		}
+	}
+}
+
+func TestHandlers_UpdateHistorySettings(t *testing.T) {
+	handlers := setupTestHandlers()
+
+	for _, tt := range []struct {
+		body   string
+		status int
+	}{
+		{`{"enabled": false}`, http.StatusOK},
+		{`{}`, http.StatusBadRequest},
+		{`{"enabled": "no"}`, http.StatusBadRequest},
+	} {
+		req, err := http.NewRequest("PUT", "/api/v1/history/settings", bytes.NewBufferString(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		handlers.UpdateHistorySettings(rr, req)
+
+		if status := rr.Code; status != tt.status {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", tt.body, status, tt.status)
+		}
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/history", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handlers.GetHistory(rr, req)
+
+	var history models.History
+	if err := json.Unmarshal(rr.Body.Bytes(), &history); err != nil {
+		t.Fatal(err)
+	}
+	if history.Enabled {
+		t.Error("Expected history to be disabled")
+	}
+}
+
+func TestHandlers_UpdateParentalControls(t *testing.T) {
+	handlers := setupTestHandlers()
+
+	for _, tt := range []struct {
+		body   string
+		status int
+	}{
+		{`{"movie_certifications": {"us": "pg-13"}, "tv_certifications": {"US": "TV-PG"}}`, http.StatusOK},
+		{`{"movie_certifications": {"US": "TV-PG"}}`, http.StatusBadRequest},
+		{`{"movie_certifications": {"XX": "PG"}}`, http.StatusBadRequest},
+		{`{"unknown_certifications": "sometimes"}`, http.StatusBadRequest},
+		{`not json`, http.StatusBadRequest},
+	} {
+		req, err := http.NewRequest("PUT", "/api/v1/parental-controls", bytes.NewBufferString(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		handlers.UpdateParentalControls(rr, req)
+
+		if status := rr.Code; status != tt.status {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", tt.body, status, tt.status)
+		}
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/parental-controls", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handlers.GetParentalControls(rr, req)
+
+	var controls models.ParentalControls
+	if err := json.Unmarshal(rr.Body.Bytes(), &controls); err != nil {
+		t.Fatal(err)
+	}
+	if controls.MovieCertifications["US"] != "PG-13" || controls.TVCertifications["US"] != "TV-PG" || controls.UnknownCertifications != "strict" {
+		t.Errorf("Expected the saved limits with the default policy, got %+v", controls)
+	}
+}
+
+func TestHandlers_Profiles(t *testing.T) {
+	router := SetupRouter(setupTestHandlers(), 0)
+
+	serve := func(method, path, profileID, pin, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if profileID != "" {
+			req.Header.Set("X-Profile-ID", profileID)
+		}
+		if pin != "" {
+			req.Header.Set("X-Profile-PIN", pin)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Kid profiles need the owner PIN first
+	if rr := serve("POST", "/api/v1/profiles", "", "", `{"name": "Sam", "kid": true}`); rr.Code != http.StatusConflict {
+		t.Fatalf("Expected a kid profile to need the owner PIN, got %d", rr.Code)
+	}
+	if rr := serve("PUT", "/api/v1/profiles/owner-pin", "", "", `{"pin": "12"}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected a short PIN to be refused, got %d", rr.Code)
+	}
+	if rr := serve("PUT", "/api/v1/profiles/owner-pin", "", "", `{"pin": "2468"}`); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the owner PIN to be set, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := serve("POST", "/api/v1/profiles", "", "", `{"name": "Sam", "kid": true}`); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected owner actions to need the PIN, got %d", rr.Code)
+	}
+
+	// The owner's profile is used until another is selected
+	rr := serve("POST", "/api/v1/profiles", "", "2468", `{"name": "Sam", "avatar": "fox", "kid": true}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected the owner to create a profile, got %d: %s", rr.Code, rr.Body.String())
+	}
+	owner := rr.Header().Get("X-Profile-ID")
+	var kid models.Profile
+	if err := json.Unmarshal(rr.Body.Bytes(), &kid); err != nil {
+		t.Fatal(err)
+	}
+	kidID := strconv.Itoa(kid.ID)
+
+	// Each profile has its own watchlist
+	if rr := serve("POST", "/api/v1/watchlist", kidID, "", `{"id": "123", "type": "movie", "title": "Test Movie"}`); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the item to be added, got %d", rr.Code)
+	}
+	for profileID, expected := range map[string]int{kidID: 1, owner: 0} {
+		var watchlist []models.WatchlistItem
+		json.Unmarshal(serve("GET", "/api/v1/watchlist", profileID, "", "").Body.Bytes(), &watchlist)
+		if len(watchlist) != expected {
+			t.Errorf("profile %s: expected %d watchlist items, got %d", profileID, expected, len(watchlist))
+		}
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		profileID      string
+		pin            string
+		body           string
+		expectedStatus int
+	}{
+		{"Invalid profile header", "GET", "/api/v1/watchlist", "sam", "", "", http.StatusBadRequest},
+		{"Unknown profile header", "GET", "/api/v1/watchlist", "999", "", "", http.StatusNotFound},
+		{"Kid changing own parental controls", "PUT", "/api/v1/parental-controls", kidID, "", `{"movie_certifications": {"US": "R"}}`, http.StatusForbidden},
+		{"Kid creating a profile", "POST", "/api/v1/profiles", kidID, "2468", `{"name": "Alex"}`, http.StatusForbidden},
+		{"Kid reading household stats", "GET", "/api/v1/household/stats", kidID, "", "", http.StatusForbidden},
+		{"Duplicate profile name", "POST", "/api/v1/profiles", owner, "2468", `{"name": "sam"}`, http.StatusConflict},
+		{"Missing profile name", "POST", "/api/v1/profiles", owner, "2468", `{"avatar": "fox"}`, http.StatusBadRequest},
+		{"Deleting the owner's profile", "DELETE", "/api/v1/profiles/" + owner, owner, "2468", "", http.StatusForbidden},
+		{"Owner changing kid's parental controls", "PUT", "/api/v1/profiles/" + kidID + "/parental-controls", owner, "2468", `{"movie_certifications": {"US": "G"}}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if rr := serve(tt.method, tt.path, tt.profileID, tt.pin, tt.body); rr.Code != tt.expectedStatus {
+			t.Errorf("%s: got status %d want %d", tt.name, rr.Code, tt.expectedStatus)
+		}
+	}
+
+	rr = serve("GET", "/api/v1/household/stats", owner, "2468", "")
+	var household models.HouseholdStats
+	if err := json.Unmarshal(rr.Body.Bytes(), &household); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(household.Profiles) != 2 || household.Totals["total_items"] != 1.0 || household.Totals["unique_titles"] != 1.0 {
+		t.Errorf("Expected stats for both profiles with one title, got %+v", household)
+	}
+
+	// Switching changes the profile used without the header
+	if rr := serve("POST", "/api/v1/profiles/"+kidID+"/switch", "", "", ""); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the switch to succeed, got %d", rr.Code)
+	}
+	if selected := serve("GET", "/api/v1/watchlist", "", "", "").Header().Get("X-Profile-ID"); selected != kidID {
+		t.Errorf("Expected profile %s after switching, got %s", kidID, selected)
+	}
+
+	// A kid profile can't get out of its limits without the owner PIN
+	for _, tt := range []struct {
+		name      string
+		method    string
+		path      string
+		profileID string
+		pin       string
+		body      string
+	}{
+		{"Lifting own limits", "PUT", "/api/v1/parental-controls", "", "", `{}`},
+		{"Lifting own limits through the owner's endpoint", "PUT", "/api/v1/profiles/" + kidID + "/parental-controls", "", "", `{}`},
+		{"Selecting the owner's profile", "GET", "/api/v1/watchlist", owner, "", ""},
+		{"Selecting the owner's profile with a wrong PIN", "GET", "/api/v1/watchlist", owner, "1357", ""},
+		{"Switching to the owner's profile", "POST", "/api/v1/profiles/" + owner + "/switch", "", "", ""},
+	} {
+		if rr := serve(tt.method, tt.path, tt.profileID, tt.pin, tt.body); rr.Code != http.StatusForbidden {
+			t.Errorf("%s: got status %d want %d", tt.name, rr.Code, http.StatusForbidden)
+		}
+	}
+	if controls := serve("GET", "/api/v1/parental-controls", "", "", "").Body.String(); !strings.Contains(controls, `"US":"G"`) {
+		t.Errorf("Expected the kid's limits to be unchanged, got %s", controls)
+	}
+
+	if rr := serve("POST", "/api/v1/profiles/"+owner+"/switch", "", "2468", ""); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the owner PIN to switch back, got %d", rr.Code)
+	}
+	if selected := serve("GET", "/api/v1/watchlist", "", "", "").Header().Get("X-Profile-ID"); selected != owner {
+		t.Errorf("Expected profile %s after switching back, got %s", owner, selected)
+	}
+}
+
+func TestHandlers_Localize(t *testing.T) {
+	handlers := setupTestHandlers()
+
+	var locale services.Locale
+	handler := handlers.Localize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale = services.LocaleFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		query          string
+		acceptLanguage string
+		expectedStatus int
+		expected       services.Locale
+	}{
+		{"", "", http.StatusOK, services.Locale{}},
+		{"", "fr-CA,fr;q=0.9", http.StatusOK, services.Locale{Language: "fr-CA"}},
+		{"language=de&region=at", "fr-CA", http.StatusOK, services.Locale{Language: "de", Region: "AT"}},
+		{"language=deutsch", "", http.StatusBadRequest, services.Locale{}},
+		{"region=AUT", "", http.StatusBadRequest, services.Locale{}},
+	}
+
+	for _, tt := range tests {
+		locale = services.Locale{}
+		req, err := http.NewRequest("GET", "/api/v1/search/movies?"+tt.query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept-Language", tt.acceptLanguage)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != tt.expectedStatus {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", tt.query, status, tt.expectedStatus)
+		}
+		if locale != tt.expected {
+			t.Errorf("%s: expected locale %+v, got %+v", tt.query, tt.expected, locale)
+		}
+		if tt.expectedStatus == http.StatusOK && rr.Header().Get("Content-Language") != tt.expected.Language {
+			t.Errorf("%s: expected Content-Language %q, got %q", tt.query, tt.expected.Language, rr.Header().Get("Content-Language"))
+		}
+	}
+}
+
 func TestHandlers_AddToWatchlist(t *testing.T) {
 	handlers := setupTestHandlers()
 
@@ -307,7 +804,7 @@ func TestHandlers_CORS(t *testing.T) {
 	expectedHeaders := map[string]string{
 		"Access-Control-Allow-Origin":  "*",
 		"Access-Control-Allow-Methods": "GET, POST, PUT, DELETE, OPTIONS",
-		"Access-Control-Allow-Headers": "Content-Type, Authorization",
+		"Access-Control-Allow-Headers": "Content-Type, Authorization, X-Profile-ID, X-Profile-PIN",
 	}
 
 	for header, expectedValue := range expectedHeaders {
@@ -339,3 +836,84 @@ func BenchmarkHandlers_GetWatchlist(b *testing.B) {
 		handlers.GetWatchlist(rr, req)
 	}
 }
+
+func TestWriteServiceError_StatusMapping(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Not found",
+			err:            fmt.Errorf("wrapped: %w", &services.UpstreamError{Service: "TMDB", StatusCode: 404, Kind: services.ErrUpstreamNotFound}),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Rate limited",
+			err:            &services.UpstreamError{Service: "TMDB", StatusCode: 429, Kind: services.ErrUpstreamRateLimited, RetryAfter: 3 * time.Second},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:           "Unavailable",
+			err:            &services.UpstreamError{Service: "OMDB", Kind: services.ErrUpstreamUnavailable},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "Blocked by parental controls",
+			err:            fmt.Errorf("%w: rated R in US, above the PG-13 limit", services.ErrTitleBlocked),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Not allowed for the profile",
+			err:            fmt.Errorf("%w: only the account owner's profile can do this", services.ErrProfileForbidden),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Owner PIN not set",
+			err:            fmt.Errorf("failed to create profile: %w", services.ErrOwnerPINNotSet),
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Unknown profile",
+			err:            services.ErrProfileNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Profile limit",
+			err:            services.ErrProfileLimit,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Saved search limit",
+			err:            services.ErrSavedSearchLimit,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Request budget exhausted",
+			err:            fmt.Errorf("failed to search movies: %w", context.DeadlineExceeded),
+			expectedStatus: http.StatusGatewayTimeout,
+		},
+		{
+			name:           "Other error",
+			err:            fmt.Errorf("boom"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			writeServiceError(rr, "Failed", tt.err)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	writeServiceError(rr, "Failed", tests[1].err)
+	if retryAfter := rr.Header().Get("Retry-After"); retryAfter != "3" {
+		t.Errorf("Expected Retry-After header '3', got '%s'", retryAfter)
+	}
+}
@@ -2,12 +2,15 @@ package api
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
-// SetupRouter sets up all routes for the application
-func SetupRouter(handlers *Handlers) *mux.Router {
+// SetupRouter sets up all routes for the application. API requests are
+// bounded by requestTimeout, localized by their language and region and made
+// as the selected household profile.
+func SetupRouter(handlers *Handlers, requestTimeout time.Duration) *mux.Router {
 	r := mux.NewRouter()
 
 	// Apply middleware
@@ -16,6 +19,9 @@ func SetupRouter(handlers *Handlers) *mux.Router {
 
 	// API routes
 	api := r.PathPrefix("/api/v1").Subrouter()
+	api.Use(handlers.RequestTimeout(requestTimeout))
+	api.Use(handlers.Localize)
+	api.Use(handlers.SelectProfile)
 
 	// Health check
 	api.HandleFunc("/health", handlers.HealthCheck).Methods("GET")
@@ -23,6 +29,7 @@ func SetupRouter(handlers *Handlers) *mux.Router {
 	// Search endpoints
 	api.HandleFunc("/search/movies", handlers.SearchMovies).Methods("GET")
 	api.HandleFunc("/search/tv", handlers.SearchTVShows).Methods("GET")
+	api.HandleFunc("/autocomplete", handlers.Autocomplete).Methods("GET")
 
 	// Movie details
 	api.HandleFunc("/movies/{id:[0-9]+}", handlers.GetMovieDetails).Methods("GET")
@@ -30,9 +37,60 @@ func SetupRouter(handlers *Handlers) *mux.Router {
 	// TV show details
 	api.HandleFunc("/tv/{id:[0-9]+}", handlers.GetTVShowDetails).Methods("GET")
 
+	// People
+	api.HandleFunc("/people/search", handlers.SearchPeople).Methods("GET")
+	api.HandleFunc("/people/{id:[0-9]+}", handlers.GetPerson).Methods("GET")
+
+	// Collections
+	api.HandleFunc("/collections/{id:[0-9]+}", handlers.GetCollection).Methods("GET")
+	api.HandleFunc("/collections/{id:[0-9]+}/watchlist", handlers.AddCollectionToWatchlist).Methods("POST")
+
 	// Trending content
 	api.HandleFunc("/trending/movies", handlers.GetTrendingMovies).Methods("GET")
 
+	// Release calendar
+	api.HandleFunc("/calendar", handlers.GetCalendar).Methods("GET")
+	api.HandleFunc("/calendar.ics", handlers.GetCalendarICS).Methods("GET")
+
+	// Watchlist calendar feed, authorized by the secret token in its URL
+	api.HandleFunc("/watchlist/feed", handlers.GetWatchlistFeedURL).Methods("GET")
+	api.HandleFunc("/watchlist/feed/rotate", handlers.RotateWatchlistFeedURL).Methods("POST")
+	api.HandleFunc("/feeds/{token:[0-9a-f]+}/watchlist.ics", handlers.GetWatchlistFeed).Methods("GET")
+
+	// Saved searches and the inbox of their new matches
+	api.HandleFunc("/saved-searches", handlers.GetSavedSearches).Methods("GET")
+	api.HandleFunc("/saved-searches", handlers.CreateSavedSearch).Methods("POST")
+	api.HandleFunc("/saved-searches/inbox", handlers.GetSavedSearchInbox).Methods("GET")
+	api.HandleFunc("/saved-searches/inbox", handlers.ClearSavedSearchInbox).Methods("DELETE")
+	api.HandleFunc("/saved-searches/{id:[0-9]+}", handlers.GetSavedSearch).Methods("GET")
+	api.HandleFunc("/saved-searches/{id:[0-9]+}", handlers.UpdateSavedSearch).Methods("PUT")
+	api.HandleFunc("/saved-searches/{id:[0-9]+}", handlers.DeleteSavedSearch).Methods("DELETE")
+
+	// Recent searches and views
+	api.HandleFunc("/history", handlers.GetHistory).Methods("GET")
+	api.HandleFunc("/history", handlers.ClearHistory).Methods("DELETE")
+	api.HandleFunc("/history/searches", handlers.GetRecentSearches).Methods("GET")
+	api.HandleFunc("/history/searches", handlers.ClearRecentSearches).Methods("DELETE")
+	api.HandleFunc("/history/views", handlers.GetRecentViews).Methods("GET")
+	api.HandleFunc("/history/views", handlers.ClearRecentViews).Methods("DELETE")
+	api.HandleFunc("/history/settings", handlers.UpdateHistorySettings).Methods("PUT")
+
+	// Household profiles, managed by the account owner's profile
+	api.HandleFunc("/profiles", handlers.GetProfiles).Methods("GET")
+	api.HandleFunc("/profiles", handlers.CreateProfile).Methods("POST")
+	api.HandleFunc("/profiles/owner-pin", handlers.SetOwnerPIN).Methods("PUT")
+	api.HandleFunc("/profiles/{id:[0-9]+}", handlers.GetProfile).Methods("GET")
+	api.HandleFunc("/profiles/{id:[0-9]+}", handlers.UpdateProfile).Methods("PUT")
+	api.HandleFunc("/profiles/{id:[0-9]+}", handlers.DeleteProfile).Methods("DELETE")
+	api.HandleFunc("/profiles/{id:[0-9]+}/switch", handlers.SwitchProfile).Methods("POST")
+	api.HandleFunc("/profiles/{id:[0-9]+}/parental-controls", handlers.GetProfileParentalControls).Methods("GET")
+	api.HandleFunc("/profiles/{id:[0-9]+}/parental-controls", handlers.UpdateProfileParentalControls).Methods("PUT")
+	api.HandleFunc("/household/stats", handlers.GetHouseholdStats).Methods("GET")
+
+	// Parental controls
+	api.HandleFunc("/parental-controls", handlers.GetParentalControls).Methods("GET")
+	api.HandleFunc("/parental-controls", handlers.UpdateParentalControls).Methods("PUT")
+
 	// Recommendations
 	api.HandleFunc("/recommendations", handlers.GetRecommendations).Methods("GET")
 
@@ -40,6 +98,8 @@ func SetupRouter(handlers *Handlers) *mux.Router {
 	api.HandleFunc("/genres/movies", handlers.GetMovieGenres).Methods("GET")
 	api.HandleFunc("/genres/tv", handlers.GetTVGenres).Methods("GET")
 	api.HandleFunc("/discover/genre/{genreId:[0-9]+}", handlers.DiscoverByGenre).Methods("GET")
+	api.HandleFunc("/discover/genre/{genreId:[0-9]+}/search", handlers.SearchGenreByKeyword).Methods("GET")
+	api.HandleFunc("/discover/{mediaType:movie|tv}", handlers.Discover).Methods("GET")
 
 	// Watchlist endpoints
 	api.HandleFunc("/watchlist", handlers.GetWatchlist).Methods("GET")
@@ -60,6 +120,9 @@ func SetupRouter(handlers *Handlers) *mux.Router {
 	api.HandleFunc("/{type}/{id}/providers", handlers.GetWatchProviders).Methods("GET")
 	api.HandleFunc("/{type}/{id}/streaming", handlers.GetStreamingServices).Methods("GET")
 
+	// Metrics
+	r.HandleFunc("/metrics", handlers.Metrics).Methods("GET")
+
 	// Static file serving
 	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("./web/static/"))))
 
@@ -0,0 +1,38 @@
+package models
+
+// Suggestion is an autocomplete suggestion for a movie, TV show or person
+type Suggestion struct {
+	ID         int    `json:"id"`
+	MediaType  string `json:"media_type"` // movie, tv or person
+	Title      string `json:"title"`      // Title, or name for TV shows and people
+	Year       string `json:"year,omitempty"`
+	PosterPath string `json:"poster_path,omitempty"` // Profile photo for people
+}
+
+// AutocompleteResult represents autocomplete suggestions for a query
+type AutocompleteResult struct {
+	Query       string       `json:"query"`
+	Suggestions []Suggestion `json:"suggestions"`
+	Partial     bool         `json:"partial,omitempty"` // TMDB or the parental controls check didn't finish in time
+}
+
+// MultiSearchResult represents a page of TMDB multi search results
+type MultiSearchResult struct {
+	Page         int               `json:"page"`
+	Results      []MultiSearchItem `json:"results"`
+	TotalPages   int               `json:"total_pages"`
+	TotalResults int               `json:"total_results"`
+}
+
+// MultiSearchItem is a movie, TV show or person in multi search results
+type MultiSearchItem struct {
+	ID           int     `json:"id"`
+	MediaType    string  `json:"media_type"`
+	Title        string  `json:"title"`
+	Name         string  `json:"name"`
+	ReleaseDate  string  `json:"release_date"`
+	FirstAirDate string  `json:"first_air_date"`
+	PosterPath   string  `json:"poster_path"`
+	ProfilePath  string  `json:"profile_path"`
+	Popularity   float64 `json:"popularity"`
+}
@@ -0,0 +1,45 @@
+package models
+
+// MovieListResponse represents a page of TMDB's upcoming or now playing
+// movies
+type MovieListResponse struct {
+	Dates        *DateRange `json:"dates,omitempty"`
+	Page         int        `json:"page"`
+	Results      []Movie    `json:"results"`
+	TotalPages   int        `json:"total_pages"`
+	TotalResults int        `json:"total_results"`
+}
+
+// DateRange represents the release window a movie list covers
+type DateRange struct {
+	Minimum string `json:"minimum"`
+	Maximum string `json:"maximum"`
+}
+
+// Calendar represents releases in a region grouped by date
+type Calendar struct {
+	Region string        `json:"region"`
+	From   string        `json:"from"`
+	To     string        `json:"to"`
+	Days   []CalendarDay `json:"days"`
+}
+
+// CalendarDay represents the releases on one date
+type CalendarDay struct {
+	Date     string            `json:"date"` // YYYY-MM-DD
+	Releases []CalendarRelease `json:"releases"`
+}
+
+// CalendarRelease represents one release of a movie, e.g. its theatrical or
+// digital release
+type CalendarRelease struct {
+	MovieID       int     `json:"movie_id"`
+	Title         string  `json:"title"`
+	PosterPath    string  `json:"poster_path"`
+	Popularity    float64 `json:"popularity"`
+	ReleaseType   string  `json:"release_type"` // premiere, theatrical_limited, theatrical, digital, physical or tv
+	Certification string  `json:"certification,omitempty"`
+	Note          string  `json:"note,omitempty"`
+	InWatchlist   bool    `json:"in_watchlist"`
+	Watched       bool    `json:"watched"`
+}
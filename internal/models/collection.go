@@ -0,0 +1,40 @@
+package models
+
+// CollectionSummary represents the collection a movie belongs to
+type CollectionSummary struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	PosterPath   string `json:"poster_path"`
+	BackdropPath string `json:"backdrop_path"`
+}
+
+// Collection represents a franchise of movies, e.g. all the Alien films
+type Collection struct {
+	ID           int                 `json:"id"`
+	Name         string              `json:"name"`
+	Overview     string              `json:"overview"`
+	PosterPath   string              `json:"poster_path"`
+	BackdropPath string              `json:"backdrop_path"`
+	Parts        []CollectionPart    `json:"parts"`
+	Progress     *CollectionProgress `json:"progress,omitempty"`
+}
+
+// CollectionPart represents one movie in a collection
+type CollectionPart struct {
+	ID          int     `json:"id"`
+	Title       string  `json:"title"`
+	Overview    string  `json:"overview"`
+	ReleaseDate string  `json:"release_date"`
+	PosterPath  string  `json:"poster_path"`
+	VoteAverage float64 `json:"vote_average"`
+	VoteCount   int     `json:"vote_count"`
+	InWatchlist bool    `json:"in_watchlist"`
+	Watched     bool    `json:"watched"`
+}
+
+// CollectionProgress represents how much of a collection a user has seen
+type CollectionProgress struct {
+	Total       int `json:"total"`
+	Watched     int `json:"watched"`
+	InWatchlist int `json:"in_watchlist"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// RecentSearch is a search a user made
+type RecentSearch struct {
+	Query      string    `json:"query"`
+	MediaType  string    `json:"media_type"` // movie or tv
+	SearchedAt time.Time `json:"searched_at"`
+}
+
+// RecentView is a movie or TV show whose details a user opened
+type RecentView struct {
+	ID         int       `json:"id"`
+	MediaType  string    `json:"media_type"`
+	Title      string    `json:"title"`
+	PosterPath string    `json:"poster_path,omitempty"`
+	GenreIDs   []int     `json:"genre_ids,omitempty"`
+	ViewedAt   time.Time `json:"viewed_at"`
+}
+
+// History is a user's recent searches and views, newest first
+type History struct {
+	Enabled  bool           `json:"enabled"` // False when the user has opted out of history
+	Searches []RecentSearch `json:"searches"`
+	Views    []RecentView   `json:"views"`
+}
+
+// HistorySettings are a user's history preferences
+type HistorySettings struct {
+	Enabled bool `json:"enabled"`
+}
@@ -1,21 +1,35 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Movie represents a movie with combined data from TMDB and OMDB
 type Movie struct {
-	ID           int     `json:"id"`
-	Title        string  `json:"title"`
-	Overview     string  `json:"overview"`
-	ReleaseDate  string  `json:"release_date"`
-	PosterPath   string  `json:"poster_path"`
-	BackdropPath string  `json:"backdrop_path"`
-	VoteAverage  float64 `json:"vote_average"`
-	VoteCount    int     `json:"vote_count"`
-	Popularity   float64 `json:"popularity"`
-	GenreIDs     []int   `json:"genre_ids"`
-	Genres       []Genre `json:"genres"`
-	Runtime      int     `json:"runtime"`
+	ID            int     `json:"id"`
+	Title         string  `json:"title"`
+	OriginalTitle string  `json:"original_title"`
+	Overview      string  `json:"overview"`
+	ReleaseDate   string  `json:"release_date"`
+	PosterPath    string  `json:"poster_path"`
+	BackdropPath  string  `json:"backdrop_path"`
+	VoteAverage   float64 `json:"vote_average"`
+	VoteCount     int     `json:"vote_count"`
+	Popularity    float64 `json:"popularity"`
+	GenreIDs      []int   `json:"genre_ids"`
+	Genres        []Genre `json:"genres"`
+	Runtime       int     `json:"runtime"`
+
+	BelongsToCollection *CollectionSummary `json:"belongs_to_collection,omitempty"`
+
+	// Sections appended to TMDB details on request
+	Credits      *Credits         `json:"credits,omitempty"`
+	Keywords     *KeywordList     `json:"keywords,omitempty"`
+	Images       *Images          `json:"images,omitempty"`
+	ReleaseDates *ReleaseDates    `json:"release_dates,omitempty"`
+	Videos       *TrailerResponse `json:"videos,omitempty"`
+	ExternalIDs  *ExternalIDs     `json:"external_ids,omitempty"`
 
 	// OMDB specific fields
 	IMDBRating     string `json:"imdb_rating"`
@@ -28,12 +42,25 @@ type Movie struct {
 	Country        string `json:"country"`
 	Awards         string `json:"awards"`
 	IMDBId         string `json:"imdb_id"`
+
+	// Ratings, certification, box office and fallback poster from OMDB
+	OMDBDetails
+
+	// How reliably the OMDB data belongs to this movie: 1 when matched by IMDb ID, lower for title lookups
+	MatchConfidence float64 `json:"match_confidence,omitempty"`
+
+	// Combined score across TMDB, IMDb, Rotten Tomatoes and Metacritic
+	Composite *CompositeScore `json:"composite,omitempty"`
+
+	// Upstreams that were skipped because they are unavailable
+	Degraded []string `json:"degraded,omitempty"`
 }
 
 // TVShow represents a TV show with combined data from TMDB and OMDB
 type TVShow struct {
 	ID               int     `json:"id"`
 	Name             string  `json:"name"`
+	OriginalName     string  `json:"original_name"`
 	Overview         string  `json:"overview"`
 	FirstAirDate     string  `json:"first_air_date"`
 	LastAirDate      string  `json:"last_air_date"`
@@ -47,6 +74,17 @@ type TVShow struct {
 	NumberOfSeasons  int     `json:"number_of_seasons"`
 	NumberOfEpisodes int     `json:"number_of_episodes"`
 
+	NextEpisodeToAir *Episode `json:"next_episode_to_air,omitempty"`
+	LastEpisodeToAir *Episode `json:"last_episode_to_air,omitempty"`
+
+	// Sections appended to TMDB details on request
+	Credits        *Credits         `json:"credits,omitempty"`
+	Keywords       *KeywordList     `json:"keywords,omitempty"`
+	Images         *Images          `json:"images,omitempty"`
+	ContentRatings *ContentRatings  `json:"content_ratings,omitempty"`
+	Videos         *TrailerResponse `json:"videos,omitempty"`
+	ExternalIDs    *ExternalIDs     `json:"external_ids,omitempty"`
+
 	// OMDB specific fields
 	IMDBRating     string `json:"imdb_rating"`
 	RottenTomatoes string `json:"rotten_tomatoes"`
@@ -58,6 +96,18 @@ type TVShow struct {
 	Country        string `json:"country"`
 	Awards         string `json:"awards"`
 	IMDBId         string `json:"imdb_id"`
+
+	// Ratings, certification, box office and fallback poster from OMDB
+	OMDBDetails
+
+	// How reliably the OMDB data belongs to this TV show: 1 when matched by IMDb ID, lower for title lookups
+	MatchConfidence float64 `json:"match_confidence,omitempty"`
+
+	// Combined score across TMDB, IMDb, Rotten Tomatoes and Metacritic
+	Composite *CompositeScore `json:"composite,omitempty"`
+
+	// Upstreams that were skipped because they are unavailable
+	Degraded []string `json:"degraded,omitempty"`
 }
 
 // Genre represents a movie/TV show genre
@@ -66,12 +116,55 @@ type Genre struct {
 	Name string `json:"name"`
 }
 
+// OMDBDetails holds the detailed OMDB data shared by movies and TV shows
+type OMDBDetails struct {
+	Ratings           []Rating `json:"ratings,omitempty"`
+	IMDBVotes         int      `json:"imdb_votes,omitempty"`
+	Metascore         int      `json:"metascore,omitempty"`
+	Certification     string   `json:"certification,omitempty"` // e.g. "PG-13" or "TV-MA"
+	Released          string   `json:"released,omitempty"`
+	BoxOffice         string   `json:"box_office,omitempty"`
+	FallbackPosterURL string   `json:"fallback_poster_url,omitempty"` // OMDB poster, for when TMDB has none
+}
+
+// Rating represents a rating from one source, with its score normalized to 0-100
+type Rating struct {
+	Source string  `json:"source"`
+	Value  string  `json:"value"` // As reported, e.g. "7.5/10" or "85%"
+	Score  float64 `json:"score"`
+	Votes  int     `json:"votes,omitempty"`
+}
+
+// CompositeScore represents a 0-100 score combined from several rating sources
+type CompositeScore struct {
+	Score     float64          `json:"score"`
+	Breakdown []ScoreComponent `json:"breakdown"`
+}
+
+// ScoreComponent represents one source's contribution to a composite score
+type ScoreComponent struct {
+	Source   string  `json:"source"`
+	Score    float64 `json:"score"`           // Normalized 0-100
+	Votes    int     `json:"votes,omitempty"` // Vote count behind the score, if known
+	Adjusted float64 `json:"adjusted"`        // Score after shrinkage towards the prior
+	Weight   float64 `json:"weight"`
+}
+
+// ExternalIDs represents the IDs of a movie/TV show on other sites
+type ExternalIDs struct {
+	ID         int    `json:"id"`
+	IMDBID     string `json:"imdb_id"`
+	TVDBID     int    `json:"tvdb_id,omitempty"`
+	WikidataID string `json:"wikidata_id,omitempty"`
+}
+
 // SearchResult represents search results
 type SearchResult struct {
 	Page         int           `json:"page"`
 	Results      []interface{} `json:"results"`
 	TotalPages   int           `json:"total_pages"`
 	TotalResults int           `json:"total_results"`
+	Degraded     []string      `json:"degraded,omitempty"` // Upstreams skipped because they are unavailable
 }
 
 // WatchlistItem represents an item in user's watchlist
@@ -85,6 +178,16 @@ type WatchlistItem struct {
 	Rating     float64   `json:"rating,omitempty"`
 }
 
+// Episode represents a single episode of a TV show
+type Episode struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Overview      string `json:"overview"`
+	AirDate       string `json:"air_date"`
+	SeasonNumber  int    `json:"season_number"`
+	EpisodeNumber int    `json:"episode_number"`
+}
+
 // TrendingResponse represents trending content response
 type TrendingResponse struct {
 	Page         int           `json:"page"`
@@ -144,11 +247,145 @@ type TrailerResponse struct {
 
 // YouTubeVideo represents a YouTube video from search
 type YouTubeVideo struct {
-	VideoID      string `json:"video_id"`
-	Title        string `json:"title"`
-	Description  string `json:"description"`
-	Thumbnail    string `json:"thumbnail"`
-	ChannelTitle string `json:"channel_title"`
-	PublishedAt  string `json:"published_at"`
-	Duration     string `json:"duration,omitempty"`
+	VideoID         string `json:"video_id"`
+	Title           string `json:"title"`
+	Description     string `json:"description"`
+	Thumbnail       string `json:"thumbnail"`
+	ChannelTitle    string `json:"channel_title"`
+	PublishedAt     string `json:"published_at"`
+	Duration        string `json:"duration,omitempty"` // ISO 8601, e.g. PT2M30S
+	DurationSeconds int    `json:"duration_seconds,omitempty"`
+	ViewCount       int64  `json:"view_count,omitempty"`
+	Source          string `json:"source,omitempty"`   // "tmdb" or "youtube"
+	Type            string `json:"type,omitempty"`     // TMDB video type, e.g. Trailer or Teaser
+	Official        bool   `json:"official,omitempty"` // Published by the studio or network
+	Language        string `json:"language,omitempty"` // ISO 639-1
+}
+
+// Credits represents the cast and crew of a movie/TV show
+type Credits struct {
+	Cast []CastMember `json:"cast"`
+	Crew []CrewMember `json:"crew"`
+}
+
+// CastMember represents an actor and the character they play
+type CastMember struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Character   string `json:"character"`
+	Order       int    `json:"order"`
+	ProfilePath string `json:"profile_path"`
+	CreditID    string `json:"credit_id"`
+}
+
+// CrewMember represents a member of the crew and their job
+type CrewMember struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Job         string `json:"job"`
+	Department  string `json:"department"`
+	ProfilePath string `json:"profile_path"`
+	CreditID    string `json:"credit_id"`
+}
+
+// Keyword represents a TMDB keyword
+type Keyword struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// KeywordSearchResult represents a page of keyword search results
+type KeywordSearchResult struct {
+	Page         int       `json:"page"`
+	Results      []Keyword `json:"results"`
+	TotalPages   int       `json:"total_pages"`
+	TotalResults int       `json:"total_results"`
+}
+
+// KeywordMatch is a free-text keyword resolved to a TMDB keyword. When the
+// text is ambiguous, Keyword is nil and Suggestions lists the candidates.
+type KeywordMatch struct {
+	Query       string    `json:"query"`
+	Keyword     *Keyword  `json:"keyword,omitempty"`
+	Suggestions []Keyword `json:"suggestions,omitempty"`
+}
+
+// GenreKeywordSearch represents a genre search scoped by keywords. Results is
+// only set when every keyword resolved.
+type GenreKeywordSearch struct {
+	GenreID   int            `json:"genre_id"`
+	MediaType string         `json:"media_type"`
+	Keywords  []KeywordMatch `json:"keywords"`
+	Results   *SearchResult  `json:"results,omitempty"`
+}
+
+// KeywordList represents the keywords of a movie/TV show. TMDB lists movie
+// keywords under "keywords" and TV keywords under "results"; both decode
+// into Keywords.
+type KeywordList struct {
+	Keywords []Keyword `json:"keywords"`
+}
+
+// UnmarshalJSON accepts both the movie and the TV keywords shape
+func (k *KeywordList) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Keywords []Keyword `json:"keywords"`
+		Results  []Keyword `json:"results"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	k.Keywords = raw.Keywords
+	if k.Keywords == nil {
+		k.Keywords = raw.Results
+	}
+	return nil
+}
+
+// Images represents the artwork of a movie/TV show
+type Images struct {
+	Backdrops []Image `json:"backdrops"`
+	Posters   []Image `json:"posters"`
+	Logos     []Image `json:"logos,omitempty"`
+}
+
+// Image represents a single TMDB image
+type Image struct {
+	FilePath    string  `json:"file_path"`
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+	AspectRatio float64 `json:"aspect_ratio"`
+	ISO639_1    string  `json:"iso_639_1"`
+	VoteAverage float64 `json:"vote_average"`
+}
+
+// ReleaseDates represents a movie's release dates per country
+type ReleaseDates struct {
+	Results []CountryReleaseDates `json:"results"`
+}
+
+// CountryReleaseDates represents a movie's releases in one country
+type CountryReleaseDates struct {
+	ISO3166_1    string        `json:"iso_3166_1"`
+	ReleaseDates []ReleaseDate `json:"release_dates"`
+}
+
+// ReleaseDate represents a single release, e.g. theatrical or digital
+type ReleaseDate struct {
+	Certification string `json:"certification"`
+	ReleaseDate   string `json:"release_date"`
+	Type          int    `json:"type"` // 1 premiere, 2 limited theatrical, 3 theatrical, 4 digital, 5 physical, 6 TV
+	Note          string `json:"note,omitempty"`
+}
+
+// ContentRatings represents a TV show's content ratings per country
+type ContentRatings struct {
+	Results []ContentRating `json:"results"`
+}
+
+// ContentRating represents a TV show's content rating in one country
+type ContentRating struct {
+	ISO3166_1 string `json:"iso_3166_1"`
+	Rating    string `json:"rating"`
 }
@@ -0,0 +1,14 @@
+package models
+
+// ParentalControls are a profile's highest allowed certifications per
+// country. Without any limits every title is allowed.
+type ParentalControls struct {
+	MovieCertifications   map[string]string `json:"movie_certifications"`   // Country -> highest allowed movie certification, e.g. US: PG-13
+	TVCertifications      map[string]string `json:"tv_certifications"`      // Country -> highest allowed TV certification, e.g. US: TV-PG
+	UnknownCertifications string            `json:"unknown_certifications"` // strict blocks titles without a known certification, lenient allows them
+}
+
+// Enabled reports whether the controls limit any titles
+func (p ParentalControls) Enabled() bool {
+	return len(p.MovieCertifications) > 0 || len(p.TVCertifications) > 0
+}
@@ -0,0 +1,78 @@
+package models
+
+// PersonSummary represents a person in search results
+type PersonSummary struct {
+	ID                 int            `json:"id"`
+	Name               string         `json:"name"`
+	ProfilePath        string         `json:"profile_path"`
+	KnownForDepartment string         `json:"known_for_department"`
+	Popularity         float64        `json:"popularity"`
+	KnownFor           []PersonCredit `json:"known_for"`
+}
+
+// PersonSearchResult represents a page of people search results
+type PersonSearchResult struct {
+	Page         int             `json:"page"`
+	Results      []PersonSummary `json:"results"`
+	TotalPages   int             `json:"total_pages"`
+	TotalResults int             `json:"total_results"`
+}
+
+// Person represents an actor, director or other crew member
+type Person struct {
+	ID                 int                `json:"id"`
+	Name               string             `json:"name"`
+	Biography          string             `json:"biography"`
+	Birthday           string             `json:"birthday"`
+	Deathday           string             `json:"deathday,omitempty"`
+	PlaceOfBirth       string             `json:"place_of_birth"`
+	ProfilePath        string             `json:"profile_path"`
+	KnownForDepartment string             `json:"known_for_department"`
+	AlsoKnownAs        []string           `json:"also_known_as"`
+	Popularity         float64            `json:"popularity"`
+	IMDBID             string             `json:"imdb_id"`
+	KnownFor           []FilmographyEntry `json:"known_for"`
+	Filmography        []FilmographyEntry `json:"filmography"`
+}
+
+// PersonCredit represents one of a person's cast or crew credits, as
+// returned by TMDB combined credits and people search
+type PersonCredit struct {
+	ID           int     `json:"id"`
+	MediaType    string  `json:"media_type"` // "movie" or "tv"
+	Title        string  `json:"title,omitempty"`
+	Name         string  `json:"name,omitempty"`
+	ReleaseDate  string  `json:"release_date,omitempty"`
+	FirstAirDate string  `json:"first_air_date,omitempty"`
+	PosterPath   string  `json:"poster_path"`
+	VoteAverage  float64 `json:"vote_average"`
+	VoteCount    int     `json:"vote_count"`
+	Character    string  `json:"character,omitempty"`
+	Job          string  `json:"job,omitempty"`
+	Department   string  `json:"department,omitempty"`
+	EpisodeCount int     `json:"episode_count,omitempty"`
+}
+
+// CombinedCredits represents a person's movie and TV credits
+type CombinedCredits struct {
+	Cast []PersonCredit `json:"cast"`
+	Crew []PersonCredit `json:"crew"`
+}
+
+// FilmographyEntry represents one title in a person's filmography, with all
+// of their roles on it
+type FilmographyEntry struct {
+	ID          int      `json:"id"`
+	MediaType   string   `json:"media_type"`
+	Title       string   `json:"title"`
+	Year        int      `json:"year,omitempty"`
+	ReleaseDate string   `json:"release_date,omitempty"`
+	PosterPath  string   `json:"poster_path"`
+	VoteAverage float64  `json:"vote_average"`
+	VoteCount   int      `json:"vote_count"`
+	Characters  []string `json:"characters,omitempty"`
+	Jobs        []string `json:"jobs,omitempty"`
+	Departments []string `json:"departments,omitempty"` // e.g. Acting, Directing
+	InWatchlist bool     `json:"in_watchlist"`
+	Watched     bool     `json:"watched"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"strconv"
+	"time"
+)
+
+// Profile is a member of a household sharing one account. Each profile has
+// its own watchlist, history, saved searches, recommendations and parental
+// controls.
+type Profile struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Avatar    string    `json:"avatar,omitempty"` // Avatar image URL or icon name
+	Kid       bool      `json:"kid"`              // Kid profiles get parental controls and can't change them
+	Owner     bool      `json:"owner"`            // The account owner's profile, which manages the household
+	Active    bool      `json:"active"`           // Selected by the last profile switch
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Key returns the ID a profile's data is kept under
+func (p Profile) Key() string {
+	return "profile_" + strconv.Itoa(p.ID)
+}
+
+// ProfileStats are one profile's watchlist statistics
+type ProfileStats struct {
+	ProfileID int                    `json:"profile_id"`
+	Name      string                 `json:"name"`
+	Kid       bool                   `json:"kid"`
+	Stats     map[string]interface{} `json:"stats"`
+}
+
+// HouseholdStats are the watchlist statistics of each profile of an account
+// and of the household as a whole
+type HouseholdStats struct {
+	Profiles []ProfileStats         `json:"profiles"`
+	Totals   map[string]interface{} `json:"totals"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// SavedSearch is a search or discover query that is re-run on a schedule to
+// find newly matching titles
+type SavedSearch struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	MediaType string     `json:"media_type"`         // movie or tv
+	Query     string     `json:"query,omitempty"`    // Search query, plain or structured
+	Discover  string     `json:"discover,omitempty"` // Discover filters as URL query parameters, e.g. genres=878&min_rating=7.5
+	Language  string     `json:"language,omitempty"` // Language titles are fetched in, e.g. de or pt-BR
+	Region    string     `json:"region,omitempty"`   // ISO 3166-1 country code
+	CreatedAt time.Time  `json:"created_at"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	NextRunAt time.Time  `json:"next_run_at"`
+	LastError string     `json:"last_error,omitempty"`
+	Matches   int        `json:"matches"` // New matches found since the search was saved
+}
+
+// SavedSearchMatch is a title that newly matched a saved search
+type SavedSearchMatch struct {
+	SearchID    int       `json:"search_id"`
+	SearchName  string    `json:"search_name"`
+	ID          int       `json:"id"`
+	MediaType   string    `json:"media_type"`
+	Title       string    `json:"title"`
+	ReleaseDate string    `json:"release_date,omitempty"` // First air date for TV shows
+	PosterPath  string    `json:"poster_path,omitempty"`
+	FoundAt     time.Time `json:"found_at"`
+}
+
+// SavedSearchInbox lists a user's new saved search matches, newest first
+type SavedSearchInbox struct {
+	Matches []SavedSearchMatch `json:"matches"`
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"movie-discovery-app/internal/models"
+)
+
+// Autocomplete limits
+const (
+	DefaultAutocompleteLimit = 8
+	MaxAutocompleteLimit     = 20
+	minRemotePrefixLength    = 2 // Shorter prefixes are answered from the local index only
+)
+
+// Autocomplete suggests titles and people for a partial query. Recently seen
+// titles are answered from the local prefix index; TMDB multi search fills
+// the rest within the autocomplete budget, and the index answers alone
+// (marked partial) when it doesn't. OMDB is never consulted.
+func (s *DiscoveryService) Autocomplete(ctx context.Context, query string, limit int) (*models.AutocompleteResult, error) {
+	if limit <= 0 {
+		limit = DefaultAutocompleteLimit
+	}
+	limit = min(limit, MaxAutocompleteLimit)
+
+	prefix := normalizeTitle(query)
+	result := &models.AutocompleteResult{Query: query, Suggestions: []models.Suggestion{}}
+	if prefix == "" {
+		return result, nil
+	}
+
+	matches := s.titles.lookup(prefix, limit)
+
+	// The index answers alone when it has enough titles starting with the
+	// query, or the query is too short to be worth a round trip
+	titleMatches := 0
+	for _, match := range matches {
+		if match.tier == tierTitlePrefix {
+			titleMatches++
+		}
+	}
+	if titleMatches < limit && len(prefix) >= minRemotePrefixLength {
+		remote, err := s.searchSuggestions(ctx, query)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			log.Printf("Autocomplete TMDB search error: %v", err)
+			result.Partial = true
+		}
+		matches = mergeMatches(matches, remote, prefix)
+	}
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	for _, match := range matches {
+		result.Suggestions = append(result.Suggestions, match.suggestion)
+	}
+
+	return result, nil
+}
+
+// ValidateAutocompleteQuery validates a partial query. Suggestions match the
+// whole query as plain text, so field terms, including half-typed or
+// misspelled ones like "genra:x", are never rejected; only the overall length
+// of a structured query is enforced.
+func (s *DiscoveryService) ValidateAutocompleteQuery(query string) error {
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("search query cannot be empty")
+	}
+	if len(query) > maxStructuredQueryLength {
+		return fmt.Errorf("search query too long (max %d characters)", maxStructuredQueryLength)
+	}
+	return nil
+}
+
+// AutocompleteTimeout returns the time budget of an autocomplete request
+func (s *DiscoveryService) AutocompleteTimeout() time.Duration {
+	return s.autocompleteTimeout
+}
+
+// searchSuggestions asks TMDB multi search for suggestions within the
+// autocomplete budget, indexing what it finds unless it is localized
+func (s *DiscoveryService) searchSuggestions(ctx context.Context, query string) ([]indexMatch, error) {
+	english := LocaleFromContext(ctx).IsEnglish()
+	ctx, cancel := context.WithTimeout(ctx, s.autocompleteTimeout)
+	defer cancel()
+
+	searchResult, err := s.tmdbClient.SearchMulti(ctx, query, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []indexMatch
+	for _, item := range searchResult.Results {
+		suggestion := models.Suggestion{ID: item.ID, MediaType: item.MediaType}
+		switch item.MediaType {
+		case "movie":
+			suggestion.Title = item.Title
+			suggestion.Year = releaseYear(item.ReleaseDate)
+			suggestion.PosterPath = item.PosterPath
+		case "tv":
+			suggestion.Title = item.Name
+			suggestion.Year = releaseYear(item.FirstAirDate)
+			suggestion.PosterPath = item.PosterPath
+		case "person":
+			suggestion.Title = item.Name
+			suggestion.PosterPath = item.ProfilePath
+		default:
+			continue
+		}
+		if suggestion.Title == "" {
+			continue
+		}
+
+		// The index is shared, so localized titles would replace English ones
+		if english {
+			s.titles.Add(suggestion, item.Popularity)
+		}
+		matches = append(matches, indexMatch{suggestion: suggestion, popularity: item.Popularity})
+	}
+
+	return matches, nil
+}
+
+// mergeMatches combines index and TMDB matches, preferring TMDB's fresher
+// copy of duplicates and ranking TMDB-only matches by how their title
+// matches the prefix
+func mergeMatches(local, remote []indexMatch, prefix string) []indexMatch {
+	positions := make(map[string]int, len(local)+len(remote))
+	merged := make([]indexMatch, 0, len(local)+len(remote))
+	for _, match := range local {
+		positions[watchlistKey(match.suggestion.MediaType, match.suggestion.ID)] = len(merged)
+		merged = append(merged, match)
+	}
+	for _, match := range remote {
+		key := watchlistKey(match.suggestion.MediaType, match.suggestion.ID)
+		if i, seen := positions[key]; seen {
+			merged[i].suggestion = match.suggestion
+			continue
+		}
+		positions[key] = len(merged)
+		match.tier = matchTier(normalizeTitle(match.suggestion.Title), prefix)
+		merged = append(merged, match)
+	}
+
+	sortMatches(merged)
+	return merged
+}
+
+// indexResults adds movie or TV search results to the autocomplete and
+// search indexes. Only English titles are indexed, so localized requests
+// don't replace them.
+func (s *DiscoveryService) indexResults(ctx context.Context, mediaType string, results []interface{}) {
+	if !LocaleFromContext(ctx).IsEnglish() {
+		return
+	}
+
+	for _, result := range results {
+		item, ok := result.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		doc := searchResultDocument(mediaType, item)
+		if doc.ID <= 0 || doc.Title == "" {
+			continue
+		}
+
+		s.titles.Add(models.Suggestion{
+			ID:         doc.ID,
+			MediaType:  mediaType,
+			Title:      doc.Title,
+			Year:       releaseYear(doc.ReleaseDate),
+			PosterPath: doc.PosterPath,
+		}, doc.Popularity)
+		s.searchIndex.Add(doc)
+	}
+	s.saveSearchIndexIfDue()
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"movie-discovery-app/internal/models"
+)
+
+// newFakeTMDBMulti serves multi search after delay, counting requests
+func newFakeTMDBMulti(t *testing.T, delay time.Duration) (*httptest.Server, *int32) {
+	var requests int32
+	tmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search/multi" {
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt32(&requests, 1)
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.Write([]byte(`{"page": 1, "results": [
+			{"id": 155, "media_type": "movie", "title": "The Dark Knight", "release_date": "2008-07-16", "poster_path": "/tdk.jpg", "popularity": 80},
+			{"id": 70523, "media_type": "tv", "name": "Dark", "first_air_date": "2017-12-01", "poster_path": "/dark.jpg", "popularity": 40},
+			{"id": 3894, "media_type": "person", "name": "Christian Bale", "profile_path": "/bale.jpg", "popularity": 30},
+			{"id": 7, "media_type": "collection", "name": "The Dark Knight Collection"}
+		], "total_pages": 1, "total_results": 4}`))
+	}))
+	t.Cleanup(tmdb.Close)
+	return tmdb, &requests
+}
+
+func TestDiscoveryService_Autocomplete(t *testing.T) {
+	tmdb, requests := newFakeTMDBMulti(t, 0)
+	service := newTestDiscoveryService(tmdb.URL, "http://omdb.invalid")
+	service.titles.Add(models.Suggestion{ID: 1, MediaType: "movie", Title: "Darkman", Year: "1990"}, 90)
+	service.titles.Add(models.Suggestion{ID: 70523, MediaType: "tv", Title: "Dark", Year: "2017"}, 40)
+
+	result, err := service.Autocomplete(context.Background(), "dark", 10)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Partial {
+		t.Error("Expected a complete result")
+	}
+
+	// Index and TMDB results are merged without duplicates; Christian Bale
+	// doesn't match the prefix so he comes last
+	want := []models.Suggestion{
+		{ID: 1, MediaType: "movie", Title: "Darkman", Year: "1990"},
+		{ID: 70523, MediaType: "tv", Title: "Dark", Year: "2017", PosterPath: "/dark.jpg"},
+		{ID: 155, MediaType: "movie", Title: "The Dark Knight", Year: "2008", PosterPath: "/tdk.jpg"},
+		{ID: 3894, MediaType: "person", Title: "Christian Bale", PosterPath: "/bale.jpg"},
+	}
+	if !reflect.DeepEqual(result.Suggestions, want) {
+		t.Errorf("Expected %+v, got %+v", want, result.Suggestions)
+	}
+
+	// TMDB results are indexed for later lookups, which skip TMDB once the
+	// index has enough titles starting with the query
+	result, err = service.Autocomplete(context.Background(), "the dark", 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.Suggestions) != 1 || result.Suggestions[0].ID != 155 {
+		t.Errorf("Expected The Dark Knight from the index, got %+v", result.Suggestions)
+	}
+	if atomic.LoadInt32(requests) != 1 {
+		t.Errorf("Expected TMDB to be asked once, got %d requests", atomic.LoadInt32(requests))
+	}
+
+	// Single characters are answered from the index alone
+	if _, err := service.Autocomplete(context.Background(), "x", 5); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if atomic.LoadInt32(requests) != 1 {
+		t.Errorf("Expected no TMDB request for a single character, got %d requests", atomic.LoadInt32(requests))
+	}
+}
+
+func TestDiscoveryService_Autocomplete_SlowTMDB(t *testing.T) {
+	tmdb, _ := newFakeTMDBMulti(t, time.Second)
+	service := newTestDiscoveryService(tmdb.URL, "http://omdb.invalid")
+	service.autocompleteTimeout = 20 * time.Millisecond
+	service.titles.Add(models.Suggestion{ID: 1, MediaType: "movie", Title: "Darkman", Year: "1990"}, 90)
+
+	start := time.Now()
+	result, err := service.Autocomplete(context.Background(), "dark", 5)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the budget to bound the request, took %v", elapsed)
+	}
+	if !result.Partial {
+		t.Error("Expected a partial result")
+	}
+	if len(result.Suggestions) != 1 || result.Suggestions[0].Title != "Darkman" {
+		t.Errorf("Expected the indexed title, got %+v", result.Suggestions)
+	}
+}
+
+func TestDiscoveryService_ValidateAutocompleteQuery(t *testing.T) {
+	service := newTestDiscoveryService("http://tmdb.invalid", "http://omdb.invalid")
+
+	// Partial and misspelled field terms are plain text to autocomplete
+	for _, query := range []string{"genra:x", "year:", `director:"Denis`, "year:2020..2010", "genre:drama " + strings.Repeat("a", 101)} {
+		if err := service.ValidateAutocompleteQuery(query); err != nil {
+			t.Errorf("%q: expected no error, got %v", query, err)
+		}
+	}
+
+	for _, query := range []string{"", "   ", strings.Repeat("a", 501)} {
+		if err := service.ValidateAutocompleteQuery(query); err == nil {
+			t.Errorf("%q: expected an error", query)
+		}
+	}
+}
+
+func TestDiscoveryService_Autocomplete_LocalizedKeepsEnglishIndex(t *testing.T) {
+	tmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"page": 1, "results": [
+			{"id": 155, "media_type": "movie", "title": "The Dark Knight: Le Chevalier noir", "release_date": "2008-07-16", "popularity": 80},
+			{"id": 49026, "media_type": "movie", "title": "The Dark Knight Rises: Le chevalier se lève", "release_date": "2012-07-16", "popularity": 60}
+		], "total_pages": 1, "total_results": 2}`))
+	}))
+	defer tmdb.Close()
+
+	service := newTestDiscoveryService(tmdb.URL, "http://omdb.invalid")
+	service.titles.Add(models.Suggestion{ID: 155, MediaType: "movie", Title: "The Dark Knight", Year: "2008"}, 80)
+
+	ctx := WithLocale(context.Background(), Locale{Language: "fr"})
+	result, err := service.Autocomplete(ctx, "the dark", 5)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.Suggestions) != 2 || result.Suggestions[0].Title != "The Dark Knight: Le Chevalier noir" {
+		t.Errorf("Expected the French titles, got %+v", result.Suggestions)
+	}
+
+	// The shared index still has only the English title
+	matches := service.titles.lookup("the dark", 5)
+	if len(matches) != 1 || matches[0].suggestion.Title != "The Dark Knight" {
+		t.Errorf("Expected the index to keep the English title only, got %+v", matches)
+	}
+}
+
+func BenchmarkDiscoveryService_Autocomplete(b *testing.B) {
+	service := newTestDiscoveryService("http://tmdb.invalid", "http://omdb.invalid")
+	service.titles = newBenchmarkPrefixIndex(10000)
+
+	// Answered from the index without TMDB
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := service.Autocomplete(context.Background(), "the dark", DefaultAutocompleteLimit); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"movie-discovery-app/configs"
+)
+
+// ErrCircuitOpen is returned when a call is short-circuited by an open breaker
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// BreakerState represents the state of a circuit breaker
+type BreakerState int
+
+const (
+	// BreakerClosed lets all calls through
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects calls until the cooldown elapses
+	BreakerOpen
+	// BreakerHalfOpen lets a single probe through to test recovery
+	BreakerHalfOpen
+)
+
+// String returns the state name used in health and metrics output
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker stops calling an upstream after consecutive failures. Once
+// the cooldown elapses it half-opens and admits one probe: success closes it
+// again, failure re-opens it for another cooldown.
+type CircuitBreaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu             sync.Mutex
+	state          BreakerState
+	failures       int
+	openedAt       time.Time
+	probing        bool
+	opens          int64
+	rejected       int64
+	successesTotal int64
+	failuresTotal  int64
+}
+
+// BreakerSnapshot is a point-in-time view of a circuit breaker
+type BreakerSnapshot struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	Opens               int64      `json:"opens_total"`
+	Rejected            int64      `json:"rejected_total"`
+	Successes           int64      `json:"successes_total"`
+	Failures            int64      `json:"failures_total"`
+}
+
+// NewCircuitBreaker creates a breaker that opens after threshold consecutive
+// failures and probes again after cooldown
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = 5
+	}
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+
+	return &CircuitBreaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Shared breakers, one per upstream dependency
+var (
+	sharedBreakersMu sync.Mutex
+	sharedBreakers   = make(map[string]*CircuitBreaker)
+)
+
+// sharedCircuitBreaker returns the process-wide breaker for the named
+// upstream, creating it from breakerConfig on first use
+func sharedCircuitBreaker(upstream string, breakerConfig *configs.BreakerConfig) *CircuitBreaker {
+	sharedBreakersMu.Lock()
+	defer sharedBreakersMu.Unlock()
+
+	if breaker, exists := sharedBreakers[upstream]; exists {
+		return breaker
+	}
+
+	var threshold int
+	var cooldown time.Duration
+	if breakerConfig != nil {
+		threshold = breakerConfig.FailureThreshold
+		cooldown = breakerConfig.Cooldown
+	}
+
+	breaker := NewCircuitBreaker(upstream, threshold, cooldown)
+	sharedBreakers[upstream] = breaker
+	return breaker
+}
+
+// Allow reports whether a call may proceed. It returns ErrCircuitOpen while
+// the breaker is open or while a half-open probe is already in flight.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			b.rejected++
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			b.rejected++
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Available reports whether a call would currently be admitted, without
+// consuming the half-open probe
+func (b *CircuitBreaker) Available() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		return b.now().Sub(b.openedAt) >= b.cooldown
+	case BreakerHalfOpen:
+		return !b.probing
+	default:
+		return true
+	}
+}
+
+// RecordSuccess records a successful call, closing the breaker
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.successesTotal++
+	b.failures = 0
+	b.probing = false
+	b.state = BreakerClosed
+}
+
+// RecordFailure records a failed call, opening the breaker when the
+// threshold is reached or when a half-open probe fails
+func (b *CircuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failuresTotal++
+	b.failures++
+	b.probing = false
+
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		if b.state != BreakerOpen {
+			b.opens++
+		}
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// RecordIgnored releases a half-open probe without changing state, for calls
+// abandoned by the caller before the upstream answered
+func (b *CircuitBreaker) RecordIgnored() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// State returns the current breaker state
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// Snapshot returns the breaker's current state and counters
+func (b *CircuitBreaker) Snapshot() BreakerSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snapshot := BreakerSnapshot{
+		Name:                b.name,
+		State:               b.state.String(),
+		ConsecutiveFailures: b.failures,
+		Opens:               b.opens,
+		Rejected:            b.rejected,
+		Successes:           b.successesTotal,
+		Failures:            b.failuresTotal,
+	}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		snapshot.OpenedAt = &openedAt
+	}
+	return snapshot
+}
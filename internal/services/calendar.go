@@ -0,0 +1,405 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
+)
+
+// Calendar defaults and limits
+const (
+	defaultCalendarRegion     = "US"
+	defaultCalendarDays       = 30
+	maxCalendarDays           = 90
+	calendarLookupConcurrency = 5
+)
+
+// releaseTypeNames names TMDB's numbered release types
+var releaseTypeNames = map[int]string{
+	1: "premiere",
+	2: "theatrical_limited",
+	3: "theatrical",
+	4: "digital",
+	5: "physical",
+	6: "tv",
+}
+
+// releaseTypeLabels describe release types in calendar feeds
+var releaseTypeLabels = map[string]string{
+	"premiere":           "Premiere",
+	"theatrical_limited": "Limited theatrical",
+	"theatrical":         "Theatrical",
+	"digital":            "Digital",
+	"physical":           "Physical",
+	"tv":                 "TV",
+}
+
+var regionPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// CalendarQuery selects the releases shown on a calendar
+type CalendarQuery struct {
+	Region        string
+	From          time.Time
+	To            time.Time
+	WatchlistOnly bool
+}
+
+// CalendarService builds release calendars from upcoming and now playing
+// movies and the user's watchlist
+type CalendarService struct {
+	tmdbClient       *TMDBClient
+	watchlistService *WatchlistService
+	parentalService  *ParentalControlService
+	feeds            *feedTokens
+	now              func() time.Time
+}
+
+// NewCalendarService creates a new calendar service
+func NewCalendarService(config *configs.Config, watchlistService *WatchlistService, parentalService *ParentalControlService) *CalendarService {
+	return &CalendarService{
+		tmdbClient:       NewTMDBClient(&config.TMDB, &config.Cache, &config.Rate, &config.Retry, &config.Breaker),
+		watchlistService: watchlistService,
+		parentalService:  parentalService,
+		feeds:            newFeedTokens(),
+		now:              time.Now,
+	}
+}
+
+// ParseCalendarQuery validates calendar parameters. The region defaults to
+// the US and the window to the next 30 days; windows longer than 90 days
+// are rejected.
+func (s *CalendarService) ParseCalendarQuery(region, from, to string, watchlistOnly bool) (CalendarQuery, error) {
+	query := CalendarQuery{WatchlistOnly: watchlistOnly}
+
+	var err error
+	if query.Region, err = NormalizeRegion(region); err != nil {
+		return query, err
+	}
+
+	today := s.now().UTC().Truncate(24 * time.Hour)
+	query.From = today
+	if from != "" {
+		parsed, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return query, fmt.Errorf("from must be a date in YYYY-MM-DD format")
+		}
+		query.From = parsed
+	}
+
+	query.To = query.From.AddDate(0, 0, defaultCalendarDays)
+	if to != "" {
+		parsed, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return query, fmt.Errorf("to must be a date in YYYY-MM-DD format")
+		}
+		query.To = parsed
+	}
+
+	if query.To.Before(query.From) {
+		return query, fmt.Errorf("to must not be before from")
+	}
+	if query.To.Sub(query.From) > maxCalendarDays*24*time.Hour {
+		return query, fmt.Errorf("calendar range cannot exceed %d days", maxCalendarDays)
+	}
+
+	return query, nil
+}
+
+// NormalizeRegion upper-cases an ISO 3166-1 country code, defaulting to US
+func NormalizeRegion(region string) (string, error) {
+	region = strings.ToUpper(strings.TrimSpace(region))
+	if region == "" {
+		return defaultCalendarRegion, nil
+	}
+	if !regionPattern.MatchString(region) {
+		return "", fmt.Errorf("region must be an ISO 3166-1 country code, e.g. US")
+	}
+	return region, nil
+}
+
+// calendarCandidate is a movie whose releases may appear on the calendar
+type calendarCandidate struct {
+	movie    models.Movie
+	fromList bool // Listed as upcoming or now playing in the region
+}
+
+// GetCalendar gets the region's releases between query.From and query.To,
+// grouped by date. Each movie's region-specific release dates are looked
+// up, so a movie can appear once for its theatrical release and again for
+// its digital release. Movies the user's parental controls don't allow are
+// left out.
+func (s *CalendarService) GetCalendar(ctx context.Context, userID string, query CalendarQuery) (*models.Calendar, error) {
+	watchlist, err := s.watchlistService.GetWatchlist(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.calendarCandidates(ctx, query, watchlist)
+	if err != nil {
+		return nil, err
+	}
+
+	if candidates, err = s.allowedCandidates(ctx, userID, candidates); err != nil {
+		return nil, err
+	}
+
+	releases, err := s.lookupReleases(ctx, query, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	items := indexWatchlist(watchlist)
+	days := make(map[string][]models.CalendarRelease)
+	for i := range releases {
+		release := releases[i]
+		if item, ok := items[watchlistKey("movie", release.MovieID)]; ok {
+			release.InWatchlist = true
+			release.Watched = item.Watched
+		}
+		date := release.date
+		days[date] = append(days[date], release.CalendarRelease)
+	}
+
+	calendar := &models.Calendar{
+		Region: query.Region,
+		From:   query.From.Format("2006-01-02"),
+		To:     query.To.Format("2006-01-02"),
+		Days:   []models.CalendarDay{},
+	}
+	for date, dayReleases := range days {
+		sort.SliceStable(dayReleases, func(i, j int) bool {
+			if dayReleases[i].Popularity != dayReleases[j].Popularity {
+				return dayReleases[i].Popularity > dayReleases[j].Popularity
+			}
+			return dayReleases[i].Title < dayReleases[j].Title
+		})
+		calendar.Days = append(calendar.Days, models.CalendarDay{Date: date, Releases: dayReleases})
+	}
+	sort.Slice(calendar.Days, func(i, j int) bool {
+		return calendar.Days[i].Date < calendar.Days[j].Date
+	})
+
+	return calendar, nil
+}
+
+// GetCalendarICS renders the calendar as an iCalendar feed with an all-day
+// event per release
+func (s *CalendarService) GetCalendarICS(ctx context.Context, userID string, query CalendarQuery) ([]byte, error) {
+	calendar, err := s.GetCalendar(ctx, userID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []icsEvent
+	for _, day := range calendar.Days {
+		date, _ := time.Parse("2006-01-02", day.Date)
+		for _, release := range day.Releases {
+			label := releaseTypeLabels[release.ReleaseType]
+			description := fmt.Sprintf("%s release in %s.", label, calendar.Region)
+			if release.Certification != "" {
+				description += fmt.Sprintf(" Rated %s.", release.Certification)
+			}
+			if release.Note != "" {
+				description += " " + release.Note
+			}
+
+			events = append(events, icsEvent{
+				UID:         fmt.Sprintf("release-%d-%s-%s@movie-discovery-app", release.MovieID, release.ReleaseType, calendar.Region),
+				Date:        date,
+				Summary:     fmt.Sprintf("%s (%s)", release.Title, label),
+				Description: description,
+				URL:         fmt.Sprintf("https://www.themoviedb.org/movie/%d", release.MovieID),
+			})
+		}
+	}
+
+	name := fmt.Sprintf("Movie releases (%s)", calendar.Region)
+	if query.WatchlistOnly {
+		name = fmt.Sprintf("Watchlist releases (%s)", calendar.Region)
+	}
+	return writeICS(name, events, s.now()), nil
+}
+
+// calendarCandidates collects the movies to look up: the watchlist's
+// movies, plus the region's upcoming and now playing movies unless the
+// calendar is limited to the watchlist
+func (s *CalendarService) calendarCandidates(ctx context.Context, query CalendarQuery, watchlist []models.WatchlistItem) ([]calendarCandidate, error) {
+	var candidates []calendarCandidate
+	seen := make(map[int]bool)
+
+	if !query.WatchlistOnly {
+		upcoming, err := s.tmdbClient.GetUpcomingMovies(ctx, query.Region, 1)
+		if err != nil {
+			return nil, err
+		}
+		nowPlaying, err := s.tmdbClient.GetNowPlayingMovies(ctx, query.Region, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, list := range [][]models.Movie{upcoming.Results, nowPlaying.Results} {
+			for _, movie := range list {
+				if !seen[movie.ID] {
+					seen[movie.ID] = true
+					candidates = append(candidates, calendarCandidate{movie: movie, fromList: true})
+				}
+			}
+		}
+	}
+
+	for _, item := range watchlist {
+		if item.Type != "movie" {
+			continue
+		}
+		id, err := strconv.Atoi(item.ID)
+		if err != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		candidates = append(candidates, calendarCandidate{
+			movie: models.Movie{ID: id, Title: item.Title, PosterPath: item.PosterPath},
+		})
+	}
+
+	return candidates, nil
+}
+
+// datedRelease is a calendar release with its date
+type datedRelease struct {
+	models.CalendarRelease
+	date string
+}
+
+// allowedCandidates keeps the candidates the user's parental controls allow
+func (s *CalendarService) allowedCandidates(ctx context.Context, userID string, candidates []calendarCandidate) ([]calendarCandidate, error) {
+	movieIDs := make([]int, len(candidates))
+	for i, candidate := range candidates {
+		movieIDs[i] = candidate.movie.ID
+	}
+
+	allowed, err := s.parentalService.allowedTitles(ctx, userID, map[string][]int{"movie": movieIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	kept := make([]calendarCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		if allowed[watchlistKey("movie", candidate.movie.ID)] {
+			kept = append(kept, candidate)
+		}
+	}
+	return kept, nil
+}
+
+// lookupReleases fetches each candidate's release dates with bounded
+// concurrency and keeps the region's releases inside the window. If a
+// lookup fails, a listed movie falls back to the theatrical date from the
+// list.
+func (s *CalendarService) lookupReleases(ctx context.Context, query CalendarQuery, candidates []calendarCandidate) ([]datedRelease, error) {
+	results := make([][]datedRelease, len(candidates))
+
+	forEachConcurrently(len(candidates), calendarLookupConcurrency, func(i int) {
+		candidate := candidates[i]
+		releaseDates, err := s.tmdbClient.GetMovieReleaseDates(ctx, candidate.movie.ID)
+		if err != nil {
+			log.Printf("Failed to get release dates for movie %d: %v", candidate.movie.ID, err)
+			if candidate.fromList {
+				results[i] = fallbackRelease(candidate.movie, query)
+			}
+			return
+		}
+		results[i] = regionReleases(candidate.movie, releaseDates, query)
+	})
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var releases []datedRelease
+	for _, result := range results {
+		releases = append(releases, result...)
+	}
+	return releases, nil
+}
+
+// regionReleases returns a movie's releases in the query's region and window
+func regionReleases(movie models.Movie, releaseDates *models.ReleaseDates, query CalendarQuery) []datedRelease {
+	var releases []datedRelease
+	for _, country := range releaseDates.Results {
+		if country.ISO3166_1 != query.Region {
+			continue
+		}
+		for _, release := range country.ReleaseDates {
+			releaseType, known := releaseTypeNames[release.Type]
+			if !known || !inCalendarWindow(release.ReleaseDate, query) {
+				continue
+			}
+			releases = append(releases, datedRelease{
+				CalendarRelease: models.CalendarRelease{
+					MovieID:       movie.ID,
+					Title:         movie.Title,
+					PosterPath:    movie.PosterPath,
+					Popularity:    movie.Popularity,
+					ReleaseType:   releaseType,
+					Certification: release.Certification,
+					Note:          release.Note,
+				},
+				date: release.ReleaseDate[:10],
+			})
+		}
+	}
+	return releases
+}
+
+// fallbackRelease returns the theatrical release date from a movie list
+func fallbackRelease(movie models.Movie, query CalendarQuery) []datedRelease {
+	if !inCalendarWindow(movie.ReleaseDate, query) {
+		return nil
+	}
+	return []datedRelease{{
+		CalendarRelease: models.CalendarRelease{
+			MovieID:     movie.ID,
+			Title:       movie.Title,
+			PosterPath:  movie.PosterPath,
+			Popularity:  movie.Popularity,
+			ReleaseType: "theatrical",
+		},
+		date: movie.ReleaseDate[:10],
+	}}
+}
+
+// inCalendarWindow reports whether a TMDB date or timestamp falls within the
+// query's window, inclusive
+func inCalendarWindow(date string, query CalendarQuery) bool {
+	if len(date) < 10 {
+		return false
+	}
+	day := date[:10]
+	return day >= query.From.Format("2006-01-02") && day <= query.To.Format("2006-01-02")
+}
+
+// forEachConcurrently calls fn for 0..count-1 with at most limit calls
+// running at once, returning when all calls have returned
+func forEachConcurrently(count, limit int, fn func(i int)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, limit)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
+)
+
+var calendarNow = time.Date(2024, 2, 25, 18, 0, 0, 0, time.UTC)
+
+func newTestCalendarService(t *testing.T, watchlistService *WatchlistService) *CalendarService {
+	tmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/movie/upcoming":
+			if r.URL.Query().Get("region") != "US" {
+				http.Error(w, "missing region", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"page": 1, "results": [
+				{"id": 1, "title": "Dune: Part Two", "release_date": "2024-03-01", "popularity": 500},
+				{"id": 2, "title": "Small Film", "release_date": "2024-03-01", "popularity": 10}
+			]}`))
+		case "/movie/now_playing":
+			w.Write([]byte(`{"page": 1, "results": [
+				{"id": 2, "title": "Small Film", "release_date": "2024-03-01", "popularity": 10},
+				{"id": 3, "title": "No Dates", "release_date": "2024-03-05", "popularity": 50}
+			]}`))
+		case "/movie/1/release_dates":
+			w.Write([]byte(`{"id": 1, "results": [
+				{"iso_3166_1": "GB", "release_dates": [{"certification": "12A", "release_date": "2024-02-28T00:00:00.000Z", "type": 3}]},
+				{"iso_3166_1": "US", "release_dates": [
+					{"certification": "PG-13", "release_date": "2024-03-01T00:00:00.000Z", "type": 3},
+					{"certification": "PG-13", "release_date": "2024-04-16T00:00:00.000Z", "type": 4}
+				]}
+			]}`))
+		case "/movie/2/release_dates":
+			w.Write([]byte(`{"id": 2, "results": [
+				{"iso_3166_1": "US", "release_dates": [{"release_date": "2024-03-01T00:00:00.000Z", "type": 2, "note": "New York, Los Angeles"}]}
+			]}`))
+		case "/movie/4/release_dates":
+			w.Write([]byte(`{"id": 4, "results": [
+				{"iso_3166_1": "US", "release_dates": [{"release_date": "2024-03-12T00:00:00.000Z", "type": 5}]}
+			]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(tmdb.Close)
+
+	service := NewCalendarService(&configs.Config{
+		TMDB: configs.TMDBConfig{APIKey: "test_key", BaseURL: tmdb.URL},
+	}, watchlistService, newTestParentalService(t))
+	service.tmdbClient.upstream.rateLimiter = NewRateLimiter(60000, 1000)
+	service.tmdbClient.upstream.breaker = NewCircuitBreaker("test", 100, time.Minute)
+	service.now = func() time.Time { return calendarNow }
+	return service
+}
+
+func TestCalendarService_ParseCalendarQuery(t *testing.T) {
+	service := newTestCalendarService(t, NewWatchlistService())
+
+	query, err := service.ParseCalendarQuery("", "", "", false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if query.Region != "US" || query.From.Format("2006-01-02") != "2024-02-25" || query.To.Format("2006-01-02") != "2024-03-26" {
+		t.Errorf("Unexpected defaults: %+v", query)
+	}
+
+	if query, err := service.ParseCalendarQuery("gb", "2024-03-01", "", false); err != nil || query.Region != "GB" || query.To.Format("2006-01-02") != "2024-03-31" {
+		t.Errorf("Expected GB from 2024-03-01 to 2024-03-31, got %+v, %v", query, err)
+	}
+
+	invalid := []struct{ region, from, to string }{
+		{"USA", "", ""},
+		{"", "01/03/2024", ""},
+		{"", "2024-03-01", "2024-02-01"},
+		{"", "2024-01-01", "2024-06-01"},
+	}
+	for _, tt := range invalid {
+		if _, err := service.ParseCalendarQuery(tt.region, tt.from, tt.to, false); err == nil {
+			t.Errorf("Expected an error for region=%q from=%q to=%q", tt.region, tt.from, tt.to)
+		}
+	}
+}
+
+func TestCalendarService_GetCalendar(t *testing.T) {
+	watchlistService := NewWatchlistService()
+	watchlistService.AddToWatchlist("user", models.WatchlistItem{ID: "4", Type: "movie", Title: "Old Favourite"})
+	watchlistService.AddToWatchlist("user", models.WatchlistItem{ID: "1", Type: "movie", Title: "Dune: Part Two"})
+
+	service := newTestCalendarService(t, watchlistService)
+	query, _ := service.ParseCalendarQuery("US", "2024-02-25", "2024-03-31", false)
+
+	calendar, err := service.GetCalendar(context.Background(), "user", query)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var got []string
+	for _, day := range calendar.Days {
+		for _, release := range day.Releases {
+			got = append(got, day.Date+" "+release.Title+" "+release.ReleaseType)
+		}
+	}
+	expected := []string{
+		"2024-03-01 Dune: Part Two theatrical",
+		"2024-03-01 Small Film theatrical_limited",
+		"2024-03-05 No Dates theatrical", // lookup failed, date from the list
+		"2024-03-12 Old Favourite physical",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected calendar:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	dune := calendar.Days[0].Releases[0]
+	if !dune.InWatchlist || dune.Certification != "PG-13" {
+		t.Errorf("Expected Dune to be on the watchlist and rated PG-13, got %+v", dune)
+	}
+
+	// Limited to the watchlist
+	query.WatchlistOnly = true
+	calendar, err = service.GetCalendar(context.Background(), "user", query)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(calendar.Days) != 2 || calendar.Days[0].Releases[0].Title != "Dune: Part Two" || calendar.Days[1].Releases[0].Title != "Old Favourite" {
+		t.Errorf("Expected only watchlist releases, got %+v", calendar.Days)
+	}
+}
+
+func TestCalendarService_GetCalendar_ParentalControls(t *testing.T) {
+	service := newTestCalendarService(t, NewWatchlistService())
+	service.parentalService.SetControls("kids", kidControls)
+	query, _ := service.ParseCalendarQuery("US", "2024-02-25", "2024-03-31", false)
+
+	// Movie 1 is rated R by the parental controls' TMDB
+	calendar, err := service.GetCalendar(context.Background(), "kids", query)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var got []string
+	for _, day := range calendar.Days {
+		for _, release := range day.Releases {
+			got = append(got, release.Title)
+		}
+	}
+	if strings.Join(got, ", ") != "Small Film, No Dates" {
+		t.Errorf("Expected Dune: Part Two to be left out, got %v", got)
+	}
+}
+
+func TestCalendarService_GetCalendarICS(t *testing.T) {
+	service := newTestCalendarService(t, NewWatchlistService())
+	query, _ := service.ParseCalendarQuery("US", "2024-03-01", "2024-03-01", false)
+
+	data, err := service.GetCalendarICS(context.Background(), "user", query)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ics := string(data)
+
+	for _, line := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Movie releases (US)\r\n",
+		"UID:release-1-theatrical-US@movie-discovery-app\r\n",
+		"DTSTAMP:20240225T180000Z\r\n",
+		"DTSTART;VALUE=DATE:20240301\r\n",
+		"DTEND;VALUE=DATE:20240302\r\n",
+		"SUMMARY:Dune: Part Two (Theatrical)\r\n",
+		"DESCRIPTION:Limited theatrical release in US. New York\\, Los Angeles\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics, line) {
+			t.Errorf("Expected feed to contain %q, got:\n%s", line, ics)
+		}
+	}
+	if count := strings.Count(ics, "BEGIN:VEVENT"); count != 2 {
+		t.Errorf("Expected 2 events, got %d", count)
+	}
+}
+
+func TestFoldICSLine(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("é", 60)
+
+	folded := foldICSLine(line)
+	for i, part := range strings.Split(folded, "\r\n") {
+		if len(part) > icsMaxLineOctets {
+			t.Errorf("Line %d is %d octets long", i, len(part))
+		}
+		if i > 0 && !strings.HasPrefix(part, " ") {
+			t.Errorf("Continuation line %d does not start with a space", i)
+		}
+	}
+
+	if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != line {
+		t.Errorf("Unfolding did not restore the line: %q", unfolded)
+	}
+}
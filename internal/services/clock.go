@@ -0,0 +1,21 @@
+package services
+
+import "time"
+
+// Clock tells the time and waits for it to pass, so schedules can be tested
+// with a fake clock
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the system clock
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
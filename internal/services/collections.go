@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
+)
+
+// CollectionService handles movie collections and the user's progress
+// through them
+type CollectionService struct {
+	tmdbClient       *TMDBClient
+	watchlistService *WatchlistService
+	parentalService  *ParentalControlService
+}
+
+// NewCollectionService creates a new collection service
+func NewCollectionService(config *configs.Config, watchlistService *WatchlistService, parentalService *ParentalControlService) *CollectionService {
+	return &CollectionService{
+		tmdbClient:       NewTMDBClient(&config.TMDB, &config.Cache, &config.Rate, &config.Retry, &config.Breaker),
+		watchlistService: watchlistService,
+		parentalService:  parentalService,
+	}
+}
+
+// GetCollection gets a collection's movies in release order, with the
+// user's watchlist status and progress through the collection. Movies the
+// user's parental controls don't allow are left out.
+func (s *CollectionService) GetCollection(ctx context.Context, userID string, collectionID int) (*models.Collection, error) {
+	cached, err := s.tmdbClient.GetCollection(ctx, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	partIDs := make([]int, len(cached.Parts))
+	for i, part := range cached.Parts {
+		partIDs[i] = part.ID
+	}
+	allowed, err := s.parentalService.allowedTitles(ctx, userID, map[string][]int{"movie": partIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy the cached collection before filtering, sorting and overlaying
+	collection := *cached
+	collection.Parts = make([]models.CollectionPart, 0, len(cached.Parts))
+	for _, part := range cached.Parts {
+		if allowed[watchlistKey("movie", part.ID)] {
+			collection.Parts = append(collection.Parts, part)
+		}
+	}
+	sortCollectionParts(collection.Parts)
+
+	watchlist, err := s.watchlistService.GetWatchlist(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := indexWatchlist(watchlist)
+	progress := &models.CollectionProgress{Total: len(collection.Parts)}
+	for i := range collection.Parts {
+		part := &collection.Parts[i]
+		if item, ok := items[watchlistKey("movie", part.ID)]; ok {
+			part.InWatchlist = true
+			part.Watched = item.Watched
+			progress.InWatchlist++
+			if item.Watched {
+				progress.Watched++
+			}
+		}
+	}
+	collection.Progress = progress
+
+	return &collection, nil
+}
+
+// AddCollectionToWatchlist adds the movies of a collection that aren't on
+// the user's watchlist yet, returning the parts added and the updated
+// collection. The movies are added together: if one can't be added, none
+// are.
+func (s *CollectionService) AddCollectionToWatchlist(ctx context.Context, userID string, collectionID int) ([]models.CollectionPart, *models.Collection, error) {
+	collection, err := s.GetCollection(ctx, userID, collectionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var items []models.WatchlistItem
+	addedIDs := make(map[int]bool)
+	for _, part := range collection.Parts {
+		if part.InWatchlist {
+			continue
+		}
+
+		items = append(items, models.WatchlistItem{
+			ID:         strconv.Itoa(part.ID),
+			Type:       "movie",
+			Title:      part.Title,
+			PosterPath: part.PosterPath,
+		})
+		addedIDs[part.ID] = true
+	}
+	if err := s.watchlistService.AddAllToWatchlist(userID, items); err != nil {
+		return nil, nil, fmt.Errorf("failed to add %s to watchlist: %w", collection.Name, err)
+	}
+
+	// Re-read so progress reflects the additions
+	collection, err = s.GetCollection(ctx, userID, collectionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	added := []models.CollectionPart{}
+	for _, part := range collection.Parts {
+		if addedIDs[part.ID] {
+			added = append(added, part)
+		}
+	}
+
+	return added, collection, nil
+}
+
+// sortCollectionParts orders parts by release date. Unreleased parts
+// without a date come last.
+func sortCollectionParts(parts []models.CollectionPart) {
+	sort.SliceStable(parts, func(i, j int) bool {
+		a, b := parts[i], parts[j]
+		if (a.ReleaseDate == "") != (b.ReleaseDate == "") {
+			return b.ReleaseDate == ""
+		}
+		return a.ReleaseDate < b.ReleaseDate
+	})
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
+)
+
+func newTestCollectionService(t *testing.T, watchlistService *WatchlistService) *CollectionService {
+	tmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/collection/8091":
+			w.Write([]byte(`{"id": 8091, "name": "Alien Collection", "parts": [
+				{"id": 8077, "title": "Alien³", "release_date": "1992-05-22"},
+				{"id": 348, "title": "Alien", "release_date": "1979-05-25"},
+				{"id": 1, "title": "Alien: Untitled", "release_date": ""},
+				{"id": 679, "title": "Aliens", "release_date": "1986-07-18"}
+			]}`))
+		case "/collection/1570":
+			// The last part has no title, so it can't be added
+			w.Write([]byte(`{"id": 1570, "name": "Die Hard Collection", "parts": [
+				{"id": 562, "title": "Die Hard", "release_date": "1988-07-15"},
+				{"id": 1572, "title": "Die Hard: With a Vengeance", "release_date": "1995-05-19"},
+				{"id": 1573, "title": "", "release_date": "2030-01-01"}
+			]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(tmdb.Close)
+
+	service := NewCollectionService(&configs.Config{
+		TMDB: configs.TMDBConfig{APIKey: "test_key", BaseURL: tmdb.URL},
+	}, watchlistService, newTestParentalService(t))
+	service.tmdbClient.upstream.rateLimiter = NewRateLimiter(60000, 1000)
+	service.tmdbClient.upstream.breaker = NewCircuitBreaker("test", 100, time.Minute)
+	return service
+}
+
+func TestCollectionService_GetCollection(t *testing.T) {
+	watchlistService := NewWatchlistService()
+	watchlistService.AddToWatchlist("user", models.WatchlistItem{ID: "348", Type: "movie", Title: "Alien"})
+	watchlistService.MarkAsWatched("user", "348", "movie", 9)
+	watchlistService.AddToWatchlist("user", models.WatchlistItem{ID: "679", Type: "movie", Title: "Aliens"})
+
+	service := newTestCollectionService(t, watchlistService)
+
+	collection, err := service.GetCollection(context.Background(), "user", 8091)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []string{"Alien", "Aliens", "Alien³", "Alien: Untitled"}
+	for i, title := range expected {
+		if collection.Parts[i].Title != title {
+			t.Errorf("Position %d: expected %q, got %q", i, title, collection.Parts[i].Title)
+		}
+	}
+
+	if progress := *collection.Progress; progress != (models.CollectionProgress{Total: 4, Watched: 1, InWatchlist: 2}) {
+		t.Errorf("Unexpected progress %+v", progress)
+	}
+	if !collection.Parts[0].Watched || !collection.Parts[1].InWatchlist || collection.Parts[1].Watched {
+		t.Errorf("Unexpected watchlist overlay: %+v", collection.Parts)
+	}
+}
+
+func TestCollectionService_AddCollectionToWatchlist(t *testing.T) {
+	watchlistService := NewWatchlistService()
+	watchlistService.AddToWatchlist("user", models.WatchlistItem{ID: "348", Type: "movie", Title: "Alien"})
+
+	service := newTestCollectionService(t, watchlistService)
+
+	added, collection, err := service.AddCollectionToWatchlist(context.Background(), "user", 8091)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(added) != 3 {
+		t.Errorf("Expected 3 movies added, got %+v", added)
+	}
+	if collection.Progress.InWatchlist != 4 {
+		t.Errorf("Expected the whole collection on the watchlist, got %+v", collection.Progress)
+	}
+
+	// Adding again is a no-op
+	added, _, err = service.AddCollectionToWatchlist(context.Background(), "user", 8091)
+	if err != nil || len(added) != 0 {
+		t.Errorf("Expected nothing more to add, got %+v, %v", added, err)
+	}
+}
+
+func TestCollectionService_AddCollectionToWatchlist_PartFails(t *testing.T) {
+	watchlistService := NewWatchlistService()
+	service := newTestCollectionService(t, watchlistService)
+
+	// One part can't be added, so none are
+	added, collection, err := service.AddCollectionToWatchlist(context.Background(), "user", 1570)
+	if err == nil || !strings.Contains(err.Error(), "item title cannot be empty") {
+		t.Fatalf("Expected the untitled part to fail, got %+v, %v", added, err)
+	}
+	if added != nil || collection != nil {
+		t.Errorf("Expected nothing to be returned, got %+v, %+v", added, collection)
+	}
+	if watchlist, _ := watchlistService.GetWatchlist("user"); len(watchlist) != 0 {
+		t.Errorf("Expected the watchlist to be unchanged, got %+v", watchlist)
+	}
+}
+
+func TestCollectionService_ParentalControls(t *testing.T) {
+	watchlistService := NewWatchlistService()
+	service := newTestCollectionService(t, watchlistService)
+	service.parentalService.SetControls("kids", kidControls)
+
+	// Movie 1 is rated R, so it is neither listed nor added
+	collection, err := service.GetCollection(context.Background(), "kids", 8091)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(collection.Parts) != 3 || collection.Progress.Total != 3 {
+		t.Errorf("Expected the R-rated part to be left out, got %+v", collection.Parts)
+	}
+
+	added, _, err := service.AddCollectionToWatchlist(context.Background(), "kids", 8091)
+	if err != nil || len(added) != 3 {
+		t.Fatalf("Expected 3 movies added, got %+v, %v", added, err)
+	}
+	if watchlistService.IsInWatchlist("kids", "1", "movie") {
+		t.Error("Expected the R-rated part not to be added")
+	}
+}
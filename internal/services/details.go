@@ -0,0 +1,54 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sections that can be appended to TMDB movie details, in request order
+var MovieDetailSections = []string{"credits", "keywords", "images", "release_dates", "videos", "external_ids"}
+
+// Sections that can be appended to TMDB TV show details, in request order.
+// TV shows have content ratings where movies have release dates.
+var TVDetailSections = []string{"credits", "keywords", "images", "content_ratings", "videos", "external_ids"}
+
+// ParseDetailSections parses a comma-separated include parameter into the
+// detail sections to append for mediaType ("movie" or "tv"). Sections come
+// back in canonical order so equal requests share a cache entry. An empty
+// include selects no sections.
+func ParseDetailSections(mediaType, include string) ([]string, error) {
+	available := MovieDetailSections
+	if mediaType == "tv" {
+		available = TVDetailSections
+	}
+
+	requested := make(map[string]bool)
+	for _, section := range strings.Split(include, ",") {
+		section = strings.TrimSpace(section)
+		if section == "" {
+			continue
+		}
+		if !containsString(available, section) {
+			return nil, fmt.Errorf("unknown include section %q (available: %s)", section, strings.Join(available, ", "))
+		}
+		requested[section] = true
+	}
+
+	sections := make([]string, 0, len(requested))
+	for _, section := range available {
+		if requested[section] {
+			sections = append(sections, section)
+		}
+	}
+	return sections, nil
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
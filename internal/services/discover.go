@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"movie-discovery-app/internal/models"
+)
+
+// Genre match modes
+const (
+	GenreMatchAll = "all" // Results have every genre
+	GenreMatchAny = "any" // Results have at least one genre
+)
+
+// maxDiscoverPage is the last page TMDB's discover endpoints return
+const maxDiscoverPage = 500
+
+var languagePattern = regexp.MustCompile(`^[a-z]{2}$`)
+
+// Discover discovers movies or TV shows matching the filters, which must have
+// been validated. The cache key covers every TMDB parameter and the locale,
+// so differently filtered or localized requests never share results.
+func (s *GenreService) Discover(ctx context.Context, mediaType string, page int, filters DiscoveryFilters) (*models.SearchResult, error) {
+	params := discoverParams(mediaType, page, filters)
+	cacheKey := fmt.Sprintf("discover_%s_%s", mediaType, params.Encode()) + localeCacheKey(ctx)
+
+	// Check cache first
+	if cached := s.tmdbClient.cache.Get(cacheKey); cached != nil {
+		if result, ok := cached.(*models.SearchResult); ok {
+			return s.scoreResults(result, filters), nil
+		}
+	}
+
+	var result models.SearchResult
+	if err := s.tmdbClient.getLocalized(ctx, "/discover/"+mediaType, params, &result); err != nil {
+		if mediaType == "tv" {
+			return nil, fmt.Errorf("failed to discover TV shows: %w", err)
+		}
+		return nil, fmt.Errorf("failed to discover movies: %w", err)
+	}
+
+	// Fill in overviews TMDB has no translation of
+	if needsEnglishOverviews(ctx, result.Results) {
+		if english, err := s.Discover(englishContext(ctx), mediaType, page, filters); err == nil {
+			fillEnglishOverviews(result.Results, english.Results)
+		}
+	}
+
+	// Cache the result
+	s.tmdbClient.cache.Set(cacheKey, &result, 30*time.Minute)
+
+	return s.scoreResults(&result, filters), nil
+}
+
+// discoverParams maps filters to TMDB discover parameters. Release years
+// filter on the primary release date for movies and the first air date for
+// TV shows; cast and crew only apply to movies.
+func discoverParams(mediaType string, page int, filters DiscoveryFilters) url.Values {
+	params := url.Values{}
+	params.Add("page", strconv.Itoa(page))
+	params.Add("sort_by", tmdbSortBy(filters.SortBy))
+
+	dateField := "primary_release_date"
+	if mediaType == "tv" {
+		dateField = "first_air_date"
+	}
+
+	if filters.MinRating > 0 {
+		params.Add("vote_average.gte", fmt.Sprintf("%.1f", filters.MinRating))
+	}
+	if filters.MaxRating > 0 {
+		params.Add("vote_average.lte", fmt.Sprintf("%.1f", filters.MaxRating))
+	}
+	if filters.MinYear > 0 {
+		params.Add(dateField+".gte", fmt.Sprintf("%d-01-01", filters.MinYear))
+	}
+	if filters.MaxYear > 0 {
+		params.Add(dateField+".lte", fmt.Sprintf("%d-12-31", filters.MaxYear))
+	}
+
+	// TMDB ANDs comma-separated IDs and ORs pipe-separated ones
+	if len(filters.GenreIDs) > 0 {
+		separator := ","
+		if filters.GenreMatch == GenreMatchAny {
+			separator = "|"
+		}
+		params.Add("with_genres", joinIDs(filters.GenreIDs, separator))
+	}
+	if len(filters.WithoutGenres) > 0 {
+		params.Add("without_genres", joinIDs(filters.WithoutGenres, ","))
+	}
+	if filters.MinRuntime > 0 {
+		params.Add("with_runtime.gte", strconv.Itoa(filters.MinRuntime))
+	}
+	if filters.MaxRuntime > 0 {
+		params.Add("with_runtime.lte", strconv.Itoa(filters.MaxRuntime))
+	}
+	if filters.Language != "" {
+		params.Add("with_original_language", filters.Language)
+	}
+	if filters.Certification != "" {
+		params.Add("certification", filters.Certification)
+		params.Add("certification_country", filters.Region)
+	}
+	if filters.MinVoteCount > 0 {
+		params.Add("vote_count.gte", strconv.Itoa(filters.MinVoteCount))
+	}
+	if len(filters.KeywordIDs) > 0 {
+		params.Add("with_keywords", joinIDs(filters.KeywordIDs, ","))
+	}
+	if mediaType == "movie" {
+		if len(filters.CastIDs) > 0 {
+			params.Add("with_cast", joinIDs(filters.CastIDs, ","))
+		}
+		if len(filters.CrewIDs) > 0 {
+			params.Add("with_crew", joinIDs(filters.CrewIDs, ","))
+		}
+	}
+	if len(filters.ProviderIDs) > 0 {
+		params.Add("with_watch_providers", joinIDs(filters.ProviderIDs, "|"))
+		params.Add("watch_region", filters.Region)
+	}
+
+	return params
+}
+
+// ParseDiscoveryFilters reads discover query parameters into validated
+// filters and a page number
+func ParseDiscoveryFilters(mediaType string, params url.Values) (DiscoveryFilters, int, error) {
+	filters := GetDefaultFilters()
+	page := 1
+
+	if mediaType != "movie" && mediaType != "tv" {
+		return filters, page, fmt.Errorf("media type must be movie or tv")
+	}
+
+	ints := []struct {
+		name string
+		dest *int
+	}{
+		{"page", &page},
+		{"min_year", &filters.MinYear},
+		{"max_year", &filters.MaxYear},
+		{"min_runtime", &filters.MinRuntime},
+		{"max_runtime", &filters.MaxRuntime},
+		{"min_votes", &filters.MinVoteCount},
+	}
+	for _, param := range ints {
+		if value := params.Get(param.name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return filters, page, fmt.Errorf("%s must be a whole number", param.name)
+			}
+			*param.dest = parsed
+		}
+	}
+
+	floats := []struct {
+		name string
+		dest *float64
+	}{
+		{"min_rating", &filters.MinRating},
+		{"max_rating", &filters.MaxRating},
+	}
+	for _, param := range floats {
+		if value := params.Get(param.name); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return filters, page, fmt.Errorf("%s must be a number", param.name)
+			}
+			*param.dest = parsed
+		}
+	}
+
+	lists := []struct {
+		name string
+		dest *[]int
+	}{
+		{"genres", &filters.GenreIDs},
+		{"without_genres", &filters.WithoutGenres},
+		{"keywords", &filters.KeywordIDs},
+		{"cast", &filters.CastIDs},
+		{"crew", &filters.CrewIDs},
+		{"providers", &filters.ProviderIDs},
+	}
+	for _, param := range lists {
+		ids, err := parseIDs(params.Get(param.name))
+		if err != nil {
+			return filters, page, fmt.Errorf("%s must be a comma-separated list of IDs", param.name)
+		}
+		*param.dest = ids
+	}
+
+	if sortBy := params.Get("sort_by"); sortBy != "" {
+		filters.SortBy = sortBy
+	}
+	filters.GenreMatch = params.Get("genre_match")
+	filters.Language = params.Get("original_language")
+	filters.Certification = params.Get("certification")
+	filters.Region = params.Get("region")
+
+	if page < 1 || page > maxDiscoverPage {
+		return filters, page, fmt.Errorf("page must be between 1 and %d", maxDiscoverPage)
+	}
+	if mediaType == "tv" && (len(filters.CastIDs) > 0 || len(filters.CrewIDs) > 0) {
+		return filters, page, fmt.Errorf("cast and crew filters are only supported for movies")
+	}
+	if err := filters.Validate(); err != nil {
+		return filters, page, err
+	}
+
+	return filters, page, nil
+}
+
+// parseIDs parses a comma-separated list of IDs
+func parseIDs(value string) ([]int, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var ids []int
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// joinIDs joins IDs with a separator
+func joinIDs(ids []int, separator string) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, separator)
+}
+
+// containsInt reports whether ids contains id
+func containsInt(ids []int, id int) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
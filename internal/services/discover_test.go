@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"movie-discovery-app/configs"
+)
+
+func TestParseDiscoveryFilters(t *testing.T) {
+	params, _ := url.ParseQuery("genres=28,12&genre_match=any&without_genres=27&min_runtime=90&max_runtime=150" +
+		"&original_language=JA&certification=PG-13&region=us&min_votes=200&keywords=9715&cast=6193&crew=138&providers=8,337&page=2")
+
+	filters, page, err := ParseDiscoveryFilters("movie", params)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if page != 2 {
+		t.Errorf("Expected page 2, got %d", page)
+	}
+
+	got := discoverParams("movie", page, filters)
+	expected := map[string]string{
+		"with_genres":              "28|12",
+		"without_genres":           "27",
+		"with_runtime.gte":         "90",
+		"with_runtime.lte":         "150",
+		"with_original_language":   "ja",
+		"certification":            "PG-13",
+		"certification_country":    "US",
+		"vote_count.gte":           "200",
+		"with_keywords":            "9715",
+		"with_cast":                "6193",
+		"with_crew":                "138",
+		"with_watch_providers":     "8|337",
+		"watch_region":             "US",
+		"primary_release_date.gte": "1900-01-01",
+		"sort_by":                  "popularity.desc",
+		"page":                     "2",
+	}
+	for name, value := range expected {
+		if got.Get(name) != value {
+			t.Errorf("Expected %s=%q, got %q", name, value, got.Get(name))
+		}
+	}
+
+	// TV shows filter on the first air date and default to matching every genre
+	params, _ = url.ParseQuery("genres=18,80")
+	filters, page, err = ParseDiscoveryFilters("tv", params)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	got = discoverParams("tv", page, filters)
+	if got.Get("with_genres") != "18,80" || got.Get("first_air_date.gte") != "1900-01-01" || got.Get("primary_release_date.gte") != "" {
+		t.Errorf("Unexpected TV parameters: %v", got)
+	}
+}
+
+func TestParseDiscoveryFilters_Invalid(t *testing.T) {
+	tests := []struct {
+		mediaType string
+		query     string
+	}{
+		{"person", ""},
+		{"movie", "page=0"},
+		{"movie", "page=501"},
+		{"movie", "min_runtime=long"},
+		{"movie", "min_rating=high"},
+		{"movie", "genres=28,action"},
+		{"movie", "genres=-28"},
+		{"movie", "genre_match=some"},
+		{"movie", "genres=28&without_genres=28"},
+		{"movie", "min_runtime=150&max_runtime=90"},
+		{"movie", "min_votes=-1"},
+		{"movie", "original_language=english"},
+		{"movie", "region=USA"},
+		{"movie", "certification=R"},
+		{"movie", "providers=8"},
+		{"tv", "cast=6193"},
+	}
+
+	for _, tt := range tests {
+		params, _ := url.ParseQuery(tt.query)
+		if _, _, err := ParseDiscoveryFilters(tt.mediaType, params); err == nil {
+			t.Errorf("Expected an error for %s?%s", tt.mediaType, tt.query)
+		}
+	}
+}
+
+func TestGenreService_Discover_CachesPerFilters(t *testing.T) {
+	var requests int32
+	tmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`{"page": 1, "results": [{"id": 1, "title": "` + r.URL.Query().Get("with_original_language") + `"}], "total_pages": 1, "total_results": 1}`))
+	}))
+	defer tmdb.Close()
+
+	service := NewGenreService(&configs.Config{
+		TMDB: configs.TMDBConfig{APIKey: "test_key", BaseURL: tmdb.URL},
+	})
+	service.tmdbClient.upstream.rateLimiter = NewRateLimiter(60000, 1000)
+	service.tmdbClient.upstream.breaker = NewCircuitBreaker("test", 100, time.Minute)
+
+	discover := func(language string) string {
+		filters := GetDefaultFilters()
+		filters.GenreIDs = []int{28}
+		filters.Language = language
+		if err := filters.Validate(); err != nil {
+			t.Fatalf("Expected valid filters, got %v", err)
+		}
+		result, err := service.Discover(context.Background(), "movie", 1, filters)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return result.Results[0].(map[string]interface{})["title"].(string)
+	}
+
+	if title := discover("ja"); title != "ja" {
+		t.Errorf("Expected the ja result, got %q", title)
+	}
+	if title := discover("ko"); title != "ko" {
+		t.Errorf("Expected the ko result, not the cached ja one, got %q", title)
+	}
+	if title := discover("ja"); title != "ja" {
+		t.Errorf("Expected the cached ja result, got %q", title)
+	}
+	if count := atomic.LoadInt32(&requests); count != 2 {
+		t.Errorf("Expected 2 requests to TMDB, got %d", count)
+	}
+}
+
+func TestGenreService_SearchByGenreAndKeyword(t *testing.T) {
+	var discovered url.Values
+	tmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search/keyword":
+			switch r.URL.Query().Get("query") {
+			case "Heist":
+				w.Write([]byte(`{"page": 1, "results": [{"id": 2, "name": "heist movie"}, {"id": 10051, "name": "heist"}], "total_pages": 1, "total_results": 2}`))
+			case "time loop":
+				w.Write([]byte(`{"page": 1, "results": [{"id": 4379, "name": "time loop paradox"}], "total_pages": 1, "total_results": 1}`))
+			case "robot":
+				w.Write([]byte(`{"page": 1, "results": [{"id": 14544, "name": "robot uprising"}, {"id": 310, "name": "robot cop"}], "total_pages": 1, "total_results": 2}`))
+			default:
+				w.Write([]byte(`{"page": 1, "results": [], "total_pages": 0, "total_results": 0}`))
+			}
+		case "/discover/tv":
+			discovered = r.URL.Query()
+			w.Write([]byte(`{"page": 1, "results": [{"id": 1, "name": "Money Heist"}], "total_pages": 1, "total_results": 1}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer tmdb.Close()
+
+	service := NewGenreService(&configs.Config{
+		TMDB: configs.TMDBConfig{APIKey: "test_key", BaseURL: tmdb.URL},
+	})
+	service.tmdbClient.upstream.rateLimiter = NewRateLimiter(60000, 1000)
+	service.tmdbClient.upstream.breaker = NewCircuitBreaker("test", 100, time.Minute)
+
+	// An exact name match and a single result both resolve
+	search, err := service.SearchByGenreAndKeyword(context.Background(), 80, "Heist, time loop", "tv", 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(search.Keywords) != 2 || search.Keywords[0].Keyword.ID != 10051 || search.Keywords[1].Keyword.ID != 4379 {
+		t.Fatalf("Unexpected keyword matches: %+v", search.Keywords)
+	}
+	if search.Results == nil || len(search.Results.Results) != 1 {
+		t.Fatalf("Expected discover results, got %+v", search.Results)
+	}
+	if discovered.Get("with_genres") != "80" || discovered.Get("with_keywords") != "10051,4379" {
+		t.Errorf("Unexpected discover parameters: %v", discovered)
+	}
+
+	// An ambiguous keyword returns suggestions instead of results
+	discovered = nil
+	search, err = service.SearchByGenreAndKeyword(context.Background(), 80, "Heist,robot", "tv", 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if search.Results != nil || discovered != nil {
+		t.Errorf("Expected no discover request for an ambiguous keyword, got %+v", search.Results)
+	}
+	robot := search.Keywords[1]
+	if robot.Keyword != nil || len(robot.Suggestions) != 2 || robot.Suggestions[0].Name != "robot uprising" {
+		t.Errorf("Expected suggestions for robot, got %+v", robot)
+	}
+
+	if _, err := service.SearchByGenreAndKeyword(context.Background(), 80, "heist", "person", 1); err == nil {
+		t.Error("Expected an error for an unsupported media type")
+	}
+}
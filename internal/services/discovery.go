@@ -1,10 +1,13 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
+	"time"
 
 	"movie-discovery-app/configs"
 	"movie-discovery-app/internal/models"
@@ -16,75 +19,176 @@ type DiscoveryService struct {
 	omdbClient       *OMDBClient
 	youtubeService   *YouTubeService
 	providersService *ProvidersService
+	genreService     *GenreService // Genre lookups and discover for structured queries
+
+	// Recently seen titles for autocomplete, and the budget for asking TMDB
+	titles              *PrefixIndex
+	autocompleteTimeout time.Duration
+
+	// Everything fetched so far, for searching while TMDB is unavailable
+	searchIndex      *SearchIndex
+	searchIndexStore *searchIndexStore
+
+	// Bounds for enriching search results with OMDB data
+	enrichmentConcurrency int
+	enrichmentTimeout     time.Duration
+
+	scorer *Scorer
 }
 
 // NewDiscoveryService creates a new discovery service
 func NewDiscoveryService(config *configs.Config) *DiscoveryService {
-	tmdbClient := NewTMDBClient(&config.TMDB, &config.Cache, &config.Rate)
-	omdbClient := NewOMDBClient(&config.OMDB, &config.Cache, &config.Rate)
+	tmdbClient := NewTMDBClient(&config.TMDB, &config.Cache, &config.Rate, &config.Retry, &config.Breaker)
+	omdbClient := NewOMDBClient(&config.OMDB, &config.Cache, &config.Rate, &config.Retry, &config.Breaker)
+
+	concurrency := config.Enrichment.Concurrency
+	if concurrency <= 0 {
+		concurrency = 5
+	}
+	timeout := config.Enrichment.Timeout
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+
+	autocompleteTimeout := config.Autocomplete.Timeout
+	if autocompleteTimeout <= 0 {
+		autocompleteTimeout = 250 * time.Millisecond
+	}
+
+	scorer := NewScorer(&config.Scoring)
 
 	return &DiscoveryService{
-		tmdbClient:       tmdbClient,
-		omdbClient:       omdbClient,
-		youtubeService:   NewYouTubeService(),
-		providersService: NewProvidersService(),
+		tmdbClient:            tmdbClient,
+		omdbClient:            omdbClient,
+		youtubeService:        NewYouTubeService(config),
+		providersService:      NewProvidersService(tmdbClient, &config.Breaker),
+		genreService:          &GenreService{tmdbClient: tmdbClient, scorer: scorer},
+		enrichmentConcurrency: concurrency,
+		enrichmentTimeout:     timeout,
+		titles:                NewPrefixIndex(config.Autocomplete.IndexSize),
+		autocompleteTimeout:   autocompleteTimeout,
+		searchIndex:           newSearchIndex(config.SearchIndex.Path),
+		searchIndexStore:      &searchIndexStore{path: config.SearchIndex.Path, saveInterval: config.SearchIndex.SaveInterval},
+		scorer:                scorer,
 	}
 }
 
-// SearchMovies searches for movies using both TMDB and OMDB
-func (s *DiscoveryService) SearchMovies(query string, page int) (*models.SearchResult, error) {
+// SearchMovies searches for movies using both TMDB and OMDB. Structured
+// queries (see ParseSearchQuery) are run through discover.
+func (s *DiscoveryService) SearchMovies(ctx context.Context, query string, page int) (*models.SearchResult, error) {
+	parsed, err := ParseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Structured() {
+		return s.searchStructured(ctx, "movie", parsed, page)
+	}
+
 	// Get results from TMDB first (primary source)
-	tmdbResults, err := s.tmdbClient.SearchMovies(query, page)
+	tmdbResults, err := s.tmdbClient.SearchMovies(ctx, query, page)
 	if err != nil {
 		log.Printf("TMDB search error: %v", err)
-		// Fallback to OMDB if TMDB fails
-		return s.searchMoviesOMDBFallback(query, page)
+		// No point falling back once the request has been abandoned
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to search movies: %w", err)
+		}
+		// Fall back to titles fetched earlier, then to OMDB
+		if local := s.searchLocalIndex("movie", query, page); local != nil {
+			return local, nil
+		}
+		return s.searchMoviesOMDBFallback(ctx, query, page)
 	}
 
-	// Enhance TMDB results with OMDB data
-	enhancedResults := make([]interface{}, 0, len(tmdbResults.Results))
-	for _, result := range tmdbResults.Results {
-		if movieData, ok := result.(map[string]interface{}); ok {
-			enhanced := s.enhanceMovieWithOMDB(movieData)
-			enhancedResults = append(enhancedResults, enhanced)
-		}
+	// Enhance TMDB results with OMDB data, skipping OMDB while its breaker is open
+	results, degraded, err := s.enrichResults(ctx, tmdbResults.Results, s.omdbClient.Available, s.enhanceMovieWithOMDB)
+	if err != nil {
+		return nil, fmt.Errorf("movie search cancelled: %w", err)
 	}
 
-	tmdbResults.Results = enhancedResults
-	return tmdbResults, nil
+	s.scoreResults(results)
+	s.indexResults(ctx, "movie", results)
+
+	response := *tmdbResults
+	response.Results = results
+	if degraded {
+		response.Degraded = []string{"omdb"}
+	}
+
+	return &response, nil
 }
 
-// SearchTVShows searches for TV shows using both TMDB and OMDB
-func (s *DiscoveryService) SearchTVShows(query string, page int) (*models.SearchResult, error) {
+// SearchTVShows searches for TV shows using both TMDB and OMDB. Structured
+// queries (see ParseSearchQuery) are run through discover.
+func (s *DiscoveryService) SearchTVShows(ctx context.Context, query string, page int) (*models.SearchResult, error) {
+	parsed, err := ParseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Structured() {
+		return s.searchStructured(ctx, "tv", parsed, page)
+	}
+
 	// Get results from TMDB first (primary source)
-	tmdbResults, err := s.tmdbClient.SearchTVShows(query, page)
+	tmdbResults, err := s.tmdbClient.SearchTVShows(ctx, query, page)
 	if err != nil {
 		log.Printf("TMDB TV search error: %v", err)
-		// Return error for TV shows as OMDB has limited TV support
+		// Fall back to TV shows fetched earlier; OMDB has limited TV support
+		if ctx.Err() == nil {
+			if local := s.searchLocalIndex("tv", query, page); local != nil {
+				return local, nil
+			}
+		}
 		return nil, fmt.Errorf("failed to search TV shows: %w", err)
 	}
 
-	// Enhance TMDB results with OMDB data where possible
-	enhancedResults := make([]interface{}, 0, len(tmdbResults.Results))
-	for _, result := range tmdbResults.Results {
-		if tvData, ok := result.(map[string]interface{}); ok {
-			enhanced := s.enhanceTVShowWithOMDB(tvData)
-			enhancedResults = append(enhancedResults, enhanced)
-		}
+	// Enhance TMDB results with OMDB data where possible, skipping OMDB while its breaker is open
+	results, degraded, err := s.enrichResults(ctx, tmdbResults.Results, s.omdbClient.Available, s.enhanceTVShowWithOMDB)
+	if err != nil {
+		return nil, fmt.Errorf("TV show search cancelled: %w", err)
+	}
+
+	s.scoreResults(results)
+	s.indexResults(ctx, "tv", results)
+
+	response := *tmdbResults
+	response.Results = results
+	if degraded {
+		response.Degraded = []string{"omdb"}
 	}
 
-	tmdbResults.Results = enhancedResults
-	return tmdbResults, nil
+	return &response, nil
 }
 
-// GetMovieDetails gets comprehensive movie details from both APIs
-func (s *DiscoveryService) GetMovieDetails(movieID int) (*models.Movie, error) {
-	// Get basic details from TMDB
-	tmdbMovie, err := s.tmdbClient.GetMovieDetails(movieID)
+// searchTMDB runs a search or structured query on TMDB alone, without OMDB
+// enrichment, scoring or indexing, for background work that only needs IDs,
+// titles and dates. There is no fallback while TMDB is down.
+func (s *DiscoveryService) searchTMDB(ctx context.Context, mediaType, query string, page int) (*models.SearchResult, error) {
+	parsed, err := ParseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Structured() {
+		return s.fetchStructured(ctx, mediaType, parsed, page)
+	}
+
+	if mediaType == "tv" {
+		return s.tmdbClient.SearchTVShows(ctx, query, page)
+	}
+	return s.tmdbClient.SearchMovies(ctx, query, page)
+}
+
+// GetMovieDetails gets comprehensive movie details from both APIs, including
+// the requested TMDB sections (see ParseDetailSections)
+func (s *DiscoveryService) GetMovieDetails(ctx context.Context, movieID int, include []string) (*models.Movie, error) {
+	// Get details from TMDB, with the requested sections in the same call
+	cachedMovie, err := s.tmdbClient.GetMovieDetails(ctx, movieID, include...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get movie details from TMDB: %w", err)
 	}
 
+	// Work on a copy so OMDB data isn't merged into the cached TMDB response
+	tmdbMovie := *cachedMovie
+
 	// Try to enhance with OMDB data
 	if tmdbMovie.Title != "" {
 		year := ""
@@ -92,26 +196,54 @@ func (s *DiscoveryService) GetMovieDetails(movieID int) (*models.Movie, error) {
 			year = tmdbMovie.ReleaseDate[:4]
 		}
 
-		omdbMovie, err := s.omdbClient.GetMovieByTitle(tmdbMovie.Title, year)
+		// TMDB movie details usually carry the IMDb ID already
+		imdbID := tmdbMovie.IMDBId
+		if imdbID == "" {
+			imdbID = s.externalIMDBID(ctx, "movie", tmdbMovie.ID)
+		}
+
+		omdbMovie, confidence, err := s.lookupOMDB(ctx, "movie", imdbID, tmdbMovie.Title, year)
 		if err != nil {
 			log.Printf("Failed to get OMDB data for movie %s: %v", tmdbMovie.Title, err)
+			if errors.Is(err, ErrUpstreamUnavailable) {
+				tmdbMovie.Degraded = []string{"omdb"}
+			}
 		} else {
 			// Merge OMDB data into TMDB movie
-			s.mergeOMDBIntoMovie(tmdbMovie, omdbMovie)
+			s.mergeOMDBIntoMovie(&tmdbMovie, omdbMovie)
+			tmdbMovie.MatchConfidence = confidence
 		}
 	}
 
-	return tmdbMovie, nil
+	tmdbMovie.Composite = s.scorer.ScoreMovie(&tmdbMovie)
+	s.indexMovie(ctx, &tmdbMovie)
+
+	return &tmdbMovie, nil
 }
 
-// GetTVShowDetails gets comprehensive TV show details from both APIs
-func (s *DiscoveryService) GetTVShowDetails(tvID int) (*models.TVShow, error) {
-	// Get basic details from TMDB
-	tmdbTVShow, err := s.tmdbClient.GetTVShowDetails(tvID)
+// GetTVShowDetails gets comprehensive TV show details from both APIs,
+// including the requested TMDB sections (see ParseDetailSections)
+func (s *DiscoveryService) GetTVShowDetails(ctx context.Context, tvID int, include []string) (*models.TVShow, error) {
+	// TV details lack the IMDb ID, so external IDs are always fetched for
+	// OMDB matching and only returned when requested
+	sections := include
+	if !containsString(include, "external_ids") {
+		sections = append(append([]string{}, include...), "external_ids")
+	}
+
+	// Get details from TMDB, with the requested sections in the same call
+	cachedTVShow, err := s.tmdbClient.GetTVShowDetails(ctx, tvID, sections...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get TV show details from TMDB: %w", err)
 	}
 
+	// Work on a copy so OMDB data isn't merged into the cached TMDB response
+	tmdbTVShow := *cachedTVShow
+	externalIDs := tmdbTVShow.ExternalIDs
+	if !containsString(include, "external_ids") {
+		tmdbTVShow.ExternalIDs = nil
+	}
+
 	// Try to enhance with OMDB data
 	if tmdbTVShow.Name != "" {
 		year := ""
@@ -119,30 +251,48 @@ func (s *DiscoveryService) GetTVShowDetails(tvID int) (*models.TVShow, error) {
 			year = tmdbTVShow.FirstAirDate[:4]
 		}
 
-		omdbTVShow, err := s.omdbClient.GetTVShowByTitle(tmdbTVShow.Name, year)
+		imdbID := ""
+		if externalIDs != nil {
+			imdbID = externalIDs.IMDBID
+		}
+
+		omdbTVShow, confidence, err := s.lookupOMDB(ctx, "tv", imdbID, tmdbTVShow.Name, year)
 		if err != nil {
 			log.Printf("Failed to get OMDB data for TV show %s: %v", tmdbTVShow.Name, err)
+			if errors.Is(err, ErrUpstreamUnavailable) {
+				tmdbTVShow.Degraded = []string{"omdb"}
+			}
 		} else {
 			// Merge OMDB data into TMDB TV show
-			s.mergeOMDBIntoTVShow(tmdbTVShow, omdbTVShow)
+			s.mergeOMDBIntoTVShow(&tmdbTVShow, omdbTVShow)
+			tmdbTVShow.MatchConfidence = confidence
 		}
 	}
 
-	return tmdbTVShow, nil
+	tmdbTVShow.Composite = s.scorer.ScoreTVShow(&tmdbTVShow)
+	s.indexTVShow(ctx, &tmdbTVShow)
+
+	return &tmdbTVShow, nil
 }
 
 // GetTrendingMovies gets trending movies from TMDB
-func (s *DiscoveryService) GetTrendingMovies(timeWindow string, page int) (*models.TrendingResponse, error) {
+func (s *DiscoveryService) GetTrendingMovies(ctx context.Context, timeWindow string, page int) (*models.TrendingResponse, error) {
 	// Validate time window
 	if timeWindow != "day" && timeWindow != "week" {
 		timeWindow = "week" // default
 	}
 
-	return s.tmdbClient.GetTrendingMovies(timeWindow, page)
+	trending, err := s.tmdbClient.GetTrendingMovies(ctx, timeWindow, page)
+	if err != nil {
+		return nil, err
+	}
+	s.indexResults(ctx, "movie", trending.Results)
+
+	return trending, nil
 }
 
 // GetTrendingTVShows gets trending TV shows from TMDB
-func (s *DiscoveryService) GetTrendingTVShows(timeWindow string, page int) (*models.TrendingResponse, error) {
+func (s *DiscoveryService) GetTrendingTVShows(ctx context.Context, timeWindow string, page int) (*models.TrendingResponse, error) {
 	// This would be implemented similar to GetTrendingMovies
 	// For now, return an empty result
 	return &models.TrendingResponse{
@@ -153,11 +303,22 @@ func (s *DiscoveryService) GetTrendingTVShows(timeWindow string, page int) (*mod
 	}, nil
 }
 
+// scoreResults adds a composite score to each search result
+func (s *DiscoveryService) scoreResults(results []interface{}) {
+	for _, result := range results {
+		if item, ok := result.(map[string]interface{}); ok {
+			if composite := s.scorer.ScoreResult(item); composite != nil {
+				item["composite"] = composite
+			}
+		}
+	}
+}
+
 // enhanceMovieWithOMDB enhances TMDB movie data with OMDB information
-func (s *DiscoveryService) enhanceMovieWithOMDB(movieData map[string]interface{}) map[string]interface{} {
+func (s *DiscoveryService) enhanceMovieWithOMDB(ctx context.Context, movieData map[string]interface{}) (map[string]interface{}, bool) {
 	title, ok := movieData["title"].(string)
 	if !ok {
-		return movieData
+		return movieData, false
 	}
 
 	releaseDate, _ := movieData["release_date"].(string)
@@ -166,11 +327,16 @@ func (s *DiscoveryService) enhanceMovieWithOMDB(movieData map[string]interface{}
 		year = releaseDate[:4]
 	}
 
-	// Try to get OMDB data
-	omdbData, err := s.omdbClient.GetMovieByTitle(title, year)
+	// Match OMDB data by IMDb ID so remakes don't get each other's ratings
+	imdbID := ""
+	if id, ok := movieData["id"].(float64); ok {
+		imdbID = s.externalIMDBID(ctx, "movie", int(id))
+	}
+
+	omdbData, confidence, err := s.lookupSearchResultOMDB(ctx, "movie", imdbID, title, year)
 	if err != nil {
 		log.Printf("Failed to enhance movie %s with OMDB data: %v", title, err)
-		return movieData
+		return movieData, false
 	}
 
 	// Add OMDB fields to the movie data
@@ -184,15 +350,18 @@ func (s *DiscoveryService) enhanceMovieWithOMDB(movieData map[string]interface{}
 	movieData["country"] = omdbData.Country
 	movieData["awards"] = omdbData.Awards
 	movieData["imdb_id"] = omdbData.IMDBID
+	movieData["match_confidence"] = confidence
+	movieData["ratings"] = omdbData.GetRatings()
+	movieData["certification"] = omdbValue(omdbData.Rated)
 
-	return movieData
+	return movieData, true
 }
 
 // enhanceTVShowWithOMDB enhances TMDB TV show data with OMDB information
-func (s *DiscoveryService) enhanceTVShowWithOMDB(tvData map[string]interface{}) map[string]interface{} {
+func (s *DiscoveryService) enhanceTVShowWithOMDB(ctx context.Context, tvData map[string]interface{}) (map[string]interface{}, bool) {
 	name, ok := tvData["name"].(string)
 	if !ok {
-		return tvData
+		return tvData, false
 	}
 
 	firstAirDate, _ := tvData["first_air_date"].(string)
@@ -201,11 +370,16 @@ func (s *DiscoveryService) enhanceTVShowWithOMDB(tvData map[string]interface{})
 		year = firstAirDate[:4]
 	}
 
-	// Try to get OMDB data
-	omdbData, err := s.omdbClient.GetTVShowByTitle(name, year)
+	// Match OMDB data by IMDb ID so same-name shows don't get each other's ratings
+	imdbID := ""
+	if id, ok := tvData["id"].(float64); ok {
+		imdbID = s.externalIMDBID(ctx, "tv", int(id))
+	}
+
+	omdbData, confidence, err := s.lookupSearchResultOMDB(ctx, "tv", imdbID, name, year)
 	if err != nil {
 		log.Printf("Failed to enhance TV show %s with OMDB data: %v", name, err)
-		return tvData
+		return tvData, false
 	}
 
 	// Add OMDB fields to the TV show data
@@ -219,8 +393,11 @@ func (s *DiscoveryService) enhanceTVShowWithOMDB(tvData map[string]interface{})
 	tvData["country"] = omdbData.Country
 	tvData["awards"] = omdbData.Awards
 	tvData["imdb_id"] = omdbData.IMDBID
+	tvData["match_confidence"] = confidence
+	tvData["ratings"] = omdbData.GetRatings()
+	tvData["certification"] = omdbValue(omdbData.Rated)
 
-	return tvData
+	return tvData, true
 }
 
 // mergeOMDBIntoMovie merges OMDB data into a TMDB movie struct
@@ -235,6 +412,7 @@ func (s *DiscoveryService) mergeOMDBIntoMovie(movie *models.Movie, omdbData *OMD
 	movie.Country = omdbData.Country
 	movie.Awards = omdbData.Awards
 	movie.IMDBId = omdbData.IMDBID
+	movie.OMDBDetails = omdbData.GetDetails()
 
 	// Parse runtime if available
 	if omdbData.Runtime != "" && omdbData.Runtime != "N/A" {
@@ -257,11 +435,19 @@ func (s *DiscoveryService) mergeOMDBIntoTVShow(tvShow *models.TVShow, omdbData *
 	tvShow.Country = omdbData.Country
 	tvShow.Awards = omdbData.Awards
 	tvShow.IMDBId = omdbData.IMDBID
+	tvShow.OMDBDetails = omdbData.GetDetails()
+
+	// Fill in the season count if TMDB didn't have it
+	if tvShow.NumberOfSeasons == 0 {
+		if seasons, err := strconv.Atoi(omdbData.TotalSeasons); err == nil {
+			tvShow.NumberOfSeasons = seasons
+		}
+	}
 }
 
 // searchMoviesOMDBFallback provides fallback search using OMDB when TMDB fails
-func (s *DiscoveryService) searchMoviesOMDBFallback(query string, page int) (*models.SearchResult, error) {
-	omdbResults, err := s.omdbClient.SearchMovies(query, page)
+func (s *DiscoveryService) searchMoviesOMDBFallback(ctx context.Context, query string, page int) (*models.SearchResult, error) {
+	omdbResults, err := s.omdbClient.SearchMovies(ctx, query, page)
 	if err != nil {
 		return nil, fmt.Errorf("both TMDB and OMDB search failed: %w", err)
 	}
@@ -296,8 +482,19 @@ func (s *DiscoveryService) ValidateSearchQuery(query string) error {
 		return fmt.Errorf("search query cannot be empty")
 	}
 
-	if len(query) > 100 {
-		return fmt.Errorf("search query too long (max 100 characters)")
+	if len(query) > maxStructuredQueryLength {
+		return fmt.Errorf("search query too long (max %d characters)", maxStructuredQueryLength)
+	}
+
+	// Field terms of structured queries don't count towards the text limit
+	if len(query) > maxSearchTextLength {
+		parsed, err := ParseSearchQuery(query)
+		if err != nil || !parsed.Structured() {
+			return fmt.Errorf("search query too long (max %d characters)", maxSearchTextLength)
+		}
+		if len(parsed.Text) > maxSearchTextLength {
+			return fmt.Errorf("search text too long (max %d characters besides field terms)", maxSearchTextLength)
+		}
 	}
 
 	return nil
@@ -317,71 +514,55 @@ func (s *DiscoveryService) ValidatePage(page int) error {
 }
 
 // GetMovieTrailers gets trailers for a movie
-func (s *DiscoveryService) GetMovieTrailers(movieID int) ([]models.YouTubeVideo, error) {
-	// First try to get movie details to get title and year
-	movie, err := s.tmdbClient.GetMovieDetails(movieID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get movie details: %w", err)
-	}
-
-	year := ""
-	if movie.ReleaseDate != "" && len(movie.ReleaseDate) >= 4 {
-		year = movie.ReleaseDate[:4]
-	}
-
-	return s.youtubeService.SearchTrailers(movie.Title, year, "movie")
+func (s *DiscoveryService) GetMovieTrailers(ctx context.Context, movieID int) ([]models.YouTubeVideo, error) {
+	return s.getTrailers(ctx, movieID, "movie")
 }
 
 // GetTVTrailers gets trailers for a TV show
-func (s *DiscoveryService) GetTVTrailers(tvID int) ([]models.YouTubeVideo, error) {
-	// First try to get TV details to get title and year
-	tvShow, err := s.tmdbClient.GetTVShowDetails(tvID)
+func (s *DiscoveryService) GetTVTrailers(ctx context.Context, tvID int) ([]models.YouTubeVideo, error) {
+	return s.getTrailers(ctx, tvID, "tv")
+}
+
+// GetOfficialTrailer gets the most relevant official trailer
+func (s *DiscoveryService) GetOfficialTrailer(ctx context.Context, mediaID int, mediaType string) (*models.YouTubeVideo, error) {
+	trailers, err := s.getTrailers(ctx, mediaID, mediaType)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get TV show details: %w", err)
+		return nil, err
 	}
 
-	year := ""
-	if tvShow.FirstAirDate != "" && len(tvShow.FirstAirDate) >= 4 {
-		year = tvShow.FirstAirDate[:4]
+	if len(trailers) == 0 {
+		return nil, ErrNoTrailer
 	}
 
-	return s.youtubeService.SearchTrailers(tvShow.Name, year, "tv")
-}
-
-// GetOfficialTrailer gets the most relevant official trailer
-func (s *DiscoveryService) GetOfficialTrailer(mediaID int, mediaType string) (*models.YouTubeVideo, error) {
-	switch mediaType {
-	case "movie":
-		movie, err := s.tmdbClient.GetMovieDetails(mediaID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get movie details: %w", err)
-		}
-		year := ""
-		if movie.ReleaseDate != "" && len(movie.ReleaseDate) >= 4 {
-			year = movie.ReleaseDate[:4]
-		}
-		return s.youtubeService.GetOfficialTrailer(movie.Title, year, "movie")
-	case "tv":
-		tvShow, err := s.tmdbClient.GetTVShowDetails(mediaID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get TV show details: %w", err)
-		}
-		year := ""
-		if tvShow.FirstAirDate != "" && len(tvShow.FirstAirDate) >= 4 {
-			year = tvShow.FirstAirDate[:4]
-		}
-		return s.youtubeService.GetOfficialTrailer(tvShow.Name, year, "tv")
-	default:
-		return nil, fmt.Errorf("unsupported media type: %s", mediaType)
+	// TMDB trailers are already ranked; YouTube results need picking
+	if trailers[0].Source == TrailerSourceYouTube {
+		return pickOfficialTrailer(trailers), nil
 	}
+	return &trailers[0], nil
 }
 
 // GetWatchProviders gets watch providers for a movie or TV show
-func (s *DiscoveryService) GetWatchProviders(mediaID int, mediaType string) (*models.WatchProviders, error) {
-	return s.providersService.GetWatchProviders(mediaID, mediaType)
+func (s *DiscoveryService) GetWatchProviders(ctx context.Context, mediaID int, mediaType string) (*models.WatchProviders, error) {
+	return s.providersService.GetWatchProviders(ctx, mediaID, mediaType)
 }
 
 // GetStreamingServices gets streaming services for a specific region
-func (s *DiscoveryService) GetStreamingServices(mediaID int, mediaType string, region string) ([]models.WatchProvider, error) {
-	return s.providersService.GetStreamingServices(mediaID, mediaType, region)
+func (s *DiscoveryService) GetStreamingServices(ctx context.Context, mediaID int, mediaType string, region string) ([]models.WatchProvider, error) {
+	return s.providersService.GetStreamingServices(ctx, mediaID, mediaType, region)
+}
+
+// YouTubeQuota reports the YouTube Data API quota spent today
+func (s *DiscoveryService) YouTubeQuota() QuotaSnapshot {
+	return s.youtubeService.Quota()
+}
+
+// UpstreamStatus reports circuit breaker and rate limiter state for each
+// upstream dependency
+func (s *DiscoveryService) UpstreamStatus() []UpstreamStatus {
+	return []UpstreamStatus{
+		s.tmdbClient.upstream.status(),
+		s.omdbClient.upstream.status(),
+		s.youtubeService.upstream.status(),
+		s.providersService.upstream.status(),
+	}
 }
@@ -1,10 +1,21 @@
 package services
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
 )
 
 func TestDiscoveryService_ValidateSearchQuery(t *testing.T) {
@@ -57,6 +68,21 @@ func TestDiscoveryService_ValidateSearchQuery(t *testing.T) {
 			query:   "a",
 			wantErr: false,
 		},
+		{
+			name:    "Long structured query",
+			query:   `director:"Denis Villeneuve" actor:"Rebecca Ferguson" year:2015..2024 genre:"science fiction" rating:>7 runtime:<180 language:en on:netflix dune`,
+			wantErr: false,
+		},
+		{
+			name:    "Structured query with long text",
+			query:   "year:>2015 " + strings.Repeat("a", 101),
+			wantErr: true,
+		},
+		{
+			name:    "Structured query over the overall limit",
+			query:   strings.Repeat("genre:drama ", 50),
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -165,28 +191,110 @@ func TestCache_SetAndGet(t *testing.T) {
 }
 
 func TestRateLimiter_Wait(t *testing.T) {
-	rateLimiter := &RateLimiter{
-		requests: make(chan time.Time, 2), // Small limit for testing
-		limit:    2,
-		window:   time.Minute,
-	}
+	rateLimiter := NewRateLimiter(2, 2) // Small limit for testing
 
 	// First request should succeed
-	err := rateLimiter.Wait()
+	err := rateLimiter.Wait(context.Background())
 	if err != nil {
 		t.Errorf("First request should succeed, got error: %v", err)
 	}
 
 	// Second request should succeed
-	err = rateLimiter.Wait()
+	err = rateLimiter.Wait(context.Background())
 	if err != nil {
 		t.Errorf("Second request should succeed, got error: %v", err)
 	}
 
-	// Third request should fail (rate limit exceeded)
-	err = rateLimiter.Wait()
-	if err == nil {
-		t.Error("Third request should fail due to rate limit")
+	// Third request should fail because the next token arrives after the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = rateLimiter.Wait(ctx)
+	if !errors.Is(err, ErrRateLimitExceeded) {
+		t.Errorf("Third request should fail with ErrRateLimitExceeded, got: %v", err)
+	}
+}
+
+func TestRateLimiter_WaitBlocksUntilRefill(t *testing.T) {
+	rateLimiter := NewRateLimiter(600, 1) // One token every 100ms
+
+	if err := rateLimiter.Wait(context.Background()); err != nil {
+		t.Fatalf("First request should succeed, got error: %v", err)
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := rateLimiter.Wait(ctx); err != nil {
+		t.Fatalf("Second request should wait for a token, got error: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected Wait to block for a refill, returned after %v", elapsed)
+	}
+}
+
+func TestRateLimiter_WaitCancelled(t *testing.T) {
+	rateLimiter := NewRateLimiter(1, 1)
+	rateLimiter.Wait(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := rateLimiter.Wait(ctx); err == nil {
+		t.Error("Expected Wait to fail for a cancelled context")
+	}
+}
+
+func TestRateLimiter_Remaining(t *testing.T) {
+	rateLimiter := NewRateLimiter(60, 5)
+
+	if remaining := rateLimiter.Remaining(); remaining != 5 {
+		t.Errorf("Expected 5 remaining tokens, got %d", remaining)
+	}
+
+	rateLimiter.Wait(context.Background())
+	rateLimiter.Wait(context.Background())
+
+	if remaining := rateLimiter.Remaining(); remaining != 3 {
+		t.Errorf("Expected 3 remaining tokens, got %d", remaining)
+	}
+}
+
+func TestSharedRateLimiter_OnePerUpstream(t *testing.T) {
+	rateConfig := &configs.RateLimitConfig{RequestsPerMinute: 60, Burst: 10}
+
+	tmdb := NewTMDBClient(&configs.TMDBConfig{}, &configs.CacheConfig{}, rateConfig, &configs.RetryConfig{}, &configs.BreakerConfig{})
+	genres := NewGenreService(&configs.Config{Rate: *rateConfig})
+	omdb := NewOMDBClient(&configs.OMDBConfig{}, &configs.CacheConfig{}, rateConfig, &configs.RetryConfig{}, &configs.BreakerConfig{})
+
+	if tmdb.upstream.rateLimiter != genres.tmdbClient.upstream.rateLimiter {
+		t.Error("Expected TMDB clients to share one rate limiter")
+	}
+	if tmdb.upstream.rateLimiter == omdb.upstream.rateLimiter {
+		t.Error("Expected TMDB and OMDB to use separate rate limiters")
+	}
+}
+
+func TestSharedRateLimiter_FirstConfigWins(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	first := sharedRateLimiter("conflict-test", &configs.RateLimitConfig{RequestsPerMinute: 60, Burst: 10})
+	same := sharedRateLimiter("conflict-test", &configs.RateLimitConfig{RequestsPerMinute: 60, Burst: 10})
+	if logs.Len() != 0 {
+		t.Errorf("Expected no warning for the same config, got %q", logs.String())
+	}
+
+	conflicting := sharedRateLimiter("conflict-test", &configs.RateLimitConfig{RequestsPerMinute: 600, Burst: 50})
+	if same != first || conflicting != first {
+		t.Error("Expected every client of the upstream to share the first limiter")
+	}
+	if first.Burst() != 10 {
+		t.Errorf("Expected the first config to win, got a burst of %d", first.Burst())
+	}
+	if !strings.Contains(logs.String(), "Ignoring rate limit {RequestsPerMinute:600 Burst:50} for conflict-test") {
+		t.Errorf("Expected the conflict to be logged, got %q", logs.String())
 	}
 }
 
@@ -247,3 +355,516 @@ func ExampleDiscoveryService_SearchMovies() {
 	
 	_ = service // Use the service to avoid unused variable error
 }
+
+// newTestDiscoveryService creates a discovery service backed by fake TMDB and
+// OMDB servers, with its own rate limiters and breakers so tests don't share
+// state through the process-wide ones
+func newTestDiscoveryService(tmdbURL, omdbURL string) *DiscoveryService {
+	config := &configs.Config{
+		TMDB:  configs.TMDBConfig{APIKey: "test_key", BaseURL: tmdbURL},
+		OMDB:  configs.OMDBConfig{APIKey: "test_key", BaseURL: omdbURL},
+		Cache: configs.CacheConfig{Duration: 30 * time.Minute},
+	}
+
+	service := NewDiscoveryService(config)
+	for _, upstream := range []*upstreamClient{service.tmdbClient.upstream, service.omdbClient.upstream} {
+		upstream.rateLimiter = NewRateLimiter(60000, 1000)
+		upstream.breaker = NewCircuitBreaker(upstream.name, 100, time.Minute)
+		upstream.retry = RetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, MaxElapsed: time.Second}
+	}
+	return service
+}
+
+// newFakeTMDBSearch serves a page of count movie search results
+func newFakeTMDBSearch(count int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		results := make([]string, count)
+		for i := range results {
+			results[i] = fmt.Sprintf(`{"id": %d, "title": "Movie %d", "release_date": "2001-01-01"}`, i, i)
+		}
+		fmt.Fprintf(w, `{"page": 1, "results": [%s], "total_pages": 1, "total_results": %d}`, strings.Join(results, ","), count)
+	}))
+}
+
+// newFakeOMDB serves OMDB title lookups after the given latency
+func newFakeOMDB(latency func(title string) time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		title := r.URL.Query().Get("t")
+		select {
+		case <-time.After(latency(title)):
+		case <-r.Context().Done():
+			return
+		}
+		fmt.Fprintf(w, `{"Title": %q, "imdbRating": "7.5", "imdbID": "tt-%s", "Response": "True"}`, title, title)
+	}))
+}
+
+func TestDiscoveryService_SearchMovies_StopsEnrichmentWhenCancelled(t *testing.T) {
+	tmdb := newFakeTMDBSearch(20)
+	defer tmdb.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var omdbCalls int32
+	omdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The client goes away while the first enrichment call is in flight
+		atomic.AddInt32(&omdbCalls, 1)
+		cancel()
+		w.Write([]byte(`{"Title": "Movie 0", "Response": "True"}`))
+	}))
+	defer omdb.Close()
+
+	service := newTestDiscoveryService(tmdb.URL, omdb.URL)
+
+	_, err := service.SearchMovies(ctx, "movie", 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	// Only the calls already handed to workers may reach OMDB
+	if calls := atomic.LoadInt32(&omdbCalls); calls > int32(service.enrichmentConcurrency) {
+		t.Errorf("Expected enrichment to stop after at most %d OMDB calls, got %d", service.enrichmentConcurrency, calls)
+	}
+}
+
+func TestDiscoveryService_SearchMovies_ParallelEnrichmentKeepsOrder(t *testing.T) {
+	tmdb := newFakeTMDBSearch(20)
+	defer tmdb.Close()
+
+	// Earlier results answer slowest, so completion order is reversed
+	omdb := newFakeOMDB(func(title string) time.Duration {
+		var i int
+		fmt.Sscanf(title, "Movie %d", &i)
+		return time.Duration(20-i) * time.Millisecond
+	})
+	defer omdb.Close()
+
+	service := newTestDiscoveryService(tmdb.URL, omdb.URL)
+
+	result, err := service.SearchMovies(context.Background(), "movie", 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for i, item := range result.Results {
+		movie := item.(map[string]interface{})
+		if movie["title"] != fmt.Sprintf("Movie %d", i) {
+			t.Errorf("Expected result %d to be 'Movie %d', got %v", i, i, movie["title"])
+		}
+		if movie["enriched"] != true || movie["imdb_rating"] != "7.5" {
+			t.Errorf("Expected result %d to be enriched, got %v", i, movie)
+		}
+	}
+
+	// The cached TMDB results must not pick up OMDB fields
+	cached, _ := service.tmdbClient.SearchMovies(context.Background(), "movie", 1)
+	if _, exists := cached.Results[0].(map[string]interface{})["imdb_rating"]; exists {
+		t.Error("Expected cached TMDB results to be left unmodified")
+	}
+}
+
+func TestDiscoveryService_SearchMovies_PartialEnrichmentOnDeadline(t *testing.T) {
+	tmdb := newFakeTMDBSearch(4)
+	defer tmdb.Close()
+
+	// Movie 3 is too slow for the enrichment deadline
+	omdb := newFakeOMDB(func(title string) time.Duration {
+		if title == "Movie 3" {
+			return 2 * time.Second
+		}
+		return 0
+	})
+	defer omdb.Close()
+
+	service := newTestDiscoveryService(tmdb.URL, omdb.URL)
+	service.enrichmentTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	result, err := service.SearchMovies(context.Background(), "movie", 1)
+	if err != nil {
+		t.Fatalf("Expected partial results, got error %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected enrichment to stop at its deadline, took %v", elapsed)
+	}
+
+	if len(result.Results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(result.Results))
+	}
+	for i, item := range result.Results {
+		expected := i != 3
+		if enriched := item.(map[string]interface{})["enriched"]; enriched != expected {
+			t.Errorf("Expected result %d enriched=%v, got %v", i, expected, enriched)
+		}
+	}
+}
+
+// benchmarkSearchMovies measures a cold search against an OMDB server that
+// takes 10ms per lookup
+func benchmarkSearchMovies(b *testing.B, concurrency int) {
+	tmdb := newFakeTMDBSearch(20)
+	defer tmdb.Close()
+
+	omdb := newFakeOMDB(func(string) time.Duration { return 10 * time.Millisecond })
+	defer omdb.Close()
+
+	service := newTestDiscoveryService(tmdb.URL, omdb.URL)
+	service.enrichmentConcurrency = concurrency
+	service.enrichmentTimeout = time.Minute
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Start each iteration with a cold OMDB cache
+		b.StopTimer()
+		service.omdbClient.cache = &Cache{data: make(map[string]CacheItem)}
+		b.StartTimer()
+
+		if _, err := service.SearchMovies(context.Background(), "movie", 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDiscoveryService_SearchMovies_Sequential(b *testing.B) {
+	benchmarkSearchMovies(b, 1)
+}
+
+func BenchmarkDiscoveryService_SearchMovies_Parallel(b *testing.B) {
+	benchmarkSearchMovies(b, 5)
+}
+
+// Fixtures for titles shared by remakes. OMDB's title lookup always returns
+// the better-known original, as it does in practice.
+var (
+	remakeTMDBFixtures = map[string]string{
+		"/search/movie": `{"page": 1, "results": [{"id": 1091, "title": "The Thing", "release_date": "1982-06-25"}, {"id": 60935, "title": "The Thing", "release_date": "2011-10-12"}, {"id": 777, "title": "The Thing", "release_date": "2030-01-01"}], "total_pages": 1, "total_results": 3}`,
+		"/movie/1091/external_ids":  `{"id": 1091, "imdb_id": "tt0084787"}`,
+		"/movie/60935/external_ids": `{"id": 60935, "imdb_id": "tt0905372"}`,
+		"/movie/777/external_ids":   `{"id": 777, "imdb_id": null}`,
+		"/movie/1091":               `{"id": 1091, "title": "The Thing", "release_date": "1982-06-25", "imdb_id": "tt9999999"}`,
+		"/movie/60935":              `{"id": 60935, "title": "The Thing", "release_date": "2011-10-12", "imdb_id": "", "external_ids": {"imdb_id": "tt0905372"}}`,
+		"/search/tv":                `{"page": 1, "results": [{"id": 121, "name": "Doctor Who", "first_air_date": "1963-11-23"}, {"id": 57243, "name": "Doctor Who", "first_air_date": "2005-03-26"}, {"id": 999, "name": "Doctor Who", "first_air_date": "2023-11-25"}], "total_pages": 1, "total_results": 3}`,
+		"/tv/121/external_ids":      `{"id": 121, "imdb_id": "tt0056751"}`,
+		"/tv/57243/external_ids":    `{"id": 57243, "imdb_id": "tt0436992"}`,
+		"/tv/999/external_ids":      `{"id": 999, "imdb_id": ""}`,
+	}
+	remakeOMDBFixtures = map[string]string{
+		"tt0084787":  `{"Title": "The Thing", "Year": "1982", "imdbRating": "8.2", "imdbID": "tt0084787", "Response": "True"}`,
+		"tt0905372":  `{"Title": "The Thing", "Year": "2011", "imdbRating": "6.2", "imdbID": "tt0905372", "Response": "True"}`,
+		"tt0056751":  `{"Title": "Doctor Who", "Year": "1963–1989", "imdbRating": "8.4", "imdbID": "tt0056751", "Response": "True"}`,
+		"tt0436992":  `{"Title": "Doctor Who", "Year": "2005–2022", "imdbRating": "8.6", "imdbID": "tt0436992", "Response": "True"}`,
+		"The Thing":  `{"Title": "The Thing", "Year": "1982", "imdbRating": "8.2", "imdbID": "tt0084787", "Response": "True"}`,
+		"Doctor Who": `{"Title": "Doctor Who", "Year": "1963–1989", "imdbRating": "8.4", "imdbID": "tt0056751", "Response": "True"}`,
+	}
+)
+
+// newRemakeFixtureService serves the remake fixtures from fake TMDB and OMDB
+// servers, failing the test on any other TMDB request
+func newRemakeFixtureService(t *testing.T) *DiscoveryService {
+	tmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fixture, exists := remakeTMDBFixtures[r.URL.Path]
+		if !exists {
+			t.Errorf("Unexpected TMDB request %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(fixture))
+	}))
+	t.Cleanup(tmdb.Close)
+
+	omdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := r.URL.Query().Get("i"); id != "" {
+			fixture, exists := remakeOMDBFixtures[id]
+			if !exists {
+				fixture = `{"Response": "False", "Error": "Incorrect IMDb ID."}`
+			}
+			w.Write([]byte(fixture))
+			return
+		}
+		fixture, exists := remakeOMDBFixtures[r.URL.Query().Get("t")]
+		if !exists {
+			fixture = `{"Response": "False", "Error": "Movie not found!"}`
+		}
+		w.Write([]byte(fixture))
+	}))
+	t.Cleanup(omdb.Close)
+
+	return newTestDiscoveryService(tmdb.URL, omdb.URL)
+}
+
+func TestDiscoveryService_SearchMovies_MatchesRemakesByIMDBID(t *testing.T) {
+	service := newRemakeFixtureService(t)
+
+	result, err := service.SearchMovies(context.Background(), "the thing", 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []struct {
+		imdbID     interface{}
+		rating     interface{}
+		confidence interface{}
+	}{
+		{"tt0084787", "8.2", matchConfidenceIMDBID},
+		{"tt0905372", "6.2", matchConfidenceIMDBID},
+		// No IMDb ID, and OMDB's title lookup returns the original, so the
+		// result is left unenriched rather than given its rating
+		{nil, nil, nil},
+	}
+
+	for i, want := range expected {
+		movie := result.Results[i].(map[string]interface{})
+		if movie["imdb_id"] != want.imdbID || movie["imdb_rating"] != want.rating {
+			t.Errorf("Result %d: expected %v rated %v, got %v rated %v", i, want.imdbID, want.rating, movie["imdb_id"], movie["imdb_rating"])
+		}
+		if movie["match_confidence"] != want.confidence {
+			t.Errorf("Result %d: expected match confidence %v, got %v", i, want.confidence, movie["match_confidence"])
+		}
+	}
+
+	// External IDs are cached, so searching again costs no TMDB lookups
+	if service.tmdbClient.cache.Get("external_ids_movie_60935") == nil {
+		t.Error("Expected the external IDs to be cached")
+	}
+}
+
+func TestDiscoveryService_SearchTVShows_MatchesSameNameShowsByIMDBID(t *testing.T) {
+	service := newRemakeFixtureService(t)
+
+	result, err := service.SearchTVShows(context.Background(), "doctor who", 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for i, want := range []interface{}{"tt0056751", "tt0436992", nil} {
+		show := result.Results[i].(map[string]interface{})
+		if show["imdb_id"] != want {
+			t.Errorf("Result %d: expected %v, got %v", i, want, show["imdb_id"])
+		}
+	}
+}
+
+func TestDiscoveryService_GetMovieDetails_UsesExternalIDs(t *testing.T) {
+	service := newRemakeFixtureService(t)
+
+	movie, err := service.GetMovieDetails(context.Background(), 60935, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if movie.IMDBId != "tt0905372" || movie.IMDBRating != "6.2" {
+		t.Errorf("Expected the 2011 remake's OMDB data, got %s rated %s", movie.IMDBId, movie.IMDBRating)
+	}
+	if movie.MatchConfidence != matchConfidenceIMDBID {
+		t.Errorf("Expected match confidence %v, got %v", matchConfidenceIMDBID, movie.MatchConfidence)
+	}
+}
+
+func TestDiscoveryService_GetMovieDetails_UnknownIMDBIDFallsBackToTitle(t *testing.T) {
+	service := newRemakeFixtureService(t)
+
+	movie, err := service.GetMovieDetails(context.Background(), 1091, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// OMDB answers "Incorrect IMDb ID." and the title lookup takes over
+	if movie.IMDBRating != "8.2" || movie.MatchConfidence != matchConfidenceTitleYear {
+		t.Errorf("Expected the title match rated 8.2, got %q with confidence %v", movie.IMDBRating, movie.MatchConfidence)
+	}
+}
+
+func TestOMDBError(t *testing.T) {
+	tests := []struct {
+		message  string
+		notFound bool
+	}{
+		{"Movie not found!", true},
+		{"Series not found!", true},
+		{"Incorrect IMDb ID.", true},
+		{"Invalid API key!", false},
+		{"Request limit reached!", false},
+	}
+
+	for _, tt := range tests {
+		if notFound := errors.Is(omdbError(tt.message), ErrUpstreamNotFound); notFound != tt.notFound {
+			t.Errorf("omdbError(%q): expected not found %v, got %v", tt.message, tt.notFound, notFound)
+		}
+	}
+}
+
+func TestTitleMatchConfidence(t *testing.T) {
+	omdbData := &OMDBResponse{Title: "Doctor Who", Year: "2005–2022"}
+
+	tests := []struct {
+		title    string
+		year     string
+		expected float64
+	}{
+		{"Doctor Who", "2005", matchConfidenceTitleYear},
+		{"doctor who", "", matchConfidenceTitle},
+		{"Doctor Who", "1963", matchConfidenceTitle},
+		{"Doctor Who Confidential", "1963", matchConfidenceWeak},
+	}
+
+	for _, tt := range tests {
+		if confidence := titleMatchConfidence(tt.title, tt.year, omdbData); confidence != tt.expected {
+			t.Errorf("titleMatchConfidence(%q, %q) = %v, want %v", tt.title, tt.year, confidence, tt.expected)
+		}
+	}
+}
+
+func TestParseRatingValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected float64
+		ok       bool
+	}{
+		{"7.5/10", 75, true},
+		{"85%", 85, true},
+		{"74/100", 74, true},
+		{" 8/10 ", 80, true},
+		{"N/A", 0, false},
+		{"", 0, false},
+		{"11/10", 0, false},
+		{"120%", 0, false},
+		{"great", 0, false},
+		{"7.5/0", 0, false},
+	}
+
+	for _, tt := range tests {
+		score, ok := parseRatingValue(tt.value)
+		if ok != tt.ok || score != tt.expected {
+			t.Errorf("parseRatingValue(%q) = %v, %v; want %v, %v", tt.value, score, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestParseVoteCount(t *testing.T) {
+	tests := map[string]int{
+		"1,234,567": 1234567,
+		"987":       987,
+		"N/A":       0,
+		"":          0,
+	}
+
+	for value, expected := range tests {
+		if votes := parseVoteCount(value); votes != expected {
+			t.Errorf("parseVoteCount(%q) = %d, want %d", value, votes, expected)
+		}
+	}
+}
+
+func TestOMDBResponse_GetDetails(t *testing.T) {
+	omdbData := &OMDBResponse{
+		Rated:      "PG-13",
+		Released:   "16 Jul 2010",
+		Poster:     "N/A",
+		BoxOffice:  "$292,587,330",
+		Metascore:  "74",
+		IMDBRating: "8.8",
+		IMDBVotes:  "2,512,345",
+		Ratings: []OMDBRating{
+			{Source: RatingSourceIMDb, Value: "8.8/10"},
+			{Source: RatingSourceRottenTomatoes, Value: "87%"},
+		},
+	}
+
+	details := omdbData.GetDetails()
+
+	if details.Certification != "PG-13" || details.BoxOffice != "$292,587,330" || details.Metascore != 74 {
+		t.Errorf("Unexpected details: %+v", details)
+	}
+	if details.FallbackPosterURL != "" {
+		t.Errorf("Expected N/A poster to be dropped, got %q", details.FallbackPosterURL)
+	}
+
+	// Metacritic is missing from the array, so it comes from Metascore
+	expected := []models.Rating{
+		{Source: RatingSourceIMDb, Value: "8.8/10", Score: 88, Votes: 2512345},
+		{Source: RatingSourceRottenTomatoes, Value: "87%", Score: 87},
+		{Source: RatingSourceMetacritic, Value: "74/100", Score: 74},
+	}
+	if len(details.Ratings) != len(expected) {
+		t.Fatalf("Expected %d ratings, got %+v", len(expected), details.Ratings)
+	}
+	for i, rating := range details.Ratings {
+		if rating != expected[i] {
+			t.Errorf("Rating %d: expected %+v, got %+v", i, expected[i], rating)
+		}
+	}
+}
+
+func TestParseDetailSections(t *testing.T) {
+	tests := []struct {
+		mediaType string
+		include   string
+		expected  []string
+		wantErr   bool
+	}{
+		{"movie", "videos,credits", []string{"credits", "videos"}, false},
+		{"movie", " keywords , keywords ,", []string{"keywords"}, false},
+		{"movie", "", []string{}, false},
+		{"movie", "content_ratings", nil, true},
+		{"tv", "content_ratings,external_ids", []string{"content_ratings", "external_ids"}, false},
+		{"tv", "release_dates", nil, true},
+		{"movie", "reviews", nil, true},
+	}
+
+	for _, tt := range tests {
+		sections, err := ParseDetailSections(tt.mediaType, tt.include)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDetailSections(%q, %q) error = %v, wantErr %v", tt.mediaType, tt.include, err, tt.wantErr)
+			continue
+		}
+		if strings.Join(sections, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("ParseDetailSections(%q, %q) = %v, want %v", tt.mediaType, tt.include, sections, tt.expected)
+		}
+	}
+}
+
+func TestDiscoveryService_GetTVShowDetails_AppendsSections(t *testing.T) {
+	var appended string
+	tmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		appended = r.URL.Query().Get("append_to_response")
+		w.Write([]byte(`{
+			"id": 57243, "name": "Doctor Who", "first_air_date": "2005-03-26",
+			"credits": {"cast": [{"id": 1, "name": "David Tennant", "character": "The Doctor", "order": 0}], "crew": [{"id": 2, "name": "Russell T Davies", "job": "Executive Producer", "department": "Production"}]},
+			"keywords": {"results": [{"id": 10, "name": "time travel"}]},
+			"external_ids": {"id": 57243, "imdb_id": "tt0436992"}
+		}`))
+	}))
+	defer tmdb.Close()
+
+	var omdbQuery string
+	omdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		omdbQuery = r.URL.Query().Get("i")
+		w.Write([]byte(remakeOMDBFixtures["tt0436992"]))
+	}))
+	defer omdb.Close()
+
+	service := newTestDiscoveryService(tmdb.URL, omdb.URL)
+
+	tvShow, err := service.GetTVShowDetails(context.Background(), 57243, []string{"credits", "keywords"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if appended != "credits,keywords,external_ids" {
+		t.Errorf("Expected append_to_response=credits,keywords,external_ids, got %q", appended)
+	}
+	if tvShow.Credits == nil || len(tvShow.Credits.Cast) != 1 || tvShow.Credits.Cast[0].Character != "The Doctor" {
+		t.Errorf("Expected credits to be decoded, got %+v", tvShow.Credits)
+	}
+	if tvShow.Keywords == nil || len(tvShow.Keywords.Keywords) != 1 || tvShow.Keywords.Keywords[0].Name != "time travel" {
+		t.Errorf("Expected TV keywords to be decoded, got %+v", tvShow.Keywords)
+	}
+
+	// External IDs were used for matching but not requested
+	if omdbQuery != "tt0436992" {
+		t.Errorf("Expected OMDB lookup by IMDb ID, got %q", omdbQuery)
+	}
+	if tvShow.ExternalIDs != nil {
+		t.Errorf("Expected external IDs to be left out, got %+v", tvShow.ExternalIDs)
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"sync"
+)
+
+// enrichFunc enriches a single search result, returning the enriched copy
+// and whether enrichment succeeded
+type enrichFunc func(ctx context.Context, item map[string]interface{}) (map[string]interface{}, bool)
+
+// enrichResults enriches search results with bounded concurrency. Results
+// keep their order and each one is marked with an "enriched" flag. Items
+// still pending when the enrichment deadline passes are returned as-is, so a
+// slow upstream yields partially enriched results rather than an error.
+// degraded reports whether any item was skipped because the upstream's
+// breaker was open.
+func (s *DiscoveryService) enrichResults(ctx context.Context, results []interface{}, available func() bool, enrich enrichFunc) (enriched []interface{}, degraded bool, err error) {
+	enrichCtx := ctx
+	if s.enrichmentTimeout > 0 {
+		var cancel context.CancelFunc
+		enrichCtx, cancel = context.WithTimeout(ctx, s.enrichmentTimeout)
+		defer cancel()
+	}
+
+	// Start from unenriched copies so cached TMDB results are never modified
+	enriched = make([]interface{}, len(results))
+	for i, result := range results {
+		if item, ok := result.(map[string]interface{}); ok {
+			item = cloneResult(item)
+			item["enriched"] = false
+			enriched[i] = item
+		} else {
+			enriched[i] = result
+		}
+	}
+
+	workers := s.enrichmentConcurrency
+	if workers <= 0 {
+		workers = 1
+	}
+
+	jobs := make(chan int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				item := enriched[i].(map[string]interface{})
+				if !available() {
+					mu.Lock()
+					degraded = true
+					mu.Unlock()
+					continue
+				}
+
+				if result, ok := enrich(enrichCtx, item); ok {
+					result["enriched"] = true
+					enriched[i] = result
+				}
+			}
+		}()
+	}
+
+	// Hand out items until they run out or the deadline passes
+dispatch:
+	for i := range enriched {
+		if _, ok := enriched[i].(map[string]interface{}); !ok {
+			continue
+		}
+		select {
+		case jobs <- i:
+		case <-enrichCtx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	// An abandoned request gets an error; an expired enrichment budget only
+	// means some items stay unenriched
+	if err := ctx.Err(); err != nil {
+		return nil, degraded, err
+	}
+
+	return enriched, degraded, nil
+}
+
+// cloneResult returns a shallow copy of a search result
+func cloneResult(item map[string]interface{}) map[string]interface{} {
+	clone := make(map[string]interface{}, len(item)+1)
+	for key, value := range item {
+		clone[key] = value
+	}
+	return clone
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"movie-discovery-app/internal/models"
+)
+
+// ErrFeedNotFound is returned for an unknown or revoked feed token
+var ErrFeedNotFound = errors.New("feed not found")
+
+// feedReleaseTypes are the movie release types a watchlist feed announces
+var feedReleaseTypes = []string{"theatrical_limited", "theatrical", "digital"}
+
+// feedTokens maps secret feed tokens to users. Knowing a feed's URL is what
+// authorizes reading it, so calendar apps can subscribe without logging in.
+type feedTokens struct {
+	mu     sync.Mutex
+	users  map[string]string // token -> userID
+	tokens map[string]string // userID -> token
+}
+
+func newFeedTokens() *feedTokens {
+	return &feedTokens{
+		users:  make(map[string]string),
+		tokens: make(map[string]string),
+	}
+}
+
+// WatchlistFeedToken returns the user's feed token, creating one on first use
+func (s *CalendarService) WatchlistFeedToken(userID string) (string, error) {
+	s.feeds.mu.Lock()
+	defer s.feeds.mu.Unlock()
+
+	if token, exists := s.feeds.tokens[userID]; exists {
+		return token, nil
+	}
+	return s.feeds.issue(userID)
+}
+
+// RotateWatchlistFeedToken replaces the user's feed token, so the old feed
+// URL stops working
+func (s *CalendarService) RotateWatchlistFeedToken(userID string) (string, error) {
+	s.feeds.mu.Lock()
+	defer s.feeds.mu.Unlock()
+
+	if old, exists := s.feeds.tokens[userID]; exists {
+		delete(s.feeds.users, old)
+	}
+	return s.feeds.issue(userID)
+}
+
+// RevokeWatchlistFeedToken removes the user's feed token, so their feed URL
+// stops working
+func (s *CalendarService) RevokeWatchlistFeedToken(userID string) {
+	s.feeds.mu.Lock()
+	defer s.feeds.mu.Unlock()
+
+	if token, exists := s.feeds.tokens[userID]; exists {
+		delete(s.feeds.users, token)
+		delete(s.feeds.tokens, userID)
+	}
+}
+
+// issue creates a new random token for the user. The caller holds mu.
+func (f *feedTokens) issue(userID string) (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate feed token: %w", err)
+	}
+
+	token := hex.EncodeToString(buf)
+	f.users[token] = userID
+	f.tokens[userID] = token
+	return token, nil
+}
+
+// GetWatchlistFeed renders the watchlist feed for a feed token: upcoming
+// theatrical and digital releases of the watchlist's movies in the region,
+// and the next episode of each TV show on the watchlist. region must already
+// be normalized. Event UIDs are stable, so a calendar app replaces an event
+// when its date changes.
+func (s *CalendarService) GetWatchlistFeed(ctx context.Context, token, region string) ([]byte, error) {
+	s.feeds.mu.Lock()
+	userID, exists := s.feeds.users[token]
+	s.feeds.mu.Unlock()
+	if !exists {
+		return nil, ErrFeedNotFound
+	}
+
+	watchlist, err := s.watchlistService.GetWatchlist(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	today := s.now().UTC().Truncate(24 * time.Hour)
+	events := make([][]icsEvent, len(watchlist))
+
+	forEachConcurrently(len(watchlist), calendarLookupConcurrency, func(i int) {
+		item := watchlist[i]
+		id, err := strconv.Atoi(item.ID)
+		if err != nil {
+			return
+		}
+
+		switch item.Type {
+		case "movie":
+			releaseDates, err := s.tmdbClient.GetMovieReleaseDates(ctx, id)
+			if err != nil {
+				log.Printf("Failed to get release dates for movie %d: %v", id, err)
+				return
+			}
+			events[i] = movieFeedEvents(id, item.Title, releaseDates, region, today)
+		case "tv":
+			tvShow, err := s.tmdbClient.GetTVShowDetails(ctx, id)
+			if err != nil {
+				log.Printf("Failed to get TV show details for %d: %v", id, err)
+				return
+			}
+			if event, ok := episodeFeedEvent(tvShow, today); ok {
+				events[i] = []icsEvent{event}
+			}
+		}
+	})
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var all []icsEvent
+	for _, itemEvents := range events {
+		all = append(all, itemEvents...)
+	}
+
+	return writeICS("Watchlist", all, s.now()), nil
+}
+
+// movieFeedEvents returns events for a movie's theatrical and digital
+// releases in the region from today on. Each release type gets one event,
+// on its earliest upcoming date, so the UID stays stable when TMDB adds
+// dates.
+func movieFeedEvents(movieID int, title string, releaseDates *models.ReleaseDates, region string, today time.Time) []icsEvent {
+	earliest := make(map[string]time.Time)
+	for _, country := range releaseDates.Results {
+		if country.ISO3166_1 != region {
+			continue
+		}
+		for _, release := range country.ReleaseDates {
+			releaseType := releaseTypeNames[release.Type]
+			if !containsString(feedReleaseTypes, releaseType) || len(release.ReleaseDate) < 10 {
+				continue
+			}
+			date, err := time.Parse("2006-01-02", release.ReleaseDate[:10])
+			if err != nil || date.Before(today) {
+				continue
+			}
+			if current, exists := earliest[releaseType]; !exists || date.Before(current) {
+				earliest[releaseType] = date
+			}
+		}
+	}
+
+	var events []icsEvent
+	for _, releaseType := range feedReleaseTypes {
+		date, exists := earliest[releaseType]
+		if !exists {
+			continue
+		}
+		label := releaseTypeLabels[releaseType]
+		events = append(events, icsEvent{
+			UID:         fmt.Sprintf("watchlist-movie-%d-%s-%s@movie-discovery-app", movieID, releaseType, region),
+			Date:        date,
+			Summary:     fmt.Sprintf("%s (%s release)", title, label),
+			Description: fmt.Sprintf("%s release in %s of a movie on your watchlist.", label, region),
+			URL:         fmt.Sprintf("https://www.themoviedb.org/movie/%d", movieID),
+		})
+	}
+
+	return events
+}
+
+// episodeFeedEvent returns an event for a TV show's next episode, if one is
+// scheduled from today on
+func episodeFeedEvent(tvShow *models.TVShow, today time.Time) (icsEvent, bool) {
+	episode := tvShow.NextEpisodeToAir
+	if episode == nil || episode.AirDate == "" {
+		return icsEvent{}, false
+	}
+
+	date, err := time.Parse("2006-01-02", episode.AirDate)
+	if err != nil || date.Before(today) {
+		return icsEvent{}, false
+	}
+
+	summary := fmt.Sprintf("%s S%02dE%02d", tvShow.Name, episode.SeasonNumber, episode.EpisodeNumber)
+	if episode.Name != "" {
+		summary += ": " + episode.Name
+	}
+
+	return icsEvent{
+		UID:         fmt.Sprintf("watchlist-tv-%d-s%de%d@movie-discovery-app", tvShow.ID, episode.SeasonNumber, episode.EpisodeNumber),
+		Date:        date,
+		Summary:     summary,
+		Description: episode.Overview,
+		URL:         fmt.Sprintf("https://www.themoviedb.org/tv/%d/season/%d/episode/%d", tvShow.ID, episode.SeasonNumber, episode.EpisodeNumber),
+	}, true
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
+)
+
+func newTestFeedService(t *testing.T, watchlistService *WatchlistService) *CalendarService {
+	tmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/movie/1/release_dates":
+			w.Write([]byte(`{"id": 1, "results": [
+				{"iso_3166_1": "US", "release_dates": [
+					{"release_date": "2024-02-01T00:00:00.000Z", "type": 3},
+					{"release_date": "2024-04-16T00:00:00.000Z", "type": 4},
+					{"release_date": "2024-05-20T00:00:00.000Z", "type": 4},
+					{"release_date": "2024-06-01T00:00:00.000Z", "type": 5}
+				]}
+			]}`))
+		case "/tv/10":
+			w.Write([]byte(`{"id": 10, "name": "Shōgun", "next_episode_to_air": {
+				"id": 100, "name": "Anjin", "overview": "A ship, a storm; a stranger.", "air_date": "2024-02-27", "season_number": 1, "episode_number": 2
+			}}`))
+		case "/tv/11":
+			w.Write([]byte(`{"id": 11, "name": "Ended Show", "next_episode_to_air": null}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(tmdb.Close)
+
+	service := NewCalendarService(&configs.Config{
+		TMDB: configs.TMDBConfig{APIKey: "test_key", BaseURL: tmdb.URL},
+	}, watchlistService, newTestParentalService(t))
+	service.tmdbClient.upstream.rateLimiter = NewRateLimiter(60000, 1000)
+	service.tmdbClient.upstream.breaker = NewCircuitBreaker("test", 100, time.Minute)
+	service.now = func() time.Time { return calendarNow }
+	return service
+}
+
+func TestCalendarService_WatchlistFeedToken(t *testing.T) {
+	service := newTestFeedService(t, NewWatchlistService())
+
+	token, err := service.WatchlistFeedToken("user")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(token) != 48 {
+		t.Errorf("Expected a 48 character token, got %q", token)
+	}
+	if again, _ := service.WatchlistFeedToken("user"); again != token {
+		t.Errorf("Expected the same token on repeated calls, got %q and %q", token, again)
+	}
+	if other, _ := service.WatchlistFeedToken("other"); other == token {
+		t.Error("Expected different users to get different tokens")
+	}
+
+	rotated, err := service.RotateWatchlistFeedToken("user")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if rotated == token {
+		t.Error("Expected rotation to issue a new token")
+	}
+	if _, err := service.GetWatchlistFeed(context.Background(), token, "US"); !errors.Is(err, ErrFeedNotFound) {
+		t.Errorf("Expected the old token to be revoked, got %v", err)
+	}
+	if _, err := service.GetWatchlistFeed(context.Background(), rotated, "US"); err != nil {
+		t.Errorf("Expected the new token to work, got %v", err)
+	}
+}
+
+func TestCalendarService_GetWatchlistFeed(t *testing.T) {
+	watchlistService := NewWatchlistService()
+	watchlistService.AddToWatchlist("user", models.WatchlistItem{ID: "1", Type: "movie", Title: "Dune: Part Two"})
+	watchlistService.AddToWatchlist("user", models.WatchlistItem{ID: "10", Type: "tv", Title: "Shōgun"})
+	watchlistService.AddToWatchlist("user", models.WatchlistItem{ID: "11", Type: "tv", Title: "Ended Show"})
+
+	service := newTestFeedService(t, watchlistService)
+	token, _ := service.WatchlistFeedToken("user")
+
+	data, err := service.GetWatchlistFeed(context.Background(), token, "US")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	assertValidICS(t, data)
+	ics := string(data)
+
+	for _, line := range []string{
+		"X-WR-CALNAME:Watchlist\r\n",
+		"UID:watchlist-movie-1-digital-US@movie-discovery-app\r\n",
+		"DTSTART;VALUE=DATE:20240416\r\n",
+		"SUMMARY:Dune: Part Two (Digital release)\r\n",
+		"UID:watchlist-tv-10-s1e2@movie-discovery-app\r\n",
+		"DTSTART;VALUE=DATE:20240227\r\n",
+		"SUMMARY:Shōgun S01E02: Anjin\r\n",
+		"DESCRIPTION:A ship\\, a storm\\; a stranger.\r\n",
+	} {
+		if !strings.Contains(ics, line) {
+			t.Errorf("Expected feed to contain %q, got:\n%s", line, ics)
+		}
+	}
+
+	// The past theatrical date, the later digital date and the physical
+	// release are left out
+	if count := strings.Count(ics, "BEGIN:VEVENT"); count != 2 {
+		t.Errorf("Expected 2 events, got %d:\n%s", count, ics)
+	}
+
+	again, err := service.GetWatchlistFeed(context.Background(), token, "US")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(again) != ics {
+		t.Error("Expected the same feed, with the same UIDs, on a second fetch")
+	}
+
+	// No releases in another region
+	data, err = service.GetWatchlistFeed(context.Background(), token, "GB")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if count := strings.Count(string(data), "BEGIN:VEVENT"); count != 1 {
+		t.Errorf("Expected only the episode in GB, got %d events", count)
+	}
+}
+
+func TestCalendarService_GetWatchlistFeed_UnknownToken(t *testing.T) {
+	service := newTestFeedService(t, NewWatchlistService())
+
+	if _, err := service.GetWatchlistFeed(context.Background(), "deadbeef", "US"); !errors.Is(err, ErrFeedNotFound) {
+		t.Errorf("Expected ErrFeedNotFound, got %v", err)
+	}
+}
@@ -1,12 +1,10 @@
 package services
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"strconv"
+	"strings"
+	"time"
 
 	"movie-discovery-app/configs"
 	"movie-discovery-app/internal/models"
@@ -15,19 +13,22 @@ import (
 // GenreService handles genre-related operations
 type GenreService struct {
 	tmdbClient *TMDBClient
+	scorer     *Scorer
 }
 
 // NewGenreService creates a new genre service
 func NewGenreService(config *configs.Config) *GenreService {
-	tmdbClient := NewTMDBClient(&config.TMDB, &config.Cache, &config.Rate)
+	tmdbClient := NewTMDBClient(&config.TMDB, &config.Cache, &config.Rate, &config.Retry, &config.Breaker)
 	return &GenreService{
 		tmdbClient: tmdbClient,
+		scorer:     NewScorer(&config.Scoring),
 	}
 }
 
-// GetMovieGenres gets all available movie genres
-func (s *GenreService) GetMovieGenres() ([]models.Genre, error) {
-	cacheKey := "movie_genres"
+// GetMovieGenres gets all available movie genres, named in the request's
+// language
+func (s *GenreService) GetMovieGenres(ctx context.Context) ([]models.Genre, error) {
+	cacheKey := "movie_genres" + localeCacheKey(ctx)
 
 	// Check cache first
 	if cached := s.tmdbClient.cache.Get(cacheKey); cached != nil {
@@ -36,49 +37,23 @@ func (s *GenreService) GetMovieGenres() ([]models.Genre, error) {
 		}
 	}
 
-	// Rate limiting
-	if err := s.tmdbClient.rateLimiter.Wait(); err != nil {
-		return nil, err
-	}
-
-	// Build URL
-	params := url.Values{}
-	params.Add("api_key", s.tmdbClient.config.APIKey)
-
-	requestURL := fmt.Sprintf("%s/genre/movie/list?%s", s.tmdbClient.config.BaseURL, params.Encode())
-
-	// Make request
-	resp, err := s.tmdbClient.httpClient.Get(requestURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get movie genres: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API error: %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
 	var response struct {
 		Genres []models.Genre `json:"genres"`
 	}
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := s.tmdbClient.getLocalized(ctx, "/genre/movie/list", nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to get movie genres: %w", err)
 	}
 
 	// Cache the result
-	s.tmdbClient.cache.Set(cacheKey, response.Genres, 24*60*60*1000) // Cache for 24 hours
+	s.tmdbClient.cache.Set(cacheKey, response.Genres, 24*time.Hour)
 
 	return response.Genres, nil
 }
 
-// GetTVGenres gets all available TV show genres
-func (s *GenreService) GetTVGenres() ([]models.Genre, error) {
-	cacheKey := "tv_genres"
+// GetTVGenres gets all available TV show genres, named in the request's
+// language
+func (s *GenreService) GetTVGenres(ctx context.Context) ([]models.Genre, error) {
+	cacheKey := "tv_genres" + localeCacheKey(ctx)
 
 	// Check cache first
 	if cached := s.tmdbClient.cache.Get(cacheKey); cached != nil {
@@ -87,183 +62,96 @@ func (s *GenreService) GetTVGenres() ([]models.Genre, error) {
 		}
 	}
 
-	// Rate limiting
-	if err := s.tmdbClient.rateLimiter.Wait(); err != nil {
-		return nil, err
-	}
-
-	// Build URL
-	params := url.Values{}
-	params.Add("api_key", s.tmdbClient.config.APIKey)
-
-	requestURL := fmt.Sprintf("%s/genre/tv/list?%s", s.tmdbClient.config.BaseURL, params.Encode())
-
-	// Make request
-	resp, err := s.tmdbClient.httpClient.Get(requestURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get TV genres: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API error: %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
 	var response struct {
 		Genres []models.Genre `json:"genres"`
 	}
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := s.tmdbClient.getLocalized(ctx, "/genre/tv/list", nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to get TV genres: %w", err)
 	}
 
 	// Cache the result
-	s.tmdbClient.cache.Set(cacheKey, response.Genres, 24*60*60*1000) // Cache for 24 hours
+	s.tmdbClient.cache.Set(cacheKey, response.Genres, 24*time.Hour)
 
 	return response.Genres, nil
 }
 
 // DiscoverMoviesByGenre discovers movies by genre with additional filters
-func (s *GenreService) DiscoverMoviesByGenre(genreID int, page int, filters DiscoveryFilters) (*models.SearchResult, error) {
-	cacheKey := fmt.Sprintf("discover_movies_genre_%d_page_%d", genreID, page)
-
-	// Check cache first
-	if cached := s.tmdbClient.cache.Get(cacheKey); cached != nil {
-		if result, ok := cached.(*models.SearchResult); ok {
-			return result, nil
-		}
-	}
-
-	// Rate limiting
-	if err := s.tmdbClient.rateLimiter.Wait(); err != nil {
-		return nil, err
-	}
-
-	// Build URL with filters
-	params := url.Values{}
-	params.Add("api_key", s.tmdbClient.config.APIKey)
-	params.Add("with_genres", strconv.Itoa(genreID))
-	params.Add("page", strconv.Itoa(page))
-	params.Add("sort_by", filters.SortBy)
-
-	if filters.MinRating > 0 {
-		params.Add("vote_average.gte", fmt.Sprintf("%.1f", filters.MinRating))
-	}
-	if filters.MaxRating > 0 {
-		params.Add("vote_average.lte", fmt.Sprintf("%.1f", filters.MaxRating))
-	}
-	if filters.MinYear > 0 {
-		params.Add("primary_release_date.gte", fmt.Sprintf("%d-01-01", filters.MinYear))
-	}
-	if filters.MaxYear > 0 {
-		params.Add("primary_release_date.lte", fmt.Sprintf("%d-12-31", filters.MaxYear))
-	}
-
-	requestURL := fmt.Sprintf("%s/discover/movie?%s", s.tmdbClient.config.BaseURL, params.Encode())
-
-	// Make request
-	resp, err := s.tmdbClient.httpClient.Get(requestURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to discover movies: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API error: %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	var result models.SearchResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	// Cache the result
-	s.tmdbClient.cache.Set(cacheKey, &result, 30*60*1000) // Cache for 30 minutes
-
-	return &result, nil
+func (s *GenreService) DiscoverMoviesByGenre(ctx context.Context, genreID int, page int, filters DiscoveryFilters) (*models.SearchResult, error) {
+	filters.GenreIDs = []int{genreID}
+	return s.Discover(ctx, "movie", page, filters)
 }
 
 // DiscoverTVShowsByGenre discovers TV shows by genre with additional filters
-func (s *GenreService) DiscoverTVShowsByGenre(genreID int, page int, filters DiscoveryFilters) (*models.SearchResult, error) {
-	cacheKey := fmt.Sprintf("discover_tv_genre_%d_page_%d", genreID, page)
+func (s *GenreService) DiscoverTVShowsByGenre(ctx context.Context, genreID int, page int, filters DiscoveryFilters) (*models.SearchResult, error) {
+	filters.GenreIDs = []int{genreID}
+	return s.Discover(ctx, "tv", page, filters)
+}
 
-	// Check cache first
-	if cached := s.tmdbClient.cache.Get(cacheKey); cached != nil {
-		if result, ok := cached.(*models.SearchResult); ok {
-			return result, nil
+// scoreResults returns a copy of discovery results with a composite score on
+// each item, sorted by it when the filters ask for that
+func (s *GenreService) scoreResults(result *models.SearchResult, filters DiscoveryFilters) *models.SearchResult {
+	scored := *result
+	scored.Results = make([]interface{}, len(result.Results))
+	for i, item := range result.Results {
+		if data, ok := item.(map[string]interface{}); ok {
+			data = cloneResult(data)
+			if composite := s.scorer.ScoreResult(data); composite != nil {
+				data["composite"] = composite
+			}
+			item = data
 		}
+		scored.Results[i] = item
 	}
 
-	// Rate limiting
-	if err := s.tmdbClient.rateLimiter.Wait(); err != nil {
-		return nil, err
-	}
-
-	// Build URL with filters
-	params := url.Values{}
-	params.Add("api_key", s.tmdbClient.config.APIKey)
-	params.Add("with_genres", strconv.Itoa(genreID))
-	params.Add("page", strconv.Itoa(page))
-	params.Add("sort_by", filters.SortBy)
-
-	if filters.MinRating > 0 {
-		params.Add("vote_average.gte", fmt.Sprintf("%.1f", filters.MinRating))
-	}
-	if filters.MaxRating > 0 {
-		params.Add("vote_average.lte", fmt.Sprintf("%.1f", filters.MaxRating))
-	}
-	if filters.MinYear > 0 {
-		params.Add("first_air_date.gte", fmt.Sprintf("%d-01-01", filters.MinYear))
-	}
-	if filters.MaxYear > 0 {
-		params.Add("first_air_date.lte", fmt.Sprintf("%d-12-31", filters.MaxYear))
-	}
-
-	requestURL := fmt.Sprintf("%s/discover/tv?%s", s.tmdbClient.config.BaseURL, params.Encode())
-
-	// Make request
-	resp, err := s.tmdbClient.httpClient.Get(requestURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to discover TV shows: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API error: %d", resp.StatusCode)
+	if filters.SortBy == SortByComposite {
+		sortByComposite(scored.Results)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
+	return &scored
+}
 
-	var result models.SearchResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+// tmdbSortBy returns the sort order to request from TMDB. Composite scores
+// are computed locally, so TMDB ranks by popularity and the page is re-sorted.
+func tmdbSortBy(sortBy string) string {
+	if sortBy == SortByComposite {
+		return "popularity.desc"
 	}
-
-	// Cache the result
-	s.tmdbClient.cache.Set(cacheKey, &result, 30*60*1000) // Cache for 30 minutes
-
-	return &result, nil
+	return sortBy
 }
 
 // DiscoveryFilters represents filters for movie/TV discovery
 type DiscoveryFilters struct {
-	SortBy    string  `json:"sort_by"`    // popularity.desc, vote_average.desc, release_date.desc, etc.
+	SortBy    string  `json:"sort_by"`    // popularity.desc, vote_average.desc, release_date.desc, composite, etc.
 	MinRating float64 `json:"min_rating"` // Minimum vote average
 	MaxRating float64 `json:"max_rating"` // Maximum vote average
 	MinYear   int     `json:"min_year"`   // Minimum release year
 	MaxYear   int     `json:"max_year"`   // Maximum release year
+
+	GenreIDs      []int  `json:"genres,omitempty"`         // Genres to include
+	GenreMatch    string `json:"genre_match,omitempty"`    // all (every genre) or any (at least one)
+	WithoutGenres []int  `json:"without_genres,omitempty"` // Genres to exclude
+	MinRuntime    int    `json:"min_runtime,omitempty"`    // Minimum runtime in minutes
+	MaxRuntime    int    `json:"max_runtime,omitempty"`    // Maximum runtime in minutes
+	Language      string `json:"language,omitempty"`       // ISO 639-1 original language
+	Certification string `json:"certification,omitempty"`  // Certification in Region, e.g. PG-13
+	MinVoteCount  int    `json:"min_vote_count,omitempty"` // Minimum number of votes
+	KeywordIDs    []int  `json:"keywords,omitempty"`       // Keywords that must all apply
+	CastIDs       []int  `json:"cast,omitempty"`           // People who must be in the cast (movies only)
+	CrewIDs       []int  `json:"crew,omitempty"`           // People who must be in the crew (movies only)
+	ProviderIDs   []int  `json:"providers,omitempty"`      // Watch providers, any of which must stream it in Region
+	Region        string `json:"region,omitempty"`         // ISO 3166-1 country for certifications and watch providers
+}
+
+// minReleaseYear is the earliest release year filters and queries accept
+const minReleaseYear = 1900
+
+// releaseYearLookahead is how many years past the current one filters and
+// queries accept, for announced titles
+const releaseYearLookahead = 5
+
+// maxReleaseYear returns the latest release year filters and queries accept
+func maxReleaseYear() int {
+	return time.Now().Year() + releaseYearLookahead
 }
 
 // GetDefaultFilters returns default discovery filters
@@ -272,8 +160,8 @@ func GetDefaultFilters() DiscoveryFilters {
 		SortBy:    "popularity.desc",
 		MinRating: 0,
 		MaxRating: 10,
-		MinYear:   1900,
-		MaxYear:   2030,
+		MinYear:   minReleaseYear,
+		MaxYear:   maxReleaseYear(),
 	}
 }
 
@@ -290,6 +178,7 @@ func (f *DiscoveryFilters) Validate() error {
 		"revenue.asc":               true,
 		"primary_release_date.desc": true,
 		"primary_release_date.asc":  true,
+		SortByComposite:             true,
 	}
 
 	if !validSortOptions[f.SortBy] {
@@ -306,35 +195,168 @@ func (f *DiscoveryFilters) Validate() error {
 		f.MinRating, f.MaxRating = f.MaxRating, f.MinRating
 	}
 
-	if f.MinYear < 1900 {
-		f.MinYear = 1900
+	if f.MinYear < minReleaseYear {
+		f.MinYear = minReleaseYear
 	}
-	if f.MaxYear > 2030 {
-		f.MaxYear = 2030
+	if maxYear := maxReleaseYear(); f.MaxYear > maxYear {
+		f.MaxYear = maxYear
 	}
 	if f.MinYear > f.MaxYear {
 		f.MinYear, f.MaxYear = f.MaxYear, f.MinYear
 	}
 
+	switch f.GenreMatch {
+	case "":
+		f.GenreMatch = GenreMatchAll
+	case GenreMatchAll, GenreMatchAny:
+	default:
+		return fmt.Errorf("genre_match must be %s or %s", GenreMatchAll, GenreMatchAny)
+	}
+
+	for _, list := range []struct {
+		name string
+		ids  []int
+	}{
+		{"genres", f.GenreIDs},
+		{"without_genres", f.WithoutGenres},
+		{"keywords", f.KeywordIDs},
+		{"cast", f.CastIDs},
+		{"crew", f.CrewIDs},
+		{"providers", f.ProviderIDs},
+	} {
+		for _, id := range list.ids {
+			if id <= 0 {
+				return fmt.Errorf("%s must be positive IDs", list.name)
+			}
+		}
+	}
+	for _, id := range f.WithoutGenres {
+		if containsInt(f.GenreIDs, id) {
+			return fmt.Errorf("genre %d cannot be both included and excluded", id)
+		}
+	}
+
+	if f.MinRuntime < 0 || f.MaxRuntime < 0 {
+		return fmt.Errorf("runtime must not be negative")
+	}
+	if f.MaxRuntime > 0 && f.MinRuntime > f.MaxRuntime {
+		return fmt.Errorf("min_runtime must not be greater than max_runtime")
+	}
+	if f.MinVoteCount < 0 {
+		return fmt.Errorf("min_votes must not be negative")
+	}
+
+	f.Language = strings.ToLower(strings.TrimSpace(f.Language))
+	if f.Language != "" && !languagePattern.MatchString(f.Language) {
+		return fmt.Errorf("original language must be an ISO 639-1 language code, e.g. en")
+	}
+
+	f.Region = strings.ToUpper(strings.TrimSpace(f.Region))
+	if f.Region != "" && !regionPattern.MatchString(f.Region) {
+		return fmt.Errorf("region must be an ISO 3166-1 country code, e.g. US")
+	}
+
+	// TMDB only applies certifications and watch providers within a country
+	f.Certification = strings.TrimSpace(f.Certification)
+	if f.Certification != "" && f.Region == "" {
+		return fmt.Errorf("certification requires a region")
+	}
+	if len(f.ProviderIDs) > 0 && f.Region == "" {
+		return fmt.Errorf("providers requires a region")
+	}
+
 	return nil
 }
 
 // GetPopularGenres returns the most popular genres based on current trending content
-func (s *GenreService) GetPopularGenres(contentType string) ([]models.Genre, error) {
+func (s *GenreService) GetPopularGenres(ctx context.Context, contentType string) ([]models.Genre, error) {
 	// This is a simplified implementation
 	// In a real system, you'd analyze trending content to determine popular genres
 
 	if contentType == "movie" {
-		return s.GetMovieGenres()
+		return s.GetMovieGenres(ctx)
 	} else {
-		return s.GetTVGenres()
+		return s.GetTVGenres(ctx)
 	}
 }
 
-// SearchByGenreAndKeyword searches for content by both genre and keyword
-func (s *GenreService) SearchByGenreAndKeyword(genreID int, keyword string, contentType string, page int) (*models.SearchResult, error) {
-	// This would combine genre filtering with keyword search
-	// For now, we'll use the basic discovery endpoint
+// SearchByGenreAndKeyword discovers movies or TV shows in a genre that have
+// every comma-separated keyword in keywords. Each keyword is resolved to a
+// TMDB keyword by an exact name match or a single search result; if any
+// keyword is ambiguous or unknown, its suggestions are returned instead of
+// results.
+func (s *GenreService) SearchByGenreAndKeyword(ctx context.Context, genreID int, keywords string, mediaType string, page int) (*models.GenreKeywordSearch, error) {
+	if mediaType != "movie" && mediaType != "tv" {
+		return nil, fmt.Errorf("unsupported media type: %s", mediaType)
+	}
+
+	search := &models.GenreKeywordSearch{GenreID: genreID, MediaType: mediaType, Keywords: []models.KeywordMatch{}}
 	filters := GetDefaultFilters()
-	return s.DiscoverMoviesByGenre(genreID, page, filters)
+	filters.GenreIDs = []int{genreID}
+
+	for _, query := range strings.Split(keywords, ",") {
+		query = strings.TrimSpace(query)
+		if query == "" {
+			continue
+		}
+
+		result, err := s.tmdbClient.SearchKeywords(ctx, query, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		match := resolveKeyword(query, result.Results)
+		search.Keywords = append(search.Keywords, match)
+		if match.Keyword != nil && !containsInt(filters.KeywordIDs, match.Keyword.ID) {
+			filters.KeywordIDs = append(filters.KeywordIDs, match.Keyword.ID)
+		}
+	}
+
+	if len(search.Keywords) == 0 {
+		return search, nil
+	}
+	for _, match := range search.Keywords {
+		if match.Keyword == nil {
+			return search, nil
+		}
+	}
+
+	if err := filters.Validate(); err != nil {
+		return nil, err
+	}
+	results, err := s.Discover(ctx, mediaType, page, filters)
+	if err != nil {
+		return nil, err
+	}
+	search.Results = results
+
+	return search, nil
+}
+
+// maxKeywordSuggestions limits the suggestions for an ambiguous keyword
+const maxKeywordSuggestions = 10
+
+// resolveKeyword picks the keyword whose name matches query ignoring case,
+// or the only candidate. Otherwise the candidates become suggestions.
+func resolveKeyword(query string, candidates []models.Keyword) models.KeywordMatch {
+	match := models.KeywordMatch{Query: query}
+
+	for i := range candidates {
+		if strings.EqualFold(candidates[i].Name, query) {
+			keyword := candidates[i]
+			match.Keyword = &keyword
+			return match
+		}
+	}
+	if len(candidates) == 1 {
+		keyword := candidates[0]
+		match.Keyword = &keyword
+		return match
+	}
+
+	match.Suggestions = candidates
+	if len(match.Suggestions) > maxKeywordSuggestions {
+		match.Suggestions = match.Suggestions[:maxKeywordSuggestions]
+	}
+	return match
 }
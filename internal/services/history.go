@@ -0,0 +1,219 @@
+package services
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
+)
+
+// History defaults
+const (
+	defaultHistoryMaxEntries = 50
+	defaultHistoryRetention  = 90 * 24 * time.Hour
+)
+
+// HistoryService keeps each user's recent searches and recently viewed titles.
+// Users who opt out have nothing recorded.
+type HistoryService struct {
+	maxEntries int           // Searches and views kept per user
+	retention  time.Duration // How long entries are kept
+	clock      Clock
+
+	mu       sync.Mutex
+	searches map[string][]models.RecentSearch // userID -> searches, newest first
+	views    map[string][]models.RecentView   // userID -> views, newest first
+	disabled map[string]bool                  // Users who opted out
+}
+
+// NewHistoryService creates a new history service
+func NewHistoryService(config *configs.Config) *HistoryService {
+	maxEntries := config.History.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultHistoryMaxEntries
+	}
+	retention := config.History.Retention
+	if retention <= 0 {
+		retention = defaultHistoryRetention
+	}
+
+	return &HistoryService{
+		maxEntries: maxEntries,
+		retention:  retention,
+		clock:      realClock{},
+		searches:   make(map[string][]models.RecentSearch),
+		views:      make(map[string][]models.RecentView),
+		disabled:   make(map[string]bool),
+	}
+}
+
+// RecordSearch records a search, moving a repeated search to the front
+func (s *HistoryService) RecordSearch(userID, mediaType, query string) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.disabled[userID] {
+		return
+	}
+
+	searches := []models.RecentSearch{{Query: query, MediaType: mediaType, SearchedAt: s.clock.Now()}}
+	for _, search := range s.searches[userID] {
+		if search.MediaType == mediaType && strings.EqualFold(search.Query, query) {
+			continue
+		}
+		searches = append(searches, search)
+	}
+	s.searches[userID] = s.pruneSearches(searches)
+}
+
+// RecordMovieView records that a user opened a movie's details
+func (s *HistoryService) RecordMovieView(userID string, movie *models.Movie) {
+	s.recordView(userID, models.RecentView{
+		ID:         movie.ID,
+		MediaType:  "movie",
+		Title:      movie.Title,
+		PosterPath: movie.PosterPath,
+		GenreIDs:   genreIDs(movie.Genres, movie.GenreIDs),
+	})
+}
+
+// RecordTVShowView records that a user opened a TV show's details
+func (s *HistoryService) RecordTVShowView(userID string, tvShow *models.TVShow) {
+	s.recordView(userID, models.RecentView{
+		ID:         tvShow.ID,
+		MediaType:  "tv",
+		Title:      tvShow.Name,
+		PosterPath: tvShow.PosterPath,
+		GenreIDs:   genreIDs(tvShow.Genres, tvShow.GenreIDs),
+	})
+}
+
+// recordView records a view, moving a title viewed again to the front
+func (s *HistoryService) recordView(userID string, view models.RecentView) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.disabled[userID] {
+		return
+	}
+
+	view.ViewedAt = s.clock.Now()
+	views := []models.RecentView{view}
+	for _, existing := range s.views[userID] {
+		if existing.ID == view.ID && existing.MediaType == view.MediaType {
+			continue
+		}
+		views = append(views, existing)
+	}
+	s.views[userID] = s.pruneViews(views)
+}
+
+// GetHistory gets a user's recent searches and views
+func (s *HistoryService) GetHistory(userID string) models.History {
+	return models.History{
+		Enabled:  s.IsEnabled(userID),
+		Searches: s.GetRecentSearches(userID),
+		Views:    s.GetRecentViews(userID),
+	}
+}
+
+// GetRecentSearches gets a user's recent searches, newest first
+func (s *HistoryService) GetRecentSearches(userID string) []models.RecentSearch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	searches := s.pruneSearches(s.searches[userID])
+
+	// Return a copy to prevent external modification
+	result := make([]models.RecentSearch, len(searches))
+	copy(result, searches)
+	return result
+}
+
+// GetRecentViews gets a user's recently viewed titles, newest first
+func (s *HistoryService) GetRecentViews(userID string) []models.RecentView {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	views := s.pruneViews(s.views[userID])
+
+	// Return a copy to prevent external modification
+	result := make([]models.RecentView, len(views))
+	copy(result, views)
+	return result
+}
+
+// ClearSearches removes a user's recent searches
+func (s *HistoryService) ClearSearches(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.searches, userID)
+}
+
+// ClearViews removes a user's recently viewed titles
+func (s *HistoryService) ClearViews(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.views, userID)
+}
+
+// DeleteHistory removes everything kept for a user, including an opt-out
+func (s *HistoryService) DeleteHistory(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.searches, userID)
+	delete(s.views, userID)
+	delete(s.disabled, userID)
+}
+
+// IsEnabled reports whether history is recorded for a user
+func (s *HistoryService) IsEnabled(userID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.disabled[userID]
+}
+
+// SetEnabled turns history on or off for a user. Turning it off also
+// removes what was recorded.
+func (s *HistoryService) SetEnabled(userID string, enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if enabled {
+		delete(s.disabled, userID)
+		return
+	}
+	s.disabled[userID] = true
+	delete(s.searches, userID)
+	delete(s.views, userID)
+}
+
+// pruneSearches drops searches past the retention period or the entry limit
+func (s *HistoryService) pruneSearches(searches []models.RecentSearch) []models.RecentSearch {
+	cutoff := s.clock.Now().Add(-s.retention)
+	for i, search := range searches {
+		if i == s.maxEntries || search.SearchedAt.Before(cutoff) {
+			return searches[:i]
+		}
+	}
+	return searches
+}
+
+// pruneViews drops views past the retention period or the entry limit
+func (s *HistoryService) pruneViews(views []models.RecentView) []models.RecentView {
+	cutoff := s.clock.Now().Add(-s.retention)
+	for i, view := range views {
+		if i == s.maxEntries || view.ViewedAt.Before(cutoff) {
+			return views[:i]
+		}
+	}
+	return views
+}
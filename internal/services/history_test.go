@@ -0,0 +1,139 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
+)
+
+func newTestHistoryService(maxEntries int) (*HistoryService, *fakeClock) {
+	clock := newFakeClock(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+	service := NewHistoryService(&configs.Config{History: configs.HistoryConfig{MaxEntries: maxEntries, Retention: 30 * 24 * time.Hour}})
+	service.clock = clock
+	return service, clock
+}
+
+func TestHistoryService_RecordSearch(t *testing.T) {
+	service, clock := newTestHistoryService(3)
+
+	service.RecordSearch("user", "movie", "dune")
+	clock.Advance(time.Minute)
+	service.RecordSearch("user", "tv", "dune")
+	clock.Advance(time.Minute)
+	service.RecordSearch("user", "movie", "arrival")
+	clock.Advance(time.Minute)
+	service.RecordSearch("user", "movie", "  Dune ")
+
+	// Repeated searches move to the front
+	want := []models.RecentSearch{
+		{Query: "Dune", MediaType: "movie", SearchedAt: clock.Now()},
+		{Query: "arrival", MediaType: "movie", SearchedAt: clock.Now().Add(-time.Minute)},
+		{Query: "dune", MediaType: "tv", SearchedAt: clock.Now().Add(-2 * time.Minute)},
+	}
+	got := service.GetRecentSearches("user")
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	// Only the newest entries are kept
+	clock.Advance(time.Minute)
+	service.RecordSearch("user", "movie", "sicario")
+	if got := service.GetRecentSearches("user"); len(got) != 3 || got[0].Query != "sicario" || got[2].Query != "arrival" {
+		t.Errorf("Expected the oldest search to be dropped, got %v", got)
+	}
+
+	// Entries expire after the retention period
+	clock.Advance(30*24*time.Hour - time.Minute)
+	service.RecordSearch("user", "movie", "prisoners")
+	if got := service.GetRecentSearches("user"); len(got) != 3 {
+		t.Errorf("Expected 3 searches within the retention period, got %v", got)
+	}
+	clock.Advance(time.Second)
+	if got := service.GetRecentSearches("user"); len(got) != 2 || got[0].Query != "prisoners" || got[1].Query != "sicario" {
+		t.Errorf("Expected expired searches to be dropped, got %v", got)
+	}
+
+	service.RecordSearch("other", "movie", "heat")
+	service.ClearSearches("user")
+	if got := service.GetRecentSearches("user"); len(got) != 0 {
+		t.Errorf("Expected no searches after clearing, got %v", got)
+	}
+	if got := service.GetRecentSearches("other"); len(got) != 1 {
+		t.Errorf("Expected other users' searches to be kept, got %v", got)
+	}
+}
+
+func TestHistoryService_RecordView(t *testing.T) {
+	service, clock := newTestHistoryService(0)
+
+	service.RecordMovieView("user", &models.Movie{ID: 438631, Title: "Dune", PosterPath: "/dune.jpg", Genres: []models.Genre{{ID: 878, Name: "Science Fiction"}, {ID: 12, Name: "Adventure"}}})
+	clock.Advance(time.Minute)
+	service.RecordTVShowView("user", &models.TVShow{ID: 438631, Name: "Dune: Prophecy", GenreIDs: []int{10765}})
+	clock.Advance(time.Minute)
+	service.RecordMovieView("user", &models.Movie{ID: 438631, Title: "Dune", PosterPath: "/dune.jpg", GenreIDs: []int{878, 12}})
+
+	want := []models.RecentView{
+		{ID: 438631, MediaType: "movie", Title: "Dune", PosterPath: "/dune.jpg", GenreIDs: []int{878, 12}, ViewedAt: clock.Now()},
+		{ID: 438631, MediaType: "tv", Title: "Dune: Prophecy", GenreIDs: []int{10765}, ViewedAt: clock.Now().Add(-time.Minute)},
+	}
+	got := service.GetRecentViews("user")
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestHistoryService_OptOut(t *testing.T) {
+	service, _ := newTestHistoryService(0)
+
+	service.RecordSearch("user", "movie", "dune")
+	service.RecordMovieView("user", &models.Movie{ID: 438631, Title: "Dune"})
+
+	// Opting out removes the history and stops recording
+	service.SetEnabled("user", false)
+	service.RecordSearch("user", "movie", "arrival")
+	service.RecordMovieView("user", &models.Movie{ID: 329865, Title: "Arrival"})
+
+	history := service.GetHistory("user")
+	if history.Enabled || len(history.Searches) != 0 || len(history.Views) != 0 {
+		t.Errorf("Expected empty, disabled history, got %+v", history)
+	}
+
+	service.SetEnabled("user", true)
+	service.RecordSearch("user", "movie", "sicario")
+	if history := service.GetHistory("user"); !history.Enabled || len(history.Searches) != 1 {
+		t.Errorf("Expected history to be recorded again, got %+v", history)
+	}
+}
+
+func TestRecommendationService_RecentViews(t *testing.T) {
+	service := &RecommendationService{}
+
+	watchlist := []models.WatchlistItem{{ID: "1", Type: "tv"}}
+	views := []models.RecentView{
+		{ID: 2, MediaType: "movie", GenreIDs: []int{878, 12}},
+		{ID: 3, MediaType: "movie", GenreIDs: []int{878}},
+	}
+	preferences := service.analyzeUserPreferences(watchlist, views)
+
+	// Each view counts for a quarter of a watchlist item
+	if preferences.MovieVsTVRatio != 0.5/1.5 {
+		t.Errorf("Expected a movie ratio of 1/3, got %v", preferences.MovieVsTVRatio)
+	}
+
+	scifi := map[string]interface{}{"genre_ids": []interface{}{878.0}, "vote_average": 7.0}
+	romance := map[string]interface{}{"genre_ids": []interface{}{10749.0}, "vote_average": 7.0}
+	if got := genreAffinity(scifi["genre_ids"].([]interface{}), preferences.FavoriteGenres); got != 2.0/3 {
+		t.Errorf("Expected a science fiction affinity of 2/3, got %v", got)
+	}
+	if service.calculateRecommendationScore(scifi, preferences, "movie") <= service.calculateRecommendationScore(romance, preferences, "movie") {
+		t.Error("Expected a genre seen recently to score higher")
+	}
+
+	// Without views there is no genre preference to match
+	if got := genreAffinity(scifi["genre_ids"].([]interface{}), service.analyzeUserPreferences(watchlist, nil).FavoriteGenres); got != 0 {
+		t.Errorf("Expected no affinity without views, got %v", got)
+	}
+}
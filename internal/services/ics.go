@@ -0,0 +1,92 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// icsEvent is an all-day event in an iCalendar feed
+type icsEvent struct {
+	UID         string
+	Date        time.Time
+	Summary     string
+	Description string
+	URL         string
+}
+
+// icsMaxLineOctets is the longest content line RFC 5545 allows before folding
+const icsMaxLineOctets = 75
+
+// writeICS renders events as an iCalendar (RFC 5545) feed
+func writeICS(name string, events []icsEvent, stamp time.Time) []byte {
+	var buf bytes.Buffer
+	line := func(content string) {
+		buf.WriteString(foldICSLine(content))
+		buf.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//movie-discovery-app//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + escapeICSText(name))
+
+	for _, event := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + event.UID)
+		line("DTSTAMP:" + stamp.UTC().Format("20060102T150405Z"))
+		line("DTSTART;VALUE=DATE:" + event.Date.Format("20060102"))
+		line("DTEND;VALUE=DATE:" + event.Date.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY:" + escapeICSText(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION:" + escapeICSText(event.Description))
+		}
+		if event.URL != "" {
+			line("URL:" + event.URL)
+		}
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// escapeICSText escapes a TEXT property value
+func escapeICSText(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(text)
+}
+
+// foldICSLine splits a content line longer than 75 octets into a first line
+// and continuation lines starting with a space, without splitting a UTF-8
+// character
+func foldICSLine(content string) string {
+	if len(content) <= icsMaxLineOctets {
+		return content
+	}
+
+	var b strings.Builder
+	limit := icsMaxLineOctets
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		b.WriteString(content[:cut])
+		b.WriteString("\r\n ")
+		content = content[cut:]
+		// Continuation lines lose one octet to the leading space
+		limit = icsMaxLineOctets - 1
+	}
+	b.WriteString(content)
+	return b.String()
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	icsContentLine = regexp.MustCompile(`^([A-Za-z0-9-]+)((?:;[A-Za-z0-9-]+=[^;:]*)*):(.*)$`)
+	icsDateTimeUTC = regexp.MustCompile(`^\d{8}T\d{6}Z$`)
+	icsDate        = regexp.MustCompile(`^\d{8}$`)
+)
+
+// icsTextProperties hold TEXT values, which must escape , ; and \
+var icsTextProperties = map[string]bool{"SUMMARY": true, "DESCRIPTION": true, "X-WR-CALNAME": true}
+
+// assertValidICS checks a feed against the RFC 5545 rules the feeds rely
+// on: CRLF line endings, lines folded at 75 octets, well-formed content
+// lines, balanced components, required properties and escaped TEXT values
+func assertValidICS(t *testing.T, data []byte) {
+	t.Helper()
+	ics := string(data)
+
+	if !strings.HasSuffix(ics, "\r\n") {
+		t.Fatal("Feed does not end with CRLF")
+	}
+	physical := strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n")
+	for i, line := range physical {
+		if strings.ContainsAny(line, "\r\n") {
+			t.Errorf("Line %d has a bare CR or LF: %q", i, line)
+		}
+		if len(line) > icsMaxLineOctets {
+			t.Errorf("Line %d is %d octets long: %q", i, len(line), line)
+		}
+	}
+
+	// Unfold continuation lines
+	var lines []string
+	for _, line := range physical {
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			if len(lines) == 0 {
+				t.Fatal("Feed starts with a continuation line")
+			}
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	if lines[0] != "BEGIN:VCALENDAR" || lines[len(lines)-1] != "END:VCALENDAR" {
+		t.Fatalf("Feed must be wrapped in a VCALENDAR, got %q ... %q", lines[0], lines[len(lines)-1])
+	}
+
+	var stack []string
+	var properties map[string][]string
+	calendarProperties := make(map[string][]string)
+	uids := make(map[string]bool)
+
+	for _, line := range lines {
+		match := icsContentLine.FindStringSubmatch(line)
+		if match == nil {
+			t.Errorf("Malformed content line %q", line)
+			continue
+		}
+		name, params, value := strings.ToUpper(match[1]), match[2], match[3]
+
+		switch name {
+		case "BEGIN":
+			stack = append(stack, value)
+			if value == "VEVENT" {
+				properties = make(map[string][]string)
+			}
+			continue
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1] != value {
+				t.Fatalf("END:%s does not match the open component %v", value, stack)
+			}
+			stack = stack[:len(stack)-1]
+			if value == "VEVENT" {
+				assertValidVEvent(t, properties, uids)
+			}
+			continue
+		}
+
+		if icsTextProperties[name] {
+			for i := 0; i < len(value); i++ {
+				switch value[i] {
+				case ',', ';':
+					t.Errorf("Unescaped %q in %s: %q", value[i], name, value)
+				case '\\':
+					if i+1 >= len(value) || !strings.ContainsRune(`\;,nN`, rune(value[i+1])) {
+						t.Errorf("Invalid escape in %s: %q", name, value)
+					}
+					i++
+				}
+			}
+		}
+
+		entry := params + ":" + value
+		if stack[len(stack)-1] == "VEVENT" {
+			properties[name] = append(properties[name], entry)
+		} else {
+			calendarProperties[name] = append(calendarProperties[name], entry)
+		}
+	}
+
+	if len(stack) != 0 {
+		t.Errorf("Unclosed components %v", stack)
+	}
+	if version := calendarProperties["VERSION"]; len(version) != 1 || version[0] != ":2.0" {
+		t.Errorf("Expected VERSION:2.0 once, got %v", version)
+	}
+	if len(calendarProperties["PRODID"]) != 1 {
+		t.Errorf("Expected PRODID once, got %v", calendarProperties["PRODID"])
+	}
+}
+
+// assertValidVEvent checks an all-day event's required properties
+func assertValidVEvent(t *testing.T, properties map[string][]string, uids map[string]bool) {
+	t.Helper()
+
+	for _, name := range []string{"UID", "DTSTAMP", "DTSTART", "DTEND", "SUMMARY"} {
+		if len(properties[name]) != 1 {
+			t.Errorf("Expected exactly one %s in VEVENT, got %v", name, properties[name])
+			return
+		}
+	}
+
+	uid := properties["UID"][0]
+	if uids[uid] {
+		t.Errorf("Duplicate UID %s", uid)
+	}
+	uids[uid] = true
+
+	if stamp := strings.TrimPrefix(properties["DTSTAMP"][0], ":"); !icsDateTimeUTC.MatchString(stamp) {
+		t.Errorf("DTSTAMP must be a UTC date-time, got %q", stamp)
+	}
+
+	start, end := properties["DTSTART"][0], properties["DTEND"][0]
+	if !strings.HasPrefix(start, ";VALUE=DATE:") || !strings.HasPrefix(end, ";VALUE=DATE:") {
+		t.Errorf("Expected all-day DTSTART and DTEND, got %q and %q", start, end)
+		return
+	}
+	start, end = strings.TrimPrefix(start, ";VALUE=DATE:"), strings.TrimPrefix(end, ";VALUE=DATE:")
+	if !icsDate.MatchString(start) || !icsDate.MatchString(end) || end <= start {
+		t.Errorf("Invalid event dates %q to %q", start, end)
+	}
+}
+
+func TestWriteICS(t *testing.T) {
+	data := writeICS("Releases; mine, all", []icsEvent{
+		{
+			UID:         "a@movie-discovery-app",
+			Date:        time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+			Summary:     `Crouching Tiger, Hidden Dragon; or \ so`,
+			Description: "Line one\nLine two " + strings.Repeat("long ", 30),
+		},
+		{
+			UID:     "b@movie-discovery-app",
+			Date:    time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			Summary: "Plain",
+		},
+	}, time.Date(2024, 12, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600)))
+
+	assertValidICS(t, data)
+
+	ics := string(data)
+	for _, expected := range []string{
+		"X-WR-CALNAME:Releases\\; mine\\, all\r\n",
+		"SUMMARY:Crouching Tiger\\, Hidden Dragon\\; or \\\\ so\r\n",
+		"DESCRIPTION:Line one\\nLine two",
+		"DTSTAMP:20241201T113000Z\r\n",
+		"DTSTART;VALUE=DATE:20241231\r\nDTEND;VALUE=DATE:20250101\r\n",
+	} {
+		if !strings.Contains(ics, expected) {
+			t.Errorf("Expected feed to contain %q, got:\n%s", expected, ics)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"movie-discovery-app/internal/models"
+)
+
+// searchIndexStore keeps the local search index saved to disk
+type searchIndexStore struct {
+	path         string // Empty keeps the index in memory only
+	saveInterval time.Duration
+	lastSave     atomic.Int64 // Unix nanoseconds
+	saving       atomic.Bool
+}
+
+// newSearchIndex loads the saved search index, starting empty when there is
+// none or it can't be read. An unreadable index is replaced on the next save
+// and refills as titles are fetched.
+func newSearchIndex(path string) *SearchIndex {
+	if path == "" {
+		return NewSearchIndex()
+	}
+	index, err := LoadSearchIndex(path)
+	if err != nil {
+		log.Printf("Starting with an empty search index: %v", err)
+	}
+	return index
+}
+
+// SaveSearchIndex saves the local search index if it has changed
+func (s *DiscoveryService) SaveSearchIndex() error {
+	if s.searchIndexStore.path == "" {
+		return nil
+	}
+	s.searchIndexStore.lastSave.Store(time.Now().UnixNano())
+	return s.searchIndex.Save(s.searchIndexStore.path)
+}
+
+// saveSearchIndexIfDue saves the search index in the background when the
+// save interval has passed since the last save
+func (s *DiscoveryService) saveSearchIndexIfDue() {
+	store := s.searchIndexStore
+	if store.path == "" || time.Since(time.Unix(0, store.lastSave.Load())) < store.saveInterval {
+		return
+	}
+	if !store.saving.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer store.saving.Store(false)
+		if err := s.SaveSearchIndex(); err != nil {
+			log.Printf("Failed to save search index: %v", err)
+		}
+	}()
+}
+
+// searchLocalIndex searches the local index while TMDB is unavailable,
+// returning nil when nothing matches
+func (s *DiscoveryService) searchLocalIndex(mediaType, query string, page int) *models.SearchResult {
+	docs, total := s.searchIndex.Search(mediaType, query, page)
+	if total == 0 {
+		return nil
+	}
+
+	results := make([]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		results = append(results, searchDocumentResult(doc))
+	}
+	s.scoreResults(results)
+
+	return &models.SearchResult{
+		Page:         page,
+		Results:      results,
+		TotalPages:   (total + searchIndexPageSize - 1) / searchIndexPageSize,
+		TotalResults: total,
+		Degraded:     []string{"tmdb"},
+	}
+}
+
+// searchDocumentResult converts an indexed title to the shape of a TMDB
+// search result, with numbers as float64 and genre IDs as []interface{} as
+// if decoded from TMDB's JSON, since filters and scoring expect those types
+func searchDocumentResult(doc SearchDocument) map[string]interface{} {
+	genreIDs := make([]interface{}, 0, len(doc.GenreIDs))
+	for _, genreID := range doc.GenreIDs {
+		genreIDs = append(genreIDs, float64(genreID))
+	}
+
+	result := map[string]interface{}{
+		"id":           float64(doc.ID),
+		"overview":     doc.Overview,
+		"poster_path":  doc.PosterPath,
+		"genre_ids":    genreIDs,
+		"vote_average": doc.VoteAverage,
+		"vote_count":   float64(doc.VoteCount),
+		"popularity":   doc.Popularity,
+		"local":        true,
+	}
+	if doc.MediaType == "tv" {
+		result["name"] = doc.Title
+		result["original_name"] = doc.OriginalTitle
+		result["first_air_date"] = doc.ReleaseDate
+	} else {
+		result["title"] = doc.Title
+		result["original_title"] = doc.OriginalTitle
+		result["release_date"] = doc.ReleaseDate
+	}
+	return result
+}
+
+// indexMovie adds a movie's details to the search index, unless they are
+// localized
+func (s *DiscoveryService) indexMovie(ctx context.Context, movie *models.Movie) {
+	if !LocaleFromContext(ctx).IsEnglish() {
+		return
+	}
+
+	cast, directors := creditNames(movie.Credits)
+	if len(cast) == 0 {
+		cast = splitNames(movie.Actors)
+	}
+	if len(directors) == 0 {
+		directors = splitNames(movie.Director)
+	}
+
+	s.searchIndex.Add(SearchDocument{
+		ID:            movie.ID,
+		MediaType:     "movie",
+		Title:         movie.Title,
+		OriginalTitle: movie.OriginalTitle,
+		Overview:      movie.Overview,
+		ReleaseDate:   movie.ReleaseDate,
+		PosterPath:    movie.PosterPath,
+		GenreIDs:      genreIDs(movie.Genres, movie.GenreIDs),
+		VoteAverage:   movie.VoteAverage,
+		VoteCount:     movie.VoteCount,
+		Popularity:    movie.Popularity,
+		Cast:          cast,
+		Directors:     directors,
+		Keywords:      keywordNames(movie.Keywords),
+	})
+	s.saveSearchIndexIfDue()
+}
+
+// indexTVShow adds a TV show's details to the search index, unless they are
+// localized
+func (s *DiscoveryService) indexTVShow(ctx context.Context, tvShow *models.TVShow) {
+	if !LocaleFromContext(ctx).IsEnglish() {
+		return
+	}
+
+	cast, directors := creditNames(tvShow.Credits)
+	if len(cast) == 0 {
+		cast = splitNames(tvShow.Actors)
+	}
+	if len(directors) == 0 {
+		directors = splitNames(tvShow.Director)
+	}
+
+	s.searchIndex.Add(SearchDocument{
+		ID:            tvShow.ID,
+		MediaType:     "tv",
+		Title:         tvShow.Name,
+		OriginalTitle: tvShow.OriginalName,
+		Overview:      tvShow.Overview,
+		ReleaseDate:   tvShow.FirstAirDate,
+		PosterPath:    tvShow.PosterPath,
+		GenreIDs:      genreIDs(tvShow.Genres, tvShow.GenreIDs),
+		VoteAverage:   tvShow.VoteAverage,
+		VoteCount:     tvShow.VoteCount,
+		Popularity:    tvShow.Popularity,
+		Cast:          cast,
+		Directors:     directors,
+		Keywords:      keywordNames(tvShow.Keywords),
+	})
+	s.saveSearchIndexIfDue()
+}
+
+// searchResultDocument converts a movie or TV search result to an indexed
+// title
+func searchResultDocument(mediaType string, item map[string]interface{}) SearchDocument {
+	titleField, originalField, dateField := "title", "original_title", "release_date"
+	if mediaType == "tv" {
+		titleField, originalField, dateField = "name", "original_name", "first_air_date"
+	}
+
+	id, _ := item["id"].(float64)
+	doc := SearchDocument{ID: int(id), MediaType: mediaType}
+	doc.Title, _ = item[titleField].(string)
+	doc.OriginalTitle, _ = item[originalField].(string)
+	doc.Overview, _ = item["overview"].(string)
+	doc.ReleaseDate, _ = item[dateField].(string)
+	doc.PosterPath, _ = item["poster_path"].(string)
+	doc.VoteAverage, _ = item["vote_average"].(float64)
+	voteCount, _ := item["vote_count"].(float64)
+	doc.VoteCount = int(voteCount)
+	doc.Popularity, _ = item["popularity"].(float64)
+	if ids, ok := item["genre_ids"].([]interface{}); ok {
+		for _, genreID := range ids {
+			if genreID, ok := genreID.(float64); ok {
+				doc.GenreIDs = append(doc.GenreIDs, int(genreID))
+			}
+		}
+	}
+	return doc
+}
+
+// creditNames returns the top-billed cast and the directors in credits
+func creditNames(credits *models.Credits) (cast, directors []string) {
+	if credits == nil {
+		return nil, nil
+	}
+	for _, member := range credits.Cast {
+		if len(cast) == maxIndexedCast {
+			break
+		}
+		cast = append(cast, member.Name)
+	}
+	for _, member := range credits.Crew {
+		if member.Job == "Director" {
+			directors = appendUnique(directors, member.Name)
+		}
+	}
+	return cast, directors
+}
+
+// keywordNames returns the names of a title's keywords
+func keywordNames(keywords *models.KeywordList) []string {
+	if keywords == nil {
+		return nil
+	}
+	names := make([]string, 0, len(keywords.Keywords))
+	for _, keyword := range keywords.Keywords {
+		names = append(names, keyword.Name)
+	}
+	return names
+}
+
+// genreIDs returns the IDs of a title's genres, which details list in full
+// and search results by ID
+func genreIDs(genres []models.Genre, ids []int) []int {
+	if len(genres) == 0 {
+		return ids
+	}
+	result := make([]int, 0, len(genres))
+	for _, genre := range genres {
+		result = append(result, genre.ID)
+	}
+	return result
+}
+
+// splitNames splits an OMDB list of names such as "Lana Wachowski, Lilly
+// Wachowski"
+func splitNames(list string) []string {
+	if list == "" || list == "N/A" {
+		return nil
+	}
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
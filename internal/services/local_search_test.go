@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
+)
+
+// newFakeTMDBDown serves movie and TV details until down is set, and fails
+// every search
+func newFakeTMDBDown(t *testing.T, down *atomic.Bool) *httptest.Server {
+	tmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/movie/603":
+			w.Write([]byte(`{"id": 603, "title": "The Matrix", "original_title": "The Matrix", "release_date": "1999-03-30", "overview": "A hacker learns the truth about his reality.", "vote_average": 8.2, "vote_count": 25000, "popularity": 70, "genres": [{"id": 878, "name": "Science Fiction"}],
+				"credits": {"cast": [{"id": 6384, "name": "Keanu Reeves"}], "crew": [{"id": 9340, "name": "Lana Wachowski", "job": "Director"}, {"id": 9339, "name": "Lilly Wachowski", "job": "Director"}]},
+				"keywords": {"keywords": [{"id": 1, "name": "simulated reality"}]}}`))
+		case "/tv/1396":
+			w.Write([]byte(`{"id": 1396, "name": "Breaking Bad", "original_name": "Breaking Bad", "first_air_date": "2008-01-20", "overview": "A chemistry teacher turns to crime.", "popularity": 100}`))
+		case "/search/movie":
+			w.Write([]byte(`{"page": 1, "results": [{"id": 604, "title": "The Matrix Reloaded", "release_date": "2003-05-15", "popularity": 40, "genre_ids": [878]}], "total_pages": 1, "total_results": 1}`))
+		default:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(tmdb.Close)
+	return tmdb
+}
+
+func TestDiscoveryService_SearchLocalIndex(t *testing.T) {
+	var down atomic.Bool
+	tmdb := newFakeTMDBDown(t, &down)
+	omdb := newFakeOMDB(func(string) time.Duration { return 0 })
+	defer omdb.Close()
+	service := newTestDiscoveryService(tmdb.URL, omdb.URL)
+	ctx := context.Background()
+
+	// Details and search results are indexed as they pass through
+	if _, err := service.GetMovieDetails(ctx, 603, []string{"credits", "keywords"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := service.GetTVShowDetails(ctx, 1396, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := service.SearchMovies(ctx, "matrix", 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	down.Store(true)
+
+	result, err := service.SearchMovies(ctx, "wachowski matrx", 1)
+	if err != nil {
+		t.Fatalf("Expected the local index to answer, got %v", err)
+	}
+	if !reflect.DeepEqual(result.Degraded, []string{"tmdb"}) {
+		t.Errorf("Expected TMDB to be reported degraded, got %v", result.Degraded)
+	}
+	if len(result.Results) != 1 || result.TotalResults != 1 {
+		t.Fatalf("Expected only The Matrix, got %+v", result.Results)
+	}
+	movie := result.Results[0].(map[string]interface{})
+	if movie["title"] != "The Matrix" || movie["release_date"] != "1999-03-30" || movie["composite"] == nil {
+		t.Errorf("Expected The Matrix as a scored search result, got %+v", movie)
+	}
+
+	// The last word is matched as a prefix
+	result, err = service.SearchMovies(ctx, "the matri", 1)
+	if err != nil {
+		t.Fatalf("Expected the local index to answer, got %v", err)
+	}
+	if titles := resultTitles(result.Results); !reflect.DeepEqual(titles, []string{"The Matrix 1999", "The Matrix Reloaded 2003"}) {
+		t.Errorf("Expected both Matrix movies, got %v", titles)
+	}
+
+	result, err = service.SearchTVShows(ctx, "chemistry", 1)
+	if err != nil {
+		t.Fatalf("Expected the local index to answer, got %v", err)
+	}
+	if len(result.Results) != 1 || result.Results[0].(map[string]interface{})["name"] != "Breaking Bad" {
+		t.Errorf("Expected Breaking Bad, got %+v", result.Results)
+	}
+
+	// Titles never fetched still fail for TV
+	if _, err := service.SearchTVShows(ctx, "the wire", 1); err == nil {
+		t.Error("Expected an error when neither TMDB nor the index can answer")
+	}
+}
+
+func TestDiscoveryService_SearchLocalIndex_FilterAndScore(t *testing.T) {
+	tmdb, _ := newFakeTMDBCertifications(t)
+	service := newTestDiscoveryService(tmdb.URL, "http://omdb.invalid")
+	service.searchIndex.Add(SearchDocument{ID: 1, MediaType: "movie", Title: "Saw", ReleaseDate: "2004-10-29", GenreIDs: []int{27, 53}, VoteAverage: 7.4, VoteCount: 9000})
+	service.searchIndex.Add(SearchDocument{ID: 2, MediaType: "movie", Title: "Saw Mill", ReleaseDate: "2010-01-01", GenreIDs: []int{10751}, VoteAverage: 6, VoteCount: 100})
+
+	result := service.searchLocalIndex("movie", "saw", 1)
+	if result == nil || len(result.Results) != 2 {
+		t.Fatalf("Expected both titles, got %+v", result)
+	}
+
+	// Local results have the types of decoded TMDB results
+	saw := result.Results[0].(map[string]interface{})
+	if saw["id"] != 1.0 || !reflect.DeepEqual(saw["genre_ids"], []interface{}{27.0, 53.0}) {
+		t.Errorf("Expected a float64 ID and []interface{} genre IDs, got %#v and %#v", saw["id"], saw["genre_ids"])
+	}
+	if !hasGenre(27)(saw) || hasGenre(10751)(saw) {
+		t.Error("Expected genre filters to see the local result's genres")
+	}
+	if score := service.scorer.ScoreResult(saw); score == nil || len(score.Breakdown) == 0 {
+		t.Errorf("Expected a TMDB score for the local result, got %+v", score)
+	}
+
+	parental := NewParentalControlService(&configs.Config{}, service)
+	parental.SetControls("kid", models.ParentalControls{
+		MovieCertifications:   map[string]string{"US": "G"},
+		UnknownCertifications: UnknownCertificationsLenient,
+	})
+	filtered, err := parental.FilterResults(context.Background(), "kid", "movie", result.Results)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if titles := resultTitles(filtered); !reflect.DeepEqual(titles, []string{"Saw Mill 2010"}) {
+		t.Errorf("Expected the R-rated title to be filtered out, got %v", titles)
+	}
+}
+
+func TestDiscoveryService_SaveSearchIndex(t *testing.T) {
+	var down atomic.Bool
+	tmdb := newFakeTMDBDown(t, &down)
+	omdb := newFakeOMDB(func(string) time.Duration { return 0 })
+	defer omdb.Close()
+	path := filepath.Join(t.TempDir(), "search_index.json")
+	config := &configs.Config{
+		TMDB:        configs.TMDBConfig{APIKey: "test_key", BaseURL: tmdb.URL},
+		OMDB:        configs.OMDBConfig{APIKey: "test_key", BaseURL: omdb.URL},
+		SearchIndex: configs.SearchIndexConfig{Path: path, SaveInterval: time.Hour},
+	}
+
+	service := NewDiscoveryService(config)
+	for _, upstream := range []*upstreamClient{service.tmdbClient.upstream, service.omdbClient.upstream} {
+		upstream.rateLimiter = NewRateLimiter(60000, 1000)
+	}
+	service.searchIndexStore.lastSave.Store(time.Now().UnixNano())
+	if _, err := service.GetTVShowDetails(context.Background(), 1396, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := service.SaveSearchIndex(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// A new service picks up where the last one stopped
+	restarted := NewDiscoveryService(config)
+	if restarted.searchIndex.Len() != 1 {
+		t.Fatalf("Expected the saved index to be loaded, got %d titles", restarted.searchIndex.Len())
+	}
+	if docs, _ := restarted.searchIndex.Search("tv", "breaking", 1); len(docs) != 1 {
+		t.Errorf("Expected Breaking Bad to be searchable after loading, got %v", searchTitles(docs))
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// localeLanguagePattern matches an ISO 639-1 language code, optionally with
+// an ISO 3166-1 country, such as de or pt-BR
+var localeLanguagePattern = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+
+// Locale is the language and region TMDB titles, overviews and genre names
+// are requested in
+type Locale struct {
+	Language string // e.g. de or pt-BR; empty for TMDB's default, English
+	Region   string // ISO 3166-1 country code; empty for none
+}
+
+type localeContextKey struct{}
+
+// WithLocale returns a context whose TMDB requests use locale
+func WithLocale(ctx context.Context, locale Locale) context.Context {
+	return context.WithValue(ctx, localeContextKey{}, locale)
+}
+
+// LocaleFromContext returns the locale of a request, or the default locale
+func LocaleFromContext(ctx context.Context) Locale {
+	locale, _ := ctx.Value(localeContextKey{}).(Locale)
+	return locale
+}
+
+// IsEnglish reports whether TMDB answers in English for the locale
+func (l Locale) IsEnglish() bool {
+	return l.Language == "" || l.Language == "en" || strings.HasPrefix(l.Language, "en-")
+}
+
+// ParseLocale validates the language and region of a request. Without a
+// language parameter the most preferred supported language in the
+// Accept-Language header is used. The region is only taken from its
+// parameter, since it changes which releases apply rather than the language.
+func ParseLocale(language, region, acceptLanguage string) (Locale, error) {
+	var locale Locale
+
+	if language = strings.TrimSpace(language); language != "" {
+		normalized, ok := normalizeLanguage(language)
+		if !ok {
+			return locale, fmt.Errorf("language must be an ISO 639-1 language code, optionally with a country, e.g. de or pt-BR")
+		}
+		locale.Language = normalized
+	} else {
+		locale.Language = preferredLanguage(acceptLanguage)
+	}
+
+	if region = strings.ToUpper(strings.TrimSpace(region)); region != "" {
+		if !regionPattern.MatchString(region) {
+			return locale, fmt.Errorf("region must be an ISO 3166-1 country code, e.g. US")
+		}
+		locale.Region = region
+	}
+
+	return locale, nil
+}
+
+// normalizeLanguage lower-cases the language and upper-cases the country of
+// a language tag, so pt_br becomes pt-BR
+func normalizeLanguage(tag string) (string, bool) {
+	language, country, hasCountry := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+	normalized := strings.ToLower(language)
+	if hasCountry {
+		normalized += "-" + strings.ToUpper(country)
+	}
+	return normalized, localeLanguagePattern.MatchString(normalized)
+}
+
+// preferredLanguage returns the supported language with the highest quality
+// in an Accept-Language header, or an empty string if there is none
+func preferredLanguage(header string) string {
+	type candidate struct {
+		language string
+		quality  float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		language, ok := normalizeLanguage(strings.TrimSpace(tag))
+		if !ok {
+			continue
+		}
+
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = q
+		}
+		if quality > 0 {
+			candidates = append(candidates, candidate{language, quality})
+		}
+	}
+
+	// Stable, so equally preferred languages keep the header's order
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	if len(candidates) == 0 {
+		return ""
+	}
+	return candidates[0].language
+}
+
+// localeParams adds the request's language and region to TMDB parameters
+// that don't set them already
+func localeParams(ctx context.Context, params url.Values) url.Values {
+	locale := LocaleFromContext(ctx)
+	if params == nil {
+		params = url.Values{}
+	}
+	if locale.Language != "" && params.Get("language") == "" {
+		params.Set("language", locale.Language)
+	}
+	if locale.Region != "" && params.Get("region") == "" {
+		params.Set("region", locale.Region)
+	}
+	return params
+}
+
+// localeCacheKey returns the suffix that keeps cached TMDB responses in
+// different locales apart. It is empty for the default locale.
+func localeCacheKey(ctx context.Context) string {
+	locale := LocaleFromContext(ctx)
+	if locale == (Locale{}) {
+		return ""
+	}
+	return fmt.Sprintf("_%s_%s", locale.Language, locale.Region)
+}
+
+// englishContext returns a context for fetching in English what TMDB has no
+// translation of in the request's language
+func englishContext(ctx context.Context) context.Context {
+	return WithLocale(ctx, Locale{Region: LocaleFromContext(ctx).Region})
+}
+
+// needsEnglishOverviews reports whether localized results have any empty
+// overviews that English could fill in
+func needsEnglishOverviews(ctx context.Context, results []interface{}) bool {
+	if LocaleFromContext(ctx).IsEnglish() {
+		return false
+	}
+	for _, item := range results {
+		if data, ok := item.(map[string]interface{}); ok {
+			if overview, _ := data["overview"].(string); overview == "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fillEnglishOverviews copies overviews from the English results into the
+// localized results with the same ID that have none
+func fillEnglishOverviews(results, english []interface{}) {
+	overviews := make(map[float64]string)
+	for _, item := range english {
+		if data, ok := item.(map[string]interface{}); ok {
+			id, _ := data["id"].(float64)
+			overviews[id], _ = data["overview"].(string)
+		}
+	}
+
+	for _, item := range results {
+		if data, ok := item.(map[string]interface{}); ok {
+			id, _ := data["id"].(float64)
+			if overview, _ := data["overview"].(string); overview == "" && overviews[id] != "" {
+				data["overview"] = overviews[id]
+			}
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseLocale(t *testing.T) {
+	tests := []struct {
+		language       string
+		region         string
+		acceptLanguage string
+		expected       Locale
+	}{
+		{"", "", "", Locale{}},
+		{"de", "", "", Locale{Language: "de"}},
+		{"PT_br", "br", "", Locale{Language: "pt-BR", Region: "BR"}},
+		{"fr", "", "de-DE,de;q=0.9", Locale{Language: "fr"}},
+		{"", "", "de-DE,de;q=0.9,en;q=0.8", Locale{Language: "de-DE"}},
+		{"", "", "en;q=0.5, ja;q=0.8, *;q=0.1", Locale{Language: "ja"}},
+		{"", "GB", "es-419, fr;q=0", Locale{Region: "GB"}},
+		{"", "", "klingon, it;q=bad", Locale{}},
+	}
+	for _, tt := range tests {
+		locale, err := ParseLocale(tt.language, tt.region, tt.acceptLanguage)
+		if err != nil {
+			t.Errorf("%q %q %q: expected no error, got %v", tt.language, tt.region, tt.acceptLanguage, err)
+			continue
+		}
+		if locale != tt.expected {
+			t.Errorf("%q %q %q: expected %+v, got %+v", tt.language, tt.region, tt.acceptLanguage, tt.expected, locale)
+		}
+	}
+
+	for _, params := range [][2]string{{"german", ""}, {"de-DEU", ""}, {"", "USA"}, {"", "1"}} {
+		if _, err := ParseLocale(params[0], params[1], ""); err == nil {
+			t.Errorf("%q %q: expected an error", params[0], params[1])
+		}
+	}
+}
+
+// newFakeTMDBLocalized serves a search, details and genres in English and
+// German, counting the requests made in each language. German has no
+// overview for movie 1.
+func newFakeTMDBLocalized(t *testing.T) (*httptest.Server, func(path, language string) int) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+
+	tmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		language := r.URL.Query().Get("language")
+		mu.Lock()
+		requests[r.URL.Path+" "+language]++
+		mu.Unlock()
+
+		german := language == "de"
+		title, overview, genre := "The Thing", "One", "Comedy"
+		if german {
+			title, overview, genre = "Das Ding", "", "Komödie"
+		}
+
+		switch r.URL.Path {
+		case "/search/movie":
+			second := "Two"
+			if german {
+				second = "Zwei"
+			}
+			fmt.Fprintf(w, `{"page": 1, "results": [{"id": 1, "title": %q, "overview": %q}, {"id": 2, "title": "Two", "overview": %q}], "total_pages": 1, "total_results": 2}`, title, overview, second)
+		case "/movie/1":
+			fmt.Fprintf(w, `{"id": 1, "title": %q, "overview": %q}`, title, overview)
+		case "/genre/movie/list":
+			fmt.Fprintf(w, `{"genres": [{"id": 35, "name": %q}, {"id": 18, "name": "Drama"}]}`, genre)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(tmdb.Close)
+
+	return tmdb, func(path, language string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests[path+" "+language]
+	}
+}
+
+func TestTMDBClient_Localized(t *testing.T) {
+	tmdb, requests := newFakeTMDBLocalized(t)
+	service := newTestDiscoveryService(tmdb.URL, "http://omdb.invalid")
+	client := service.tmdbClient
+
+	german := WithLocale(context.Background(), Locale{Language: "de"})
+	english := context.Background()
+
+	result, err := client.SearchMovies(german, "ding", 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	first := result.Results[0].(map[string]interface{})
+	second := result.Results[1].(map[string]interface{})
+	if first["title"] != "Das Ding" || first["overview"] != "One" || second["overview"] != "Zwei" {
+		t.Errorf("Expected German results with the missing overview in English, got %v", result.Results)
+	}
+
+	// Each language is cached separately, and the English results fetched
+	// for the fallback are reused
+	result, err = client.SearchMovies(english, "ding", 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if title := result.Results[0].(map[string]interface{})["title"]; title != "The Thing" {
+		t.Errorf("Expected the English title, got %v", title)
+	}
+	if _, err := client.SearchMovies(german, "ding", 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := requests("/search/movie", "de"); got != 1 {
+		t.Errorf("Expected 1 German search, got %d", got)
+	}
+	if got := requests("/search/movie", ""); got != 1 {
+		t.Errorf("Expected 1 English search, got %d", got)
+	}
+
+	movie, err := client.GetMovieDetails(german, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if movie.Title != "Das Ding" || movie.Overview != "One" {
+		t.Errorf("Expected the German title with the English overview, got %q %q", movie.Title, movie.Overview)
+	}
+	movie, _ = client.GetMovieDetails(english, 1)
+	if movie.Title != "The Thing" {
+		t.Errorf("Expected the English title, got %q", movie.Title)
+	}
+
+	// Genre names are localized
+	genres, err := service.genreService.GetMovieGenres(german)
+	if err != nil || genres[0].Name != "Komödie" {
+		t.Errorf("Expected German genre names, got %v, %v", genres, err)
+	}
+	genres, err = service.genreService.GetMovieGenres(english)
+	if err != nil || genres[0].Name != "Comedy" {
+		t.Errorf("Expected English genre names, got %v, %v", genres, err)
+	}
+
+	// Structured queries understand English genre names in any language
+	for _, name := range []string{"Komödie", "comedy"} {
+		if id, err := service.resolveGenre(german, "movie", name); err != nil || id != 35 {
+			t.Errorf("%s: expected genre 35, got %d, %v", name, id, err)
+		}
+	}
+	if _, err := service.resolveGenre(german, "movie", "western"); err == nil || !strings.Contains(err.Error(), "Komödie") {
+		t.Errorf("Expected the German genre names to be suggested, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"movie-discovery-app/internal/models"
+)
+
+// Match confidence recorded when merging OMDB data into TMDB results
+const (
+	// matchConfidenceIMDBID means OMDB was queried with the title's IMDb ID
+	matchConfidenceIMDBID = 1.0
+	// matchConfidenceTitleYear means OMDB returned the same title and year
+	matchConfidenceTitleYear = 0.7
+	// matchConfidenceTitle means only the title or only the year matched
+	matchConfidenceTitle = 0.5
+	// matchConfidenceWeak means OMDB returned a different title
+	matchConfidenceWeak = 0.3
+)
+
+// lookupOMDB finds the OMDB record for a TMDB movie or TV show. The IMDb ID
+// is used when known, since titles are shared by remakes and namesakes; a
+// title and year lookup is only a fallback. The returned confidence says
+// how reliable the match is.
+func (s *DiscoveryService) lookupOMDB(ctx context.Context, mediaType, imdbID, title, year string) (*OMDBResponse, float64, error) {
+	if imdbID != "" {
+		omdbData, err := s.omdbClient.GetMovieByIMDBID(ctx, imdbID)
+		if err == nil {
+			return omdbData, matchConfidenceIMDBID, nil
+		}
+		// Only an unknown ID is worth retrying by title
+		if !errors.Is(err, ErrUpstreamNotFound) {
+			return nil, 0, err
+		}
+	}
+
+	var omdbData *OMDBResponse
+	var err error
+	if mediaType == "tv" {
+		omdbData, err = s.omdbClient.GetTVShowByTitle(ctx, title, year)
+	} else {
+		omdbData, err = s.omdbClient.GetMovieByTitle(ctx, title, year)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return omdbData, titleMatchConfidence(title, year, omdbData), nil
+}
+
+// lookupSearchResultOMDB finds the OMDB record for a search result like
+// lookupOMDB, but as results are matched in bulk, a title and year fallback
+// that returns another title, or another year when both are known, is
+// rejected rather than giving a remake its original's ratings
+func (s *DiscoveryService) lookupSearchResultOMDB(ctx context.Context, mediaType, imdbID, title, year string) (*OMDBResponse, float64, error) {
+	omdbData, confidence, err := s.lookupOMDB(ctx, mediaType, imdbID, title, year)
+	if err != nil || confidence == matchConfidenceIMDBID {
+		return omdbData, confidence, err
+	}
+
+	titleMatches := strings.EqualFold(strings.TrimSpace(omdbData.Title), strings.TrimSpace(title))
+	yearConflicts := year != "" && omdbData.Year != "" && !strings.HasPrefix(omdbData.Year, year)
+	if !titleMatches || yearConflicts {
+		return nil, 0, fmt.Errorf("OMDB returned %s (%s), which may be a different title", omdbData.Title, omdbData.Year)
+	}
+
+	return omdbData, confidence, nil
+}
+
+// titleMatchConfidence scores a title and year lookup by comparing what OMDB
+// returned with what was asked for
+func titleMatchConfidence(title, year string, omdbData *OMDBResponse) float64 {
+	titleMatches := strings.EqualFold(strings.TrimSpace(omdbData.Title), strings.TrimSpace(title))
+	// OMDB years for series are ranges such as "2008–2013"
+	yearMatches := year != "" && strings.HasPrefix(omdbData.Year, year)
+
+	switch {
+	case titleMatches && yearMatches:
+		return matchConfidenceTitleYear
+	case titleMatches || yearMatches:
+		return matchConfidenceTitle
+	default:
+		return matchConfidenceWeak
+	}
+}
+
+// externalIMDBID returns the IMDb ID TMDB has for a movie or TV show, or an
+// empty string when it has none or the lookup fails
+func (s *DiscoveryService) externalIMDBID(ctx context.Context, mediaType string, id int) string {
+	var externalIDs *models.ExternalIDs
+	var err error
+	if mediaType == "tv" {
+		externalIDs, err = s.tmdbClient.GetTVExternalIDs(ctx, id)
+	} else {
+		externalIDs, err = s.tmdbClient.GetMovieExternalIDs(ctx, id)
+	}
+	if err != nil {
+		log.Printf("Failed to get external IDs for %s %d: %v", mediaType, id, err)
+		return ""
+	}
+
+	return externalIDs.IMDBID
+}
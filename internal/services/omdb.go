@@ -1,22 +1,37 @@
 package services
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"math"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
 )
 
 // OMDBClient handles OMDB API interactions
 type OMDBClient struct {
-	config      *configs.OMDBConfig
-	httpClient  *http.Client
-	cache       *Cache
-	rateLimiter *RateLimiter
+	config   *configs.OMDBConfig
+	cache    *Cache
+	upstream *upstreamClient
+}
+
+// Rating sources as named by OMDB
+const (
+	RatingSourceIMDb           = "Internet Movie Database"
+	RatingSourceRottenTomatoes = "Rotten Tomatoes"
+	RatingSourceMetacritic     = "Metacritic"
+)
+
+// OMDBRating represents one entry of the OMDB ratings array
+type OMDBRating struct {
+	Source string `json:"Source"`
+	Value  string `json:"Value"`
 }
 
 // OMDBResponse represents the response from OMDB API
@@ -35,40 +50,56 @@ type OMDBResponse struct {
 	Country    string `json:"Country"`
 	Awards     string `json:"Awards"`
 	Poster     string `json:"Poster"`
-	Ratings    []struct {
-		Source string `json:"Source"`
-		Value  string `json:"Value"`
-	} `json:"Ratings"`
+	Ratings    []OMDBRating `json:"Ratings"`
 	Metascore    string `json:"Metascore"`
 	IMDBRating   string `json:"imdbRating"`
 	IMDBVotes    string `json:"imdbVotes"`
 	IMDBID       string `json:"imdbID"`
 	Type         string `json:"Type"`
 	TotalSeasons string `json:"totalSeasons,omitempty"`
+	BoxOffice    string `json:"BoxOffice,omitempty"`
 	Response     string `json:"Response"`
 	Error        string `json:"Error,omitempty"`
 }
 
 // NewOMDBClient creates a new OMDB API client
-func NewOMDBClient(config *configs.OMDBConfig, cacheConfig *configs.CacheConfig, rateConfig *configs.RateLimitConfig) *OMDBClient {
+func NewOMDBClient(config *configs.OMDBConfig, cacheConfig *configs.CacheConfig, rateConfig *configs.RateLimitConfig, retryConfig *configs.RetryConfig, breakerConfig *configs.BreakerConfig) *OMDBClient {
 	return &OMDBClient{
 		config: config,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
 		cache: &Cache{
 			data: make(map[string]CacheItem),
 		},
-		rateLimiter: &RateLimiter{
-			requests: make(chan time.Time, rateConfig.RequestsPerMinute),
-			limit:    rateConfig.RequestsPerMinute,
-			window:   time.Minute,
-		},
+		upstream: newUpstreamClient("OMDB", 30*time.Second, sharedRateLimiter("omdb", rateConfig), sharedCircuitBreaker("omdb", breakerConfig), newRetryPolicy(retryConfig)),
 	}
 }
 
+// Available reports whether OMDB calls are currently admitted by its circuit breaker
+func (c *OMDBClient) Available() bool {
+	return c.upstream.Available()
+}
+
+// get queries the OMDB API and decodes the JSON response into out
+func (c *OMDBClient) get(ctx context.Context, params url.Values, out interface{}) error {
+	params.Set("apikey", c.config.APIKey)
+
+	requestURL := fmt.Sprintf("%s?%s", c.config.BaseURL, params.Encode())
+	return c.upstream.getJSON(ctx, requestURL, out)
+}
+
+// omdbError converts an OMDB "Response": "False" payload into an UpstreamError.
+// OMDB reports missing titles with a 200 status and an error message, such as
+// "Movie not found!" for titles and "Incorrect IMDb ID." for unknown IDs.
+func omdbError(message string) error {
+	err := &UpstreamError{Service: "OMDB", Err: errors.New(message)}
+	lower := strings.ToLower(message)
+	if strings.Contains(lower, "not found") || strings.Contains(lower, "incorrect imdb id") {
+		err.Kind = ErrUpstreamNotFound
+	}
+	return err
+}
+
 // GetMovieByTitle gets movie details from OMDB by title
-func (c *OMDBClient) GetMovieByTitle(title string, year string) (*OMDBResponse, error) {
+func (c *OMDBClient) GetMovieByTitle(ctx context.Context, title string, year string) (*OMDBResponse, error) {
 	cacheKey := fmt.Sprintf("omdb_title_%s_%s", title, year)
 	
 	// Check cache first
@@ -78,14 +109,8 @@ func (c *OMDBClient) GetMovieByTitle(title string, year string) (*OMDBResponse,
 		}
 	}
 
-	// Rate limiting
-	if err := c.rateLimiter.Wait(); err != nil {
-		return nil, err
-	}
-
-	// Build URL
+	// Build query parameters
 	params := url.Values{}
-	params.Add("apikey", c.config.APIKey)
 	params.Add("t", title)
 	params.Add("type", "movie")
 	params.Add("plot", "full")
@@ -93,32 +118,14 @@ func (c *OMDBClient) GetMovieByTitle(title string, year string) (*OMDBResponse,
 		params.Add("y", year)
 	}
 
-	requestURL := fmt.Sprintf("%s?%s", c.config.BaseURL, params.Encode())
-
-	// Make request
-	resp, err := c.httpClient.Get(requestURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get movie from OMDB: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("OMDB API error: %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
 	var omdbResp OMDBResponse
-	if err := json.Unmarshal(body, &omdbResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := c.get(ctx, params, &omdbResp); err != nil {
+		return nil, fmt.Errorf("failed to get movie from OMDB: %w", err)
 	}
 
 	// Check if the response contains an error
 	if omdbResp.Response == "False" {
-		return nil, fmt.Errorf("OMDB error: %s", omdbResp.Error)
+		return nil, omdbError(omdbResp.Error)
 	}
 
 	// Cache the result
@@ -127,8 +134,8 @@ func (c *OMDBClient) GetMovieByTitle(title string, year string) (*OMDBResponse,
 	return &omdbResp, nil
 }
 
-// GetMovieByIMDBID gets movie details from OMDB by IMDB ID
-func (c *OMDBClient) GetMovieByIMDBID(imdbID string) (*OMDBResponse, error) {
+// GetMovieByIMDBID gets movie or TV show details from OMDB by IMDB ID
+func (c *OMDBClient) GetMovieByIMDBID(ctx context.Context, imdbID string) (*OMDBResponse, error) {
 	cacheKey := fmt.Sprintf("omdb_imdb_%s", imdbID)
 	
 	// Check cache first
@@ -138,43 +145,19 @@ func (c *OMDBClient) GetMovieByIMDBID(imdbID string) (*OMDBResponse, error) {
 		}
 	}
 
-	// Rate limiting
-	if err := c.rateLimiter.Wait(); err != nil {
-		return nil, err
-	}
-
-	// Build URL
+	// Build query parameters
 	params := url.Values{}
-	params.Add("apikey", c.config.APIKey)
 	params.Add("i", imdbID)
 	params.Add("plot", "full")
 
-	requestURL := fmt.Sprintf("%s?%s", c.config.BaseURL, params.Encode())
-
-	// Make request
-	resp, err := c.httpClient.Get(requestURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get movie from OMDB: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("OMDB API error: %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
 	var omdbResp OMDBResponse
-	if err := json.Unmarshal(body, &omdbResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := c.get(ctx, params, &omdbResp); err != nil {
+		return nil, fmt.Errorf("failed to get movie from OMDB: %w", err)
 	}
 
 	// Check if the response contains an error
 	if omdbResp.Response == "False" {
-		return nil, fmt.Errorf("OMDB error: %s", omdbResp.Error)
+		return nil, omdbError(omdbResp.Error)
 	}
 
 	// Cache the result
@@ -184,7 +167,7 @@ func (c *OMDBClient) GetMovieByIMDBID(imdbID string) (*OMDBResponse, error) {
 }
 
 // GetTVShowByTitle gets TV show details from OMDB by title
-func (c *OMDBClient) GetTVShowByTitle(title string, year string) (*OMDBResponse, error) {
+func (c *OMDBClient) GetTVShowByTitle(ctx context.Context, title string, year string) (*OMDBResponse, error) {
 	cacheKey := fmt.Sprintf("omdb_tv_%s_%s", title, year)
 	
 	// Check cache first
@@ -194,14 +177,8 @@ func (c *OMDBClient) GetTVShowByTitle(title string, year string) (*OMDBResponse,
 		}
 	}
 
-	// Rate limiting
-	if err := c.rateLimiter.Wait(); err != nil {
-		return nil, err
-	}
-
-	// Build URL
+	// Build query parameters
 	params := url.Values{}
-	params.Add("apikey", c.config.APIKey)
 	params.Add("t", title)
 	params.Add("type", "series")
 	params.Add("plot", "full")
@@ -209,32 +186,14 @@ func (c *OMDBClient) GetTVShowByTitle(title string, year string) (*OMDBResponse,
 		params.Add("y", year)
 	}
 
-	requestURL := fmt.Sprintf("%s?%s", c.config.BaseURL, params.Encode())
-
-	// Make request
-	resp, err := c.httpClient.Get(requestURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get TV show from OMDB: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("OMDB API error: %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
 	var omdbResp OMDBResponse
-	if err := json.Unmarshal(body, &omdbResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := c.get(ctx, params, &omdbResp); err != nil {
+		return nil, fmt.Errorf("failed to get TV show from OMDB: %w", err)
 	}
 
 	// Check if the response contains an error
 	if omdbResp.Response == "False" {
-		return nil, fmt.Errorf("OMDB error: %s", omdbResp.Error)
+		return nil, omdbError(omdbResp.Error)
 	}
 
 	// Cache the result
@@ -244,7 +203,7 @@ func (c *OMDBClient) GetTVShowByTitle(title string, year string) (*OMDBResponse,
 }
 
 // SearchMovies searches for movies by title
-func (c *OMDBClient) SearchMovies(title string, page int) (*OMDBSearchResponse, error) {
+func (c *OMDBClient) SearchMovies(ctx context.Context, title string, page int) (*OMDBSearchResponse, error) {
 	cacheKey := fmt.Sprintf("omdb_search_%s_%d", title, page)
 	
 	// Check cache first
@@ -254,46 +213,22 @@ func (c *OMDBClient) SearchMovies(title string, page int) (*OMDBSearchResponse,
 		}
 	}
 
-	// Rate limiting
-	if err := c.rateLimiter.Wait(); err != nil {
-		return nil, err
-	}
-
-	// Build URL
+	// Build query parameters
 	params := url.Values{}
-	params.Add("apikey", c.config.APIKey)
 	params.Add("s", title)
 	params.Add("type", "movie")
 	if page > 1 {
 		params.Add("page", fmt.Sprintf("%d", page))
 	}
 
-	requestURL := fmt.Sprintf("%s?%s", c.config.BaseURL, params.Encode())
-
-	// Make request
-	resp, err := c.httpClient.Get(requestURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search movies in OMDB: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("OMDB API error: %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
 	var searchResp OMDBSearchResponse
-	if err := json.Unmarshal(body, &searchResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := c.get(ctx, params, &searchResp); err != nil {
+		return nil, fmt.Errorf("failed to search movies in OMDB: %w", err)
 	}
 
 	// Check if the response contains an error
 	if searchResp.Response == "False" {
-		return nil, fmt.Errorf("OMDB error: %s", searchResp.Error)
+		return nil, omdbError(searchResp.Error)
 	}
 
 	// Cache the result
@@ -319,9 +254,111 @@ type OMDBSearchResponse struct {
 // GetRottenTomatoesRating extracts Rotten Tomatoes rating from ratings array
 func (r *OMDBResponse) GetRottenTomatoesRating() string {
 	for _, rating := range r.Ratings {
-		if rating.Source == "Rotten Tomatoes" {
+		if rating.Source == RatingSourceRottenTomatoes {
 			return rating.Value
 		}
 	}
 	return ""
 }
+
+// GetRatings returns every rating OMDB reported, normalized to 0-100. IMDb
+// and Metacritic are taken from their dedicated fields when the ratings
+// array leaves them out, and IMDb carries its vote count.
+func (r *OMDBResponse) GetRatings() []models.Rating {
+	ratings := make([]models.Rating, 0, len(r.Ratings)+2)
+	seen := make(map[string]bool)
+
+	add := func(source, value string) {
+		score, ok := parseRatingValue(value)
+		if !ok || seen[source] {
+			return
+		}
+		seen[source] = true
+
+		rating := models.Rating{Source: source, Value: value, Score: score}
+		if source == RatingSourceIMDb {
+			rating.Votes = parseVoteCount(r.IMDBVotes)
+		}
+		ratings = append(ratings, rating)
+	}
+
+	for _, rating := range r.Ratings {
+		add(rating.Source, rating.Value)
+	}
+	if r.IMDBRating != "" && r.IMDBRating != "N/A" {
+		add(RatingSourceIMDb, r.IMDBRating+"/10")
+	}
+	if r.Metascore != "" && r.Metascore != "N/A" {
+		add(RatingSourceMetacritic, r.Metascore+"/100")
+	}
+
+	return ratings
+}
+
+// GetDetails returns the structured ratings, certification, box office and
+// poster from an OMDB response, leaving out fields OMDB reported as "N/A"
+func (r *OMDBResponse) GetDetails() models.OMDBDetails {
+	metascore, _ := strconv.Atoi(r.Metascore)
+
+	return models.OMDBDetails{
+		Ratings:           r.GetRatings(),
+		IMDBVotes:         parseVoteCount(r.IMDBVotes),
+		Metascore:         metascore,
+		Certification:     omdbValue(r.Rated),
+		Released:          omdbValue(r.Released),
+		BoxOffice:         omdbValue(r.BoxOffice),
+		FallbackPosterURL: omdbValue(r.Poster),
+	}
+}
+
+// parseRatingValue converts an OMDB rating such as "7.5/10", "85%" or
+// "74/100" to a 0-100 score. It reports false for "N/A" and anything else
+// it can't read.
+func parseRatingValue(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "N/A" {
+		return 0, false
+	}
+
+	if percent, found := strings.CutSuffix(value, "%"); found {
+		score, err := strconv.ParseFloat(percent, 64)
+		if err != nil || score < 0 || score > 100 {
+			return 0, false
+		}
+		return score, true
+	}
+
+	numerator, denominator, found := strings.Cut(value, "/")
+	if !found {
+		return 0, false
+	}
+	score, err := strconv.ParseFloat(strings.TrimSpace(numerator), 64)
+	if err != nil {
+		return 0, false
+	}
+	scale, err := strconv.ParseFloat(strings.TrimSpace(denominator), 64)
+	if err != nil || scale <= 0 || score < 0 || score > scale {
+		return 0, false
+	}
+
+	// Round to one decimal place to hide float noise such as 88.00000000000001
+	return math.Round(score/scale*1000) / 10, true
+}
+
+// parseVoteCount parses an OMDB vote count such as "1,234,567", returning
+// 0 for "N/A"
+func parseVoteCount(value string) int {
+	votes, err := strconv.Atoi(strings.ReplaceAll(strings.TrimSpace(value), ",", ""))
+	if err != nil {
+		return 0
+	}
+	return votes
+}
+
+// omdbValue returns value, or an empty string when OMDB reported "N/A"
+func omdbValue(value string) string {
+	if value == "N/A" {
+		return ""
+	}
+	return value
+}
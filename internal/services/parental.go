@@ -0,0 +1,504 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
+)
+
+// Policies for titles without a known certification
+const (
+	UnknownCertificationsStrict  = "strict"  // Block them
+	UnknownCertificationsLenient = "lenient" // Allow them
+)
+
+// parentalLookupConcurrency bounds the certification lookups made while
+// filtering a page of results
+const parentalLookupConcurrency = 5
+
+// ErrTitleBlocked is returned for titles a profile's parental controls don't
+// allow. It is wrapped with the reason.
+var ErrTitleBlocked = errors.New("blocked by parental controls")
+
+// certificationLadders lists the certifications of each country from least
+// to most restrictive, for movies and for TV shows
+var certificationLadders = map[string]map[string][]string{
+	"movie": {
+		"AU": {"E", "G", "PG", "M", "MA15+", "R18+", "X18+"},
+		"CA": {"G", "PG", "14A", "18A", "R", "A"},
+		"DE": {"0", "6", "12", "16", "18"},
+		"FR": {"U", "10", "12", "16", "18"},
+		"GB": {"U", "PG", "12A", "12", "15", "18", "R18"},
+		"US": {"G", "PG", "PG-13", "R", "NC-17"},
+	},
+	"tv": {
+		"AU": {"P", "C", "G", "PG", "M", "MA15+", "AV15+", "R18+"},
+		"CA": {"C", "C8", "G", "PG", "14+", "18+"},
+		"DE": {"0", "6", "12", "16", "18"},
+		"FR": {"10", "12", "16", "18"},
+		"GB": {"U", "PG", "12", "15", "18"},
+		"US": {"TV-Y", "TV-Y7", "TV-G", "TV-PG", "TV-14", "TV-MA"},
+	},
+}
+
+// omdbCertificationCountry is the country whose certifications OMDB reports
+const omdbCertificationCountry = "US"
+
+// ParentalControlService keeps each profile's certification limits and checks
+// titles against them, using TMDB release dates and content ratings and
+// falling back to OMDB's rating for the US
+type ParentalControlService struct {
+	tmdbClient    *TMDBClient
+	defaultPolicy string // For profiles that don't choose a policy
+
+	mu       sync.Mutex
+	controls map[string]models.ParentalControls // userID -> controls
+}
+
+// NewParentalControlService creates a new parental control service
+func NewParentalControlService(config *configs.Config, discoveryService *DiscoveryService) *ParentalControlService {
+	policy := config.Parental.UnknownCertifications
+	if policy != UnknownCertificationsLenient {
+		policy = UnknownCertificationsStrict
+	}
+
+	return &ParentalControlService{
+		tmdbClient:    discoveryService.tmdbClient,
+		defaultPolicy: policy,
+		controls:      make(map[string]models.ParentalControls),
+	}
+}
+
+// ParseParentalControls validates parental controls, normalizing country
+// codes and certifications. An empty policy is left for the server default.
+func ParseParentalControls(controls models.ParentalControls) (models.ParentalControls, error) {
+	movieCertifications, err := parseCertificationLimits("movie", controls.MovieCertifications)
+	if err != nil {
+		return controls, err
+	}
+	tvCertifications, err := parseCertificationLimits("tv", controls.TVCertifications)
+	if err != nil {
+		return controls, err
+	}
+
+	policy := strings.ToLower(strings.TrimSpace(controls.UnknownCertifications))
+	if policy != "" && policy != UnknownCertificationsStrict && policy != UnknownCertificationsLenient {
+		return controls, fmt.Errorf("unknown_certifications must be %s or %s", UnknownCertificationsStrict, UnknownCertificationsLenient)
+	}
+
+	return models.ParentalControls{
+		MovieCertifications:   movieCertifications,
+		TVCertifications:      tvCertifications,
+		UnknownCertifications: policy,
+	}, nil
+}
+
+// parseCertificationLimits validates the highest allowed certification for
+// each country
+func parseCertificationLimits(mediaType string, limits map[string]string) (map[string]string, error) {
+	ladders := certificationLadders[mediaType]
+	label := mediaTypeLabel(mediaType)
+
+	parsed := make(map[string]string, len(limits))
+	for country, certification := range limits {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		ladder, ok := ladders[country]
+		if !ok {
+			return nil, fmt.Errorf("%s certifications are supported for %s, not %q", label, strings.Join(certificationCountries(mediaType), ", "), country)
+		}
+		rank := certificationRank(ladder, certification)
+		if rank < 0 {
+			return nil, fmt.Errorf("%q is not a %s %s certification (one of %s)", certification, country, label, strings.Join(ladder, ", "))
+		}
+		parsed[country] = ladder[rank]
+	}
+	return parsed, nil
+}
+
+// GetControls gets a profile's parental controls, with the policy that
+// applies to them
+func (s *ParentalControlService) GetControls(userID string) models.ParentalControls {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	controls := s.controls[userID]
+	if controls.MovieCertifications == nil {
+		controls.MovieCertifications = map[string]string{}
+	}
+	if controls.TVCertifications == nil {
+		controls.TVCertifications = map[string]string{}
+	}
+	if controls.UnknownCertifications == "" {
+		controls.UnknownCertifications = s.defaultPolicy
+	}
+	return controls
+}
+
+// SetControls replaces a profile's parental controls, which should have been
+// validated with ParseParentalControls. Controls without limits turn
+// filtering off.
+func (s *ParentalControlService) SetControls(userID string, controls models.ParentalControls) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !controls.Enabled() {
+		delete(s.controls, userID)
+		return
+	}
+	s.controls[userID] = controls
+}
+
+// controlsFor returns a profile's controls and whether they limit titles of
+// mediaType. Media types without limits aren't filtered.
+func (s *ParentalControlService) controlsFor(userID, mediaType string) (models.ParentalControls, bool) {
+	controls := s.GetControls(userID)
+	return controls, len(certificationLimits(controls, mediaType)) > 0
+}
+
+// FilterResults removes the search, trending or discover results of
+// mediaType ("movie" or "tv") that a profile may not see. The results are
+// not modified. A failed certification lookup fails the whole filter rather
+// than hiding the title as unrated.
+func (s *ParentalControlService) FilterResults(ctx context.Context, userID, mediaType string, results []interface{}) ([]interface{}, error) {
+	controls, enabled := s.controlsFor(userID, mediaType)
+	if !enabled {
+		return results, nil
+	}
+
+	// Stop the remaining lookups once one fails
+	lookupCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var lookupErr error
+	allowed := make([]bool, len(results))
+	forEachConcurrently(len(results), parentalLookupConcurrency, func(i int) {
+		item, ok := results[i].(map[string]interface{})
+		if !ok {
+			return
+		}
+		omdbCertification, _ := item["certification"].(string)
+		id, ok := item["id"].(float64)
+		if !ok {
+			// Titles only OMDB knows have no TMDB certifications
+			allowed[i] = checkCertifications(controls, mediaType, nil, omdbCertification) == nil
+			return
+		}
+
+		certifications, err := s.certifications(lookupCtx, mediaType, int(id))
+		if err != nil {
+			mu.Lock()
+			if lookupErr == nil {
+				lookupErr = err
+				cancel()
+			}
+			mu.Unlock()
+			return
+		}
+		allowed[i] = checkCertifications(controls, mediaType, certifications, omdbCertification) == nil
+	})
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if lookupErr != nil {
+		return nil, fmt.Errorf("failed to check parental controls: %w", lookupErr)
+	}
+
+	filtered := make([]interface{}, 0, len(results))
+	for i, result := range results {
+		if allowed[i] {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered, nil
+}
+
+// allowedTitles checks titles, given as IDs per media type ("movie" or
+// "tv"), the same way FilterResults does. It returns the watchlistKey of
+// each title the profile may see.
+func (s *ParentalControlService) allowedTitles(ctx context.Context, userID string, ids map[string][]int) (map[string]bool, error) {
+	allowed := make(map[string]bool)
+	for mediaType, mediaIDs := range ids {
+		results := make([]interface{}, 0, len(mediaIDs))
+		for _, id := range mediaIDs {
+			results = append(results, map[string]interface{}{"id": float64(id)})
+		}
+
+		filtered, err := s.FilterResults(ctx, userID, mediaType, results)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range filtered {
+			allowed[watchlistKey(mediaType, int(result.(map[string]interface{})["id"].(float64)))] = true
+		}
+	}
+	return allowed, nil
+}
+
+// FilterSuggestions removes the movie and TV show autocomplete suggestions a
+// profile may not see, checking them the same way FilterResults does until
+// ctx is done. A title still unchecked by then is treated as unrated, so the
+// profile's unknown certification policy decides, and complete is false.
+// People are kept.
+func (s *ParentalControlService) FilterSuggestions(ctx context.Context, userID string, suggestions []models.Suggestion) ([]models.Suggestion, bool, error) {
+	var mu sync.Mutex
+	var lookupErr error
+	complete := true
+	allowed := make([]bool, len(suggestions))
+	forEachConcurrently(len(suggestions), parentalLookupConcurrency, func(i int) {
+		suggestion := suggestions[i]
+		if suggestion.MediaType != "movie" && suggestion.MediaType != "tv" {
+			allowed[i] = true
+			return
+		}
+		controls, enabled := s.controlsFor(userID, suggestion.MediaType)
+		if !enabled {
+			allowed[i] = true
+			return
+		}
+
+		// Cached certifications are still found once ctx is done
+		certifications, err := s.certifications(ctx, suggestion.MediaType, suggestion.ID)
+		if err != nil && ctx.Err() == nil {
+			mu.Lock()
+			if lookupErr == nil {
+				lookupErr = err
+			}
+			mu.Unlock()
+			return
+		}
+		if err != nil {
+			// Out of time: the title counts as unrated
+			mu.Lock()
+			complete = false
+			mu.Unlock()
+		}
+		allowed[i] = checkCertifications(controls, suggestion.MediaType, certifications, "") == nil
+	})
+
+	if lookupErr != nil {
+		return nil, false, fmt.Errorf("failed to check parental controls: %w", lookupErr)
+	}
+
+	kept := make([]models.Suggestion, 0, len(suggestions))
+	for i, suggestion := range suggestions {
+		if allowed[i] {
+			kept = append(kept, suggestion)
+		}
+	}
+	return kept, complete, nil
+}
+
+// FilterSavedSearchMatches removes the saved search matches a profile may
+// not see, the same way FilterResults does
+func (s *ParentalControlService) FilterSavedSearchMatches(ctx context.Context, userID string, matches []models.SavedSearchMatch) ([]models.SavedSearchMatch, error) {
+	ids := make(map[string][]int)
+	for _, match := range matches {
+		ids[match.MediaType] = append(ids[match.MediaType], match.ID)
+	}
+
+	allowed, err := s.allowedTitles(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := make([]models.SavedSearchMatch, 0, len(matches))
+	for _, match := range matches {
+		if allowed[watchlistKey(match.MediaType, match.ID)] {
+			kept = append(kept, match)
+		}
+	}
+	return kept, nil
+}
+
+// CheckMovie returns an error wrapping ErrTitleBlocked if a profile may not
+// see a movie
+func (s *ParentalControlService) CheckMovie(ctx context.Context, userID string, movie *models.Movie) error {
+	controls, enabled := s.controlsFor(userID, "movie")
+	if !enabled {
+		return nil
+	}
+
+	certifications := releaseCertifications(movie.ReleaseDates)
+	if movie.ReleaseDates == nil {
+		var err error
+		if certifications, err = s.certifications(ctx, "movie", movie.ID); err != nil {
+			return fmt.Errorf("failed to check parental controls: %w", err)
+		}
+	}
+	return checkCertifications(controls, "movie", certifications, movie.Certification)
+}
+
+// CheckTVShow returns an error wrapping ErrTitleBlocked if a profile may not
+// see a TV show
+func (s *ParentalControlService) CheckTVShow(ctx context.Context, userID string, tvShow *models.TVShow) error {
+	controls, enabled := s.controlsFor(userID, "tv")
+	if !enabled {
+		return nil
+	}
+
+	certifications := contentRatingCertifications(tvShow.ContentRatings)
+	if tvShow.ContentRatings == nil {
+		var err error
+		if certifications, err = s.certifications(ctx, "tv", tvShow.ID); err != nil {
+			return fmt.Errorf("failed to check parental controls: %w", err)
+		}
+	}
+	return checkCertifications(controls, "tv", certifications, tvShow.Certification)
+}
+
+// certifications fetches a title's TMDB certification in each country. A
+// title TMDB doesn't know has no certifications; other failures are returned.
+func (s *ParentalControlService) certifications(ctx context.Context, mediaType string, id int) (map[string]string, error) {
+	cacheKey := fmt.Sprintf("certifications_%s_%d", mediaType, id)
+
+	// Check cache first
+	if cached := s.tmdbClient.cache.Get(cacheKey); cached != nil {
+		if certifications, ok := cached.(map[string]string); ok {
+			return certifications, nil
+		}
+	}
+
+	var certifications map[string]string
+	if mediaType == "tv" {
+		contentRatings, err := s.tmdbClient.GetTVContentRatings(ctx, id)
+		if err != nil && !errors.Is(err, ErrUpstreamNotFound) {
+			return nil, err
+		}
+		certifications = contentRatingCertifications(contentRatings)
+	} else {
+		releaseDates, err := s.tmdbClient.GetMovieReleaseDates(ctx, id)
+		if err != nil && !errors.Is(err, ErrUpstreamNotFound) {
+			return nil, err
+		}
+		certifications = releaseCertifications(releaseDates)
+	}
+
+	// Cache the result; certifications rarely change
+	s.tmdbClient.cache.Set(cacheKey, certifications, 24*time.Hour)
+
+	return certifications, nil
+}
+
+// releaseCertifications returns a movie's certification in each country.
+// When releases in a country are rated differently, the most restrictive
+// known certification counts.
+func releaseCertifications(releaseDates *models.ReleaseDates) map[string]string {
+	if releaseDates == nil {
+		return nil
+	}
+
+	certifications := make(map[string]string)
+	for _, country := range releaseDates.Results {
+		ladder := certificationLadders["movie"][country.ISO3166_1]
+		for _, release := range country.ReleaseDates {
+			if certificationRank(ladder, release.Certification) > certificationRank(ladder, certifications[country.ISO3166_1]) {
+				certifications[country.ISO3166_1] = release.Certification
+			}
+		}
+	}
+	return certifications
+}
+
+// contentRatingCertifications returns a TV show's rating in each country
+func contentRatingCertifications(contentRatings *models.ContentRatings) map[string]string {
+	if contentRatings == nil {
+		return nil
+	}
+
+	certifications := make(map[string]string)
+	for _, rating := range contentRatings.Results {
+		certifications[rating.ISO3166_1] = rating.Rating
+	}
+	return certifications
+}
+
+// certificationLimits returns the limits for a media type
+func certificationLimits(controls models.ParentalControls, mediaType string) map[string]string {
+	if mediaType == "tv" {
+		return controls.TVCertifications
+	}
+	return controls.MovieCertifications
+}
+
+// checkCertifications checks a title's certifications against the limits
+// for its media type; without limits for it every title is allowed. A title
+// is blocked if it is rated above the limit in any country with one. If none
+// of those countries has a known certification for it, the profile's
+// unknown certification policy decides; OMDB's rating stands in for a
+// missing US certification.
+func checkCertifications(controls models.ParentalControls, mediaType string, certifications map[string]string, omdbCertification string) error {
+	limits := certificationLimits(controls, mediaType)
+	countries := sortedKeys(limits)
+	known := false
+	for _, country := range countries {
+		ladder := certificationLadders[mediaType][country]
+		certification := certifications[country]
+		if certificationRank(ladder, certification) < 0 && country == omdbCertificationCountry {
+			certification = omdbCertification
+		}
+
+		rank := certificationRank(ladder, certification)
+		if rank < 0 {
+			continue
+		}
+		known = true
+		if rank > certificationRank(ladder, limits[country]) {
+			return fmt.Errorf("%w: rated %s in %s, above the %s limit", ErrTitleBlocked, ladder[rank], country, limits[country])
+		}
+	}
+
+	if known || len(countries) == 0 || controls.UnknownCertifications == UnknownCertificationsLenient {
+		return nil
+	}
+	return fmt.Errorf("%w: not rated in %s and unrated titles are blocked", ErrTitleBlocked, strings.Join(countries, " or "))
+}
+
+// certificationRank returns the position of a certification on a country's
+// ladder, or -1 if it isn't on it, e.g. NR or an empty certification
+func certificationRank(ladder []string, certification string) int {
+	certification = strings.TrimSpace(certification)
+	for i, rung := range ladder {
+		if strings.EqualFold(rung, certification) {
+			return i
+		}
+	}
+	return -1
+}
+
+// mediaTypeLabel names a media type in messages
+func mediaTypeLabel(mediaType string) string {
+	if mediaType == "tv" {
+		return "TV"
+	}
+	return "movie"
+}
+
+// certificationCountries returns the countries with known certifications
+// for a media type, in order
+func certificationCountries(mediaType string) []string {
+	countries := make([]string, 0, len(certificationLadders[mediaType]))
+	for country := range certificationLadders[mediaType] {
+		countries = append(countries, country)
+	}
+	sort.Strings(countries)
+	return countries
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
+)
+
+func TestParseParentalControls(t *testing.T) {
+	controls, err := ParseParentalControls(models.ParentalControls{
+		MovieCertifications:   map[string]string{"us": "pg-13", " GB ": "12a"},
+		TVCertifications:      map[string]string{"US": "tv-y7"},
+		UnknownCertifications: " Lenient",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := models.ParentalControls{
+		MovieCertifications:   map[string]string{"US": "PG-13", "GB": "12A"},
+		TVCertifications:      map[string]string{"US": "TV-Y7"},
+		UnknownCertifications: "lenient",
+	}
+	if fmt.Sprint(controls) != fmt.Sprint(want) {
+		t.Errorf("Expected %+v, got %+v", want, controls)
+	}
+
+	for _, invalid := range []models.ParentalControls{
+		{MovieCertifications: map[string]string{"US": "TV-MA"}},
+		{TVCertifications: map[string]string{"US": "PG-13"}},
+		{MovieCertifications: map[string]string{"USA": "PG"}},
+		{MovieCertifications: map[string]string{"JP": "G"}},
+		{UnknownCertifications: "sometimes"},
+	} {
+		if _, err := ParseParentalControls(invalid); err == nil {
+			t.Errorf("%+v: expected an error", invalid)
+		}
+	}
+}
+
+func TestCheckCertifications(t *testing.T) {
+	strict := models.ParentalControls{
+		MovieCertifications:   map[string]string{"US": "PG-13", "GB": "12A"},
+		UnknownCertifications: UnknownCertificationsStrict,
+	}
+	lenient := strict
+	lenient.UnknownCertifications = UnknownCertificationsLenient
+
+	tests := []struct {
+		name           string
+		controls       models.ParentalControls
+		mediaType      string
+		certifications map[string]string
+		omdb           string
+		reason         string // Empty when allowed
+	}{
+		{"within the limit", strict, "movie", map[string]string{"US": "PG", "GB": "PG"}, "", ""},
+		{"above the limit", strict, "movie", map[string]string{"US": "R"}, "", "rated R in US, above the PG-13 limit"},
+		{"above one country's limit", strict, "movie", map[string]string{"US": "PG-13", "GB": "15"}, "", "rated 15 in GB, above the 12A limit"},
+		{"only rated elsewhere", strict, "movie", map[string]string{"FR": "12"}, "", "not rated in GB or US"},
+		{"unrated and lenient", lenient, "movie", map[string]string{"US": "NR"}, "", ""},
+		{"OMDB rating", strict, "movie", map[string]string{"US": "NR"}, "PG", ""},
+		{"OMDB rating above the limit", lenient, "movie", nil, "R", "rated R in US, above the PG-13 limit"},
+		{"no TV limits", strict, "tv", map[string]string{"US": "TV-MA"}, "", ""},
+		{"no TV limits and unrated", strict, "tv", nil, "", ""},
+	}
+	for _, tt := range tests {
+		err := checkCertifications(tt.controls, tt.mediaType, tt.certifications, tt.omdb)
+		if tt.reason == "" {
+			if err != nil {
+				t.Errorf("%s: expected the title to be allowed, got %v", tt.name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrTitleBlocked) || !strings.Contains(err.Error(), tt.reason) {
+			t.Errorf("%s: expected the title to be blocked with %q, got %v", tt.name, tt.reason, err)
+		}
+	}
+}
+
+// newFakeTMDBCertifications serves release dates for movies 1 (PG, with an
+// R-rated re-release) and 2 (G), and content ratings for TV shows 3 (TV-Y)
+// and 4 (TV-MA), counting the requests. Movie 500's release dates fail and
+// movie 600's never arrive.
+func newFakeTMDBCertifications(t *testing.T) (*httptest.Server, *int32) {
+	var requests int32
+
+	tmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		switch r.URL.Path {
+		case "/movie/1/release_dates":
+			fmt.Fprint(w, `{"results": [{"iso_3166_1": "US", "release_dates": [{"certification": "PG", "type": 3}, {"certification": "R", "type": 5}]}]}`)
+		case "/movie/2/release_dates":
+			fmt.Fprint(w, `{"results": [{"iso_3166_1": "US", "release_dates": [{"certification": "G", "type": 3}, {"certification": "", "type": 4}]}]}`)
+		case "/tv/3/content_ratings":
+			fmt.Fprint(w, `{"results": [{"iso_3166_1": "US", "rating": "TV-Y"}]}`)
+		case "/tv/4/content_ratings":
+			fmt.Fprint(w, `{"results": [{"iso_3166_1": "US", "rating": "TV-MA"}]}`)
+		case "/movie/500/release_dates":
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		case "/movie/600/release_dates":
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(tmdb.Close)
+
+	return tmdb, &requests
+}
+
+// newTestParentalService creates a parental control service that looks up
+// certifications on newFakeTMDBCertifications
+func newTestParentalService(t *testing.T) *ParentalControlService {
+	tmdb, _ := newFakeTMDBCertifications(t)
+	return NewParentalControlService(&configs.Config{}, newTestDiscoveryService(tmdb.URL, "http://omdb.invalid"))
+}
+
+// kidControls limit movies to PG and TV shows to TV-Y7 in the US, allowing
+// unrated titles
+var kidControls = models.ParentalControls{
+	MovieCertifications:   map[string]string{"US": "PG"},
+	TVCertifications:      map[string]string{"US": "TV-Y7"},
+	UnknownCertifications: UnknownCertificationsLenient,
+}
+
+func TestParentalControlService_Filter(t *testing.T) {
+	tmdb, requests := newFakeTMDBCertifications(t)
+	service := NewParentalControlService(&configs.Config{}, newTestDiscoveryService(tmdb.URL, "http://omdb.invalid"))
+	ctx := context.Background()
+
+	movies := []interface{}{
+		map[string]interface{}{"id": 1.0, "title": "Re-released"},
+		map[string]interface{}{"id": 2.0, "title": "Family"},
+		map[string]interface{}{"id": 404.0, "title": "Unrated", "certification": "G"},
+		map[string]interface{}{"title": "Only on OMDB"},
+	}
+
+	// Without controls nothing is looked up
+	filtered, err := service.FilterResults(ctx, "kids", "movie", movies)
+	if err != nil || len(filtered) != len(movies) || atomic.LoadInt32(requests) != 0 {
+		t.Fatalf("Expected all results without lookups, got %v, %v after %d requests", filtered, err, atomic.LoadInt32(requests))
+	}
+
+	service.SetControls("kids", models.ParentalControls{
+		MovieCertifications: map[string]string{"US": "PG"},
+		TVCertifications:    map[string]string{"US": "TV-Y7"},
+	})
+
+	// The R-rated re-release counts, OMDB's rating stands in for missing
+	// release dates and the title without any rating is blocked
+	filtered, err = service.FilterResults(ctx, "kids", "movie", movies)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var titles []string
+	for _, item := range filtered {
+		titles = append(titles, item.(map[string]interface{})["title"].(string))
+	}
+	if fmt.Sprint(titles) != "[Family Unrated]" {
+		t.Errorf("Expected [Family Unrated], got %v", titles)
+	}
+	if len(movies) != 4 {
+		t.Error("Expected the results not to be modified")
+	}
+
+	// Certifications are cached, including those of titles TMDB doesn't know
+	before := atomic.LoadInt32(requests)
+	if _, err := service.FilterResults(ctx, "kids", "movie", movies); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := atomic.LoadInt32(requests); got != before {
+		t.Errorf("Expected cached certifications, got %d requests", got-before)
+	}
+
+	// A failed lookup fails the filter instead of hiding the title as unrated
+	failing := append([]interface{}{map[string]interface{}{"id": 500.0, "title": "Unreachable"}}, movies...)
+	if _, err := service.FilterResults(ctx, "kids", "movie", failing); !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Errorf("Expected the lookup error, got %v", err)
+	}
+
+	// Other profiles are unaffected
+	if filtered, _ := service.FilterResults(ctx, "parent", "movie", movies); len(filtered) != len(movies) {
+		t.Errorf("Expected all results for another profile, got %v", filtered)
+	}
+
+	tvShows := []interface{}{
+		map[string]interface{}{"id": 3.0, "name": "Toddlers"},
+		map[string]interface{}{"id": 4.0, "name": "Grown-ups"},
+	}
+	if filtered, err := service.FilterResults(ctx, "kids", "tv", tvShows); err != nil || len(filtered) != 1 || filtered[0].(map[string]interface{})["name"] != "Toddlers" {
+		t.Errorf("Expected only Toddlers, got %v, %v", filtered, err)
+	}
+
+	// Media types without limits aren't filtered
+	service.SetControls("movies only", models.ParentalControls{MovieCertifications: map[string]string{"US": "G"}})
+	before = atomic.LoadInt32(requests)
+	if filtered, err := service.FilterResults(ctx, "movies only", "tv", tvShows); err != nil || len(filtered) != len(tvShows) {
+		t.Errorf("Expected all TV shows, got %v, %v", filtered, err)
+	}
+	if got := atomic.LoadInt32(requests); got != before {
+		t.Errorf("Expected no lookups, got %d requests", got-before)
+	}
+
+	// Details carrying release dates need no lookup
+	before = atomic.LoadInt32(requests)
+	movie := &models.Movie{ID: 5, ReleaseDates: &models.ReleaseDates{Results: []models.CountryReleaseDates{
+		{ISO3166_1: "US", ReleaseDates: []models.ReleaseDate{{Certification: "PG-13"}}},
+	}}}
+	if err := service.CheckMovie(ctx, "kids", movie); !errors.Is(err, ErrTitleBlocked) || !strings.Contains(err.Error(), "rated PG-13 in US") {
+		t.Errorf("Expected a PG-13 movie to be blocked, got %v", err)
+	}
+	if got := atomic.LoadInt32(requests); got != before {
+		t.Errorf("Expected no requests, got %d", got-before)
+	}
+
+	if err := service.CheckTVShow(ctx, "kids", &models.TVShow{ID: 3}); err != nil {
+		t.Errorf("Expected a TV-Y show to be allowed, got %v", err)
+	}
+	if err := service.CheckTVShow(ctx, "kids", &models.TVShow{ID: 4}); !errors.Is(err, ErrTitleBlocked) {
+		t.Errorf("Expected a TV-MA show to be blocked, got %v", err)
+	}
+}
+
+func TestParentalControlService_FilterSuggestions(t *testing.T) {
+	tmdb, _ := newFakeTMDBCertifications(t)
+	service := NewParentalControlService(&configs.Config{}, newTestDiscoveryService(tmdb.URL, "http://omdb.invalid"))
+	service.SetControls("kids", models.ParentalControls{
+		MovieCertifications: map[string]string{"US": "PG"},
+		TVCertifications:    map[string]string{"US": "TV-Y7"},
+	})
+
+	suggestions := []models.Suggestion{
+		{ID: 1, MediaType: "movie", Title: "Re-released"},
+		{ID: 3, MediaType: "person", Title: "Someone"},
+		{ID: 4, MediaType: "tv", Title: "Grown-ups"},
+		{ID: 2, MediaType: "movie", Title: "Family"},
+		{ID: 3, MediaType: "tv", Title: "Toddlers"},
+	}
+
+	filtered, complete, err := service.FilterSuggestions(context.Background(), "kids", suggestions)
+	if err != nil || !complete {
+		t.Fatalf("Expected a complete filter, got %v, %v", complete, err)
+	}
+	if titles := fmt.Sprint(suggestionTitles(filtered)); titles != "[Someone Family Toddlers]" {
+		t.Errorf("Expected [Someone Family Toddlers], got %v", titles)
+	}
+
+	// A failed lookup fails the filter
+	failing := append([]models.Suggestion{{ID: 500, MediaType: "movie", Title: "Unreachable"}}, suggestions...)
+	if _, _, err := service.FilterSuggestions(context.Background(), "kids", failing); !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Errorf("Expected the lookup error, got %v", err)
+	}
+}
+
+func TestParentalControlService_FilterSuggestions_Deadline(t *testing.T) {
+	tmdb, _ := newFakeTMDBCertifications(t)
+	service := NewParentalControlService(&configs.Config{}, newTestDiscoveryService(tmdb.URL, "http://omdb.invalid"))
+	service.SetControls("strict", models.ParentalControls{MovieCertifications: map[string]string{"US": "PG"}})
+	service.SetControls("lenient", models.ParentalControls{
+		MovieCertifications:   map[string]string{"US": "PG"},
+		UnknownCertifications: UnknownCertificationsLenient,
+	})
+
+	suggestions := []models.Suggestion{
+		{ID: 2, MediaType: "movie", Title: "Family"},
+		{ID: 600, MediaType: "movie", Title: "Slow"},
+	}
+
+	// A title still unchecked at the deadline counts as unrated
+	for profile, expected := range map[string]string{"strict": "[Family]", "lenient": "[Family Slow]"} {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		filtered, complete, err := service.FilterSuggestions(ctx, profile, suggestions)
+		cancel()
+		if err != nil || complete {
+			t.Fatalf("%s: expected an incomplete filter, got %v, %v", profile, complete, err)
+		}
+		if titles := fmt.Sprint(suggestionTitles(filtered)); titles != expected {
+			t.Errorf("%s: expected %s, got %s", profile, expected, titles)
+		}
+	}
+
+	// Cached certifications are still used once the deadline has passed
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	filtered, complete, err := service.FilterSuggestions(ctx, "strict", suggestions[:1])
+	if err != nil || !complete || len(filtered) != 1 {
+		t.Errorf("Expected the cached title to be checked, got %v, %v, %v", filtered, complete, err)
+	}
+}
+
+func TestParentalControlService_FilterSavedSearchMatches(t *testing.T) {
+	service := newTestParentalService(t)
+	service.SetControls("kids", kidControls)
+
+	matches := []models.SavedSearchMatch{
+		{ID: 1, MediaType: "movie", Title: "Re-released"},
+		{ID: 2, MediaType: "movie", Title: "Family"},
+		{ID: 4, MediaType: "tv", Title: "Grown-ups"},
+		{ID: 3, MediaType: "tv", Title: "Toddlers"},
+	}
+
+	filtered, err := service.FilterSavedSearchMatches(context.Background(), "kids", matches)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var titles []string
+	for _, match := range filtered {
+		titles = append(titles, match.Title)
+	}
+	if fmt.Sprint(titles) != "[Family Toddlers]" {
+		t.Errorf("Expected [Family Toddlers], got %v", titles)
+	}
+
+	if filtered, _ := service.FilterSavedSearchMatches(context.Background(), "parent", matches); len(filtered) != len(matches) {
+		t.Errorf("Expected all matches for another profile, got %v", filtered)
+	}
+}
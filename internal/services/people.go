@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
+)
+
+// knownForLimit is how many titles a person is listed as known for
+const knownForLimit = 8
+
+// PeopleService handles actor and crew lookups
+type PeopleService struct {
+	tmdbClient       *TMDBClient
+	watchlistService *WatchlistService
+	parentalService  *ParentalControlService
+}
+
+// NewPeopleService creates a new people service
+func NewPeopleService(config *configs.Config, watchlistService *WatchlistService, parentalService *ParentalControlService) *PeopleService {
+	return &PeopleService{
+		tmdbClient:       NewTMDBClient(&config.TMDB, &config.Cache, &config.Rate, &config.Retry, &config.Breaker),
+		watchlistService: watchlistService,
+		parentalService:  parentalService,
+	}
+}
+
+// SearchPeople searches for actors, directors and other crew
+func (s *PeopleService) SearchPeople(ctx context.Context, query string, page int) (*models.PersonSearchResult, error) {
+	return s.tmdbClient.SearchPeople(ctx, query, page)
+}
+
+// GetPerson gets a person's details and filmography, with the user's
+// watchlist status overlaid on each title. Titles the user's parental
+// controls don't allow are left out.
+func (s *PeopleService) GetPerson(ctx context.Context, userID string, personID int) (*models.Person, error) {
+	details, err := s.tmdbClient.GetPersonDetails(ctx, personID)
+	if err != nil {
+		return nil, err
+	}
+
+	credits, err := s.tmdbClient.GetPersonCombinedCredits(ctx, personID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy the cached details before adding the filmography
+	person := *details
+	person.Filmography, err = s.allowedFilmography(ctx, userID, buildFilmography(credits))
+	if err != nil {
+		return nil, err
+	}
+
+	watchlist, err := s.watchlistService.GetWatchlist(userID)
+	if err != nil {
+		return nil, err
+	}
+	overlayWatchlist(person.Filmography, watchlist)
+
+	person.KnownFor = knownFor(person.Filmography, person.KnownForDepartment)
+
+	return &person, nil
+}
+
+// allowedFilmography keeps the filmography entries the user's parental
+// controls allow
+func (s *PeopleService) allowedFilmography(ctx context.Context, userID string, filmography []models.FilmographyEntry) ([]models.FilmographyEntry, error) {
+	ids := make(map[string][]int)
+	for _, entry := range filmography {
+		ids[entry.MediaType] = append(ids[entry.MediaType], entry.ID)
+	}
+
+	allowed, err := s.parentalService.allowedTitles(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := make([]models.FilmographyEntry, 0, len(filmography))
+	for _, entry := range filmography {
+		if allowed[watchlistKey(entry.MediaType, entry.ID)] {
+			kept = append(kept, entry)
+		}
+	}
+	return kept, nil
+}
+
+// buildFilmography merges a person's credits into one entry per title,
+// newest first. Titles without a date come last.
+func buildFilmography(credits *models.CombinedCredits) []models.FilmographyEntry {
+	entries := make(map[string]*models.FilmographyEntry)
+	var order []string
+
+	add := func(credit models.PersonCredit, department, role string, isCast bool) {
+		if credit.MediaType != "movie" && credit.MediaType != "tv" {
+			return
+		}
+
+		key := watchlistKey(credit.MediaType, credit.ID)
+		entry, exists := entries[key]
+		if !exists {
+			title := credit.Title
+			if title == "" {
+				title = credit.Name
+			}
+			date := credit.ReleaseDate
+			if date == "" {
+				date = credit.FirstAirDate
+			}
+			year, _ := strconv.Atoi(releaseYear(date))
+
+			entry = &models.FilmographyEntry{
+				ID:          credit.ID,
+				MediaType:   credit.MediaType,
+				Title:       title,
+				Year:        year,
+				ReleaseDate: date,
+				PosterPath:  credit.PosterPath,
+				VoteAverage: credit.VoteAverage,
+				VoteCount:   credit.VoteCount,
+			}
+			entries[key] = entry
+			order = append(order, key)
+		}
+
+		if role != "" {
+			if isCast {
+				entry.Characters = appendUnique(entry.Characters, role)
+			} else {
+				entry.Jobs = appendUnique(entry.Jobs, role)
+			}
+		}
+		if department != "" {
+			entry.Departments = appendUnique(entry.Departments, department)
+		}
+	}
+
+	for _, credit := range credits.Cast {
+		add(credit, "Acting", credit.Character, true)
+	}
+	for _, credit := range credits.Crew {
+		add(credit, credit.Department, credit.Job, false)
+	}
+
+	filmography := make([]models.FilmographyEntry, 0, len(order))
+	for _, key := range order {
+		filmography = append(filmography, *entries[key])
+	}
+
+	sort.SliceStable(filmography, func(i, j int) bool {
+		a, b := filmography[i], filmography[j]
+		if (a.ReleaseDate == "") != (b.ReleaseDate == "") {
+			return b.ReleaseDate == ""
+		}
+		if a.ReleaseDate != b.ReleaseDate {
+			return a.ReleaseDate > b.ReleaseDate
+		}
+		return a.Title < b.Title
+	})
+
+	return filmography
+}
+
+// overlayWatchlist marks the filmography titles that are on the watchlist
+// and whether they have been watched
+func overlayWatchlist(filmography []models.FilmographyEntry, watchlist []models.WatchlistItem) {
+	items := indexWatchlist(watchlist)
+	for i := range filmography {
+		entry := &filmography[i]
+		if item, ok := items[watchlistKey(entry.MediaType, entry.ID)]; ok {
+			entry.InWatchlist = true
+			entry.Watched = item.Watched
+		}
+	}
+}
+
+// knownFor picks the most voted-on titles from the person's main department
+func knownFor(filmography []models.FilmographyEntry, department string) []models.FilmographyEntry {
+	var titles []models.FilmographyEntry
+	for _, entry := range filmography {
+		if department == "" || containsString(entry.Departments, department) {
+			titles = append(titles, entry)
+		}
+	}
+
+	sort.SliceStable(titles, func(i, j int) bool {
+		return titles[i].VoteCount > titles[j].VoteCount
+	})
+
+	if len(titles) > knownForLimit {
+		titles = titles[:knownForLimit]
+	}
+	return titles
+}
+
+// appendUnique appends value to values unless it is already there
+func appendUnique(values []string, value string) []string {
+	if containsString(values, value) {
+		return values
+	}
+	return append(values, value)
+}
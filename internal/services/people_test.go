@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
+)
+
+func newFakeTMDBPerson() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/person/525":
+			w.Write([]byte(`{"id": 525, "name": "Christopher Nolan", "biography": "British-American filmmaker.", "known_for_department": "Directing", "imdb_id": "nm0634240"}`))
+		case "/person/525/combined_credits":
+			w.Write([]byte(`{
+				"cast": [
+					{"id": 155, "media_type": "movie", "title": "The Dark Knight", "release_date": "2008-07-16", "character": "Himself", "vote_count": 30000}
+				],
+				"crew": [
+					{"id": 27205, "media_type": "movie", "title": "Inception", "release_date": "2010-07-15", "job": "Director", "department": "Directing", "vote_count": 35000},
+					{"id": 27205, "media_type": "movie", "title": "Inception", "release_date": "2010-07-15", "job": "Writer", "department": "Writing", "vote_count": 35000},
+					{"id": 27205, "media_type": "movie", "title": "Inception", "release_date": "2010-07-15", "job": "Producer", "department": "Production", "vote_count": 35000},
+					{"id": 155, "media_type": "movie", "title": "The Dark Knight", "release_date": "2008-07-16", "job": "Director", "department": "Directing", "vote_count": 30000},
+					{"id": 872585, "media_type": "movie", "title": "Oppenheimer", "release_date": "2023-07-19", "job": "Director", "department": "Directing", "vote_count": 8000},
+					{"id": 1, "media_type": "movie", "title": "Untitled Project", "job": "Director", "department": "Directing"},
+					{"id": 4, "media_type": "tv", "name": "Westworld", "first_air_date": "2016-10-02", "job": "Executive Producer", "department": "Production", "vote_count": 5000}
+				]
+			}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestPeopleService_GetPerson(t *testing.T) {
+	tmdb := newFakeTMDBPerson()
+	defer tmdb.Close()
+
+	watchlistService := NewWatchlistService()
+	watchlistService.AddToWatchlist("user", models.WatchlistItem{ID: "27205", Type: "movie", Title: "Inception"})
+	watchlistService.MarkAsWatched("user", "27205", "movie", 9)
+	watchlistService.AddToWatchlist("user", models.WatchlistItem{ID: "872585", Type: "movie", Title: "Oppenheimer"})
+
+	service := NewPeopleService(&configs.Config{
+		TMDB: configs.TMDBConfig{APIKey: "test_key", BaseURL: tmdb.URL},
+	}, watchlistService, newTestParentalService(t))
+	service.tmdbClient.upstream.rateLimiter = NewRateLimiter(60000, 1000)
+	service.tmdbClient.upstream.breaker = NewCircuitBreaker("test", 100, time.Minute)
+
+	person, err := service.GetPerson(context.Background(), "user", 525)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if person.Name != "Christopher Nolan" || person.IMDBID != "nm0634240" {
+		t.Errorf("Unexpected person details: %+v", person)
+	}
+
+	// One entry per title, newest first, undated last
+	var titles []string
+	for _, entry := range person.Filmography {
+		titles = append(titles, entry.Title)
+	}
+	if got := strings.Join(titles, ", "); got != "Oppenheimer, Westworld, Inception, The Dark Knight, Untitled Project" {
+		t.Errorf("Unexpected filmography order: %s", got)
+	}
+
+	inception := person.Filmography[2]
+	if strings.Join(inception.Jobs, ",") != "Director,Writer,Producer" || inception.Year != 2010 {
+		t.Errorf("Expected Inception's jobs to be merged, got %+v", inception)
+	}
+	if !inception.InWatchlist || !inception.Watched {
+		t.Errorf("Expected Inception to be watched, got %+v", inception)
+	}
+	if oppenheimer := person.Filmography[0]; !oppenheimer.InWatchlist || oppenheimer.Watched {
+		t.Errorf("Expected Oppenheimer to be on the watchlist but unwatched, got %+v", oppenheimer)
+	}
+
+	darkKnight := person.Filmography[3]
+	if strings.Join(darkKnight.Characters, ",") != "Himself" || strings.Join(darkKnight.Departments, ",") != "Acting,Directing" {
+		t.Errorf("Expected The Dark Knight to carry both roles, got %+v", darkKnight)
+	}
+
+	// Known for is limited to the directing credits, most voted first
+	titles = nil
+	for _, entry := range person.KnownFor {
+		titles = append(titles, entry.Title)
+	}
+	if got := strings.Join(titles, ", "); got != "Inception, The Dark Knight, Oppenheimer, Untitled Project" {
+		t.Errorf("Unexpected known for: %s", got)
+	}
+}
+
+func TestPeopleService_GetPerson_ParentalControls(t *testing.T) {
+	tmdb := newFakeTMDBPerson()
+	defer tmdb.Close()
+
+	service := NewPeopleService(&configs.Config{
+		TMDB: configs.TMDBConfig{APIKey: "test_key", BaseURL: tmdb.URL},
+	}, NewWatchlistService(), newTestParentalService(t))
+	service.tmdbClient.upstream.rateLimiter = NewRateLimiter(60000, 1000)
+	service.tmdbClient.upstream.breaker = NewCircuitBreaker("test", 100, time.Minute)
+	service.parentalService.SetControls("kids", kidControls)
+
+	// Movie 1 is rated R and TV show 4 TV-MA; the rest are unrated
+	person, err := service.GetPerson(context.Background(), "kids", 525)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var titles []string
+	for _, entry := range person.Filmography {
+		titles = append(titles, entry.Title)
+	}
+	if got := strings.Join(titles, ", "); got != "Oppenheimer, Inception, The Dark Knight" {
+		t.Errorf("Unexpected filmography: %s", got)
+	}
+	if len(person.KnownFor) != 3 {
+		t.Errorf("Expected known for to come from the filtered filmography, got %+v", person.KnownFor)
+	}
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"container/list"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"movie-discovery-app/internal/models"
+)
+
+// Prefix index limits
+const (
+	defaultPrefixIndexSize = 10000
+	maxIndexedWords        = 8    // Word suffixes indexed per title
+	maxPrefixScan          = 2000 // Keys examined per lookup
+)
+
+// Match tiers, best first
+const (
+	tierTitlePrefix = iota // The title starts with the query
+	tierWordPrefix         // A later word of the title starts with the query
+	tierOther              // Matched by TMDB only
+)
+
+// PrefixIndex is a bounded in-memory index of recently seen titles and
+// people for autocomplete. Each title is indexed from every word, so "dark
+// kn" finds "The Dark Knight". When full, the least recently seen entry is
+// evicted.
+type PrefixIndex struct {
+	mu       sync.RWMutex
+	capacity int
+	entries  map[string]*indexEntry // media type and ID -> entry
+	keys     []indexKey             // Sorted by key
+	recency  *list.List             // Most recently seen first
+}
+
+// indexEntry is an indexed suggestion
+type indexEntry struct {
+	suggestion models.Suggestion
+	popularity float64
+	keys       []string
+	element    *list.Element
+}
+
+// indexKey is a normalized title suffix pointing at an entry
+type indexKey struct {
+	key   string
+	entry *indexEntry
+	tier  int
+}
+
+// indexMatch is a ranked lookup result
+type indexMatch struct {
+	suggestion models.Suggestion
+	popularity float64
+	tier       int
+}
+
+// NewPrefixIndex creates a prefix index holding up to capacity entries
+func NewPrefixIndex(capacity int) *PrefixIndex {
+	if capacity <= 0 {
+		capacity = defaultPrefixIndexSize
+	}
+	return &PrefixIndex{
+		capacity: capacity,
+		entries:  make(map[string]*indexEntry),
+		recency:  list.New(),
+	}
+}
+
+// Len returns the number of indexed entries
+func (x *PrefixIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.entries)
+}
+
+// Add indexes a suggestion, or refreshes it if it is already indexed
+func (x *PrefixIndex) Add(suggestion models.Suggestion, popularity float64) {
+	words := strings.Fields(normalizeTitle(suggestion.Title))
+	if len(words) == 0 {
+		return
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	id := watchlistKey(suggestion.MediaType, suggestion.ID)
+	if entry, exists := x.entries[id]; exists {
+		if entry.suggestion.Title == suggestion.Title {
+			entry.suggestion = suggestion
+			entry.popularity = popularity
+			x.recency.MoveToFront(entry.element)
+			return
+		}
+		x.remove(id, entry)
+	}
+
+	entry := &indexEntry{suggestion: suggestion, popularity: popularity}
+	for i := 0; i < len(words) && i < maxIndexedWords; i++ {
+		tier := tierWordPrefix
+		if i == 0 {
+			tier = tierTitlePrefix
+		}
+		key := strings.Join(words[i:], " ")
+		entry.keys = append(entry.keys, key)
+		x.insertKey(indexKey{key: key, entry: entry, tier: tier})
+	}
+	entry.element = x.recency.PushFront(id)
+	x.entries[id] = entry
+
+	for len(x.entries) > x.capacity {
+		oldest := x.recency.Back().Value.(string)
+		x.remove(oldest, x.entries[oldest])
+	}
+}
+
+// Lookup returns up to limit suggestions whose title has a word starting
+// with prefix: titles starting with it first, then by popularity
+func (x *PrefixIndex) Lookup(prefix string, limit int) []models.Suggestion {
+	matches := x.lookup(normalizeTitle(prefix), limit)
+	suggestions := make([]models.Suggestion, len(matches))
+	for i, match := range matches {
+		suggestions[i] = match.suggestion
+	}
+	return suggestions
+}
+
+// lookup returns ranked matches for a normalized prefix
+func (x *PrefixIndex) lookup(prefix string, limit int) []indexMatch {
+	if prefix == "" || limit <= 0 {
+		return nil
+	}
+
+	x.mu.RLock()
+	best := make(map[*indexEntry]int)
+	start := sort.Search(len(x.keys), func(i int) bool { return x.keys[i].key >= prefix })
+	for i := start; i < len(x.keys) && i-start < maxPrefixScan && strings.HasPrefix(x.keys[i].key, prefix); i++ {
+		key := x.keys[i]
+		if tier, seen := best[key.entry]; !seen || key.tier < tier {
+			best[key.entry] = key.tier
+		}
+	}
+
+	matches := make([]indexMatch, 0, len(best))
+	for entry, tier := range best {
+		matches = append(matches, indexMatch{suggestion: entry.suggestion, popularity: entry.popularity, tier: tier})
+	}
+	x.mu.RUnlock()
+
+	sortMatches(matches)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// insertKey adds a key in sorted position. The caller holds mu.
+func (x *PrefixIndex) insertKey(key indexKey) {
+	i := sort.Search(len(x.keys), func(i int) bool { return x.keys[i].key >= key.key })
+	x.keys = append(x.keys, indexKey{})
+	copy(x.keys[i+1:], x.keys[i:])
+	x.keys[i] = key
+}
+
+// remove drops an entry and its keys. The caller holds mu.
+func (x *PrefixIndex) remove(id string, entry *indexEntry) {
+	for _, key := range entry.keys {
+		i := sort.Search(len(x.keys), func(i int) bool { return x.keys[i].key >= key })
+		for ; i < len(x.keys) && x.keys[i].key == key; i++ {
+			if x.keys[i].entry == entry {
+				x.keys = append(x.keys[:i], x.keys[i+1:]...)
+				break
+			}
+		}
+	}
+	x.recency.Remove(entry.element)
+	delete(x.entries, id)
+}
+
+// sortMatches orders matches by tier, then popularity, then title
+func sortMatches(matches []indexMatch) {
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].tier != matches[j].tier {
+			return matches[i].tier < matches[j].tier
+		}
+		if matches[i].popularity != matches[j].popularity {
+			return matches[i].popularity > matches[j].popularity
+		}
+		return matches[i].suggestion.Title < matches[j].suggestion.Title
+	})
+}
+
+// matchTier ranks how a normalized title matches a normalized prefix
+func matchTier(title, prefix string) int {
+	if strings.HasPrefix(title, prefix) {
+		return tierTitlePrefix
+	}
+	if strings.Contains(title, " "+prefix) {
+		return tierWordPrefix
+	}
+	return tierOther
+}
+
+// normalizeTitle lower-cases a title and reduces it to words of letters and
+// digits separated by single spaces
+func normalizeTitle(title string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
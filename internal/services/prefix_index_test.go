@@ -0,0 +1,137 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"movie-discovery-app/internal/models"
+)
+
+func suggestionTitles(suggestions []models.Suggestion) []string {
+	titles := make([]string, len(suggestions))
+	for i, suggestion := range suggestions {
+		titles[i] = suggestion.Title
+	}
+	return titles
+}
+
+func TestPrefixIndex_Lookup(t *testing.T) {
+	index := NewPrefixIndex(100)
+	index.Add(models.Suggestion{ID: 155, MediaType: "movie", Title: "The Dark Knight", Year: "2008"}, 80)
+	index.Add(models.Suggestion{ID: 49026, MediaType: "movie", Title: "The Dark Knight Rises", Year: "2012"}, 60)
+	index.Add(models.Suggestion{ID: 70523, MediaType: "tv", Title: "Dark", Year: "2017"}, 40)
+	index.Add(models.Suggestion{ID: 1, MediaType: "movie", Title: "Darkman", Year: "1990"}, 90)
+	index.Add(models.Suggestion{ID: 603, MediaType: "movie", Title: "The Matrix", Year: "1999"}, 70)
+	index.Add(models.Suggestion{ID: 2, MediaType: "movie", Title: "Spider-Man: No Way Home"}, 50)
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		// Titles starting with the prefix come first, then by popularity
+		{"dark", []string{"Darkman", "Dark", "The Dark Knight", "The Dark Knight Rises"}},
+		{"DARK kn", []string{"The Dark Knight", "The Dark Knight Rises"}},
+		{"the", []string{"The Dark Knight", "The Matrix", "The Dark Knight Rises"}},
+		{"knight r", []string{"The Dark Knight Rises"}},
+		{"spider man", []string{"Spider-Man: No Way Home"}},
+		{"man", []string{"Spider-Man: No Way Home"}},
+		{"matrix reloaded", nil},
+		{"  ", nil},
+	}
+
+	for _, tt := range tests {
+		got := suggestionTitles(index.Lookup(tt.prefix, 10))
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: expected %v, got %v", tt.prefix, tt.want, got)
+		}
+	}
+
+	if got := index.Lookup("dark", 2); len(got) != 2 || got[0].Title != "Darkman" {
+		t.Errorf("Expected the limit to keep the best 2, got %v", suggestionTitles(got))
+	}
+}
+
+func TestPrefixIndex_Add(t *testing.T) {
+	index := NewPrefixIndex(100)
+	index.Add(models.Suggestion{ID: 1, MediaType: "movie", Title: "Dune"}, 10)
+	index.Add(models.Suggestion{ID: 1, MediaType: "tv", Title: "Dune"}, 5)
+	index.Add(models.Suggestion{ID: 1, MediaType: "movie", Title: "Dune", Year: "2021", PosterPath: "/dune.jpg"}, 20)
+	index.Add(models.Suggestion{ID: 2, MediaType: "movie", Title: "!!!"}, 10)
+
+	// Movies and TV shows sharing an ID are distinct; re-adding refreshes
+	if index.Len() != 2 {
+		t.Fatalf("Expected 2 entries, got %d", index.Len())
+	}
+	got := index.Lookup("dune", 10)
+	if len(got) != 2 || got[0].MediaType != "movie" || got[0].Year != "2021" || got[0].PosterPath != "/dune.jpg" {
+		t.Errorf("Expected the refreshed movie first, got %+v", got)
+	}
+
+	// A renamed title is no longer found under its old name
+	index.Add(models.Suggestion{ID: 1, MediaType: "tv", Title: "Dune: Prophecy"}, 5)
+	if got := suggestionTitles(index.Lookup("prophecy", 10)); !reflect.DeepEqual(got, []string{"Dune: Prophecy"}) {
+		t.Errorf("Expected the new title, got %v", got)
+	}
+	if got := index.Lookup("dune", 10); len(got) != 2 {
+		t.Errorf("Expected one entry per title, got %+v", got)
+	}
+	if len(index.keys) != 3 {
+		t.Errorf("Expected the old title's keys to be removed, got %d keys", len(index.keys))
+	}
+}
+
+func TestPrefixIndex_Eviction(t *testing.T) {
+	index := NewPrefixIndex(2)
+	index.Add(models.Suggestion{ID: 1, MediaType: "movie", Title: "Alien"}, 1)
+	index.Add(models.Suggestion{ID: 2, MediaType: "movie", Title: "Aliens"}, 1)
+
+	// Seeing Alien again makes Aliens the least recently seen
+	index.Add(models.Suggestion{ID: 1, MediaType: "movie", Title: "Alien"}, 1)
+	index.Add(models.Suggestion{ID: 3, MediaType: "movie", Title: "Alien 3"}, 1)
+
+	if index.Len() != 2 {
+		t.Fatalf("Expected 2 entries, got %d", index.Len())
+	}
+	if got := suggestionTitles(index.Lookup("alien", 10)); !reflect.DeepEqual(got, []string{"Alien", "Alien 3"}) {
+		t.Errorf("Expected Aliens to be evicted, got %v", got)
+	}
+	if len(index.keys) != 3 {
+		t.Errorf("Expected the evicted title's keys to be removed, got %d keys", len(index.keys))
+	}
+}
+
+// newBenchmarkPrefixIndex fills an index with size generated titles
+func newBenchmarkPrefixIndex(size int) *PrefixIndex {
+	words := []string{"the", "dark", "knight", "star", "wars", "return", "of", "king", "lost", "city", "night", "story"}
+	index := NewPrefixIndex(size)
+	for i := 0; i < size; i++ {
+		title := fmt.Sprintf("%s %s %s %d", words[i%len(words)], words[(i/len(words))%len(words)], words[(i/7)%len(words)], i)
+		index.Add(models.Suggestion{ID: i, MediaType: "movie", Title: title}, float64(i%100))
+	}
+	return index
+}
+
+func BenchmarkPrefixIndex_Lookup(b *testing.B) {
+	index := newBenchmarkPrefixIndex(10000)
+	prefixes := []string{"d", "dark", "the dark kn", "star wars", "city 12", "nothing"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index.Lookup(prefixes[i%len(prefixes)], DefaultAutocompleteLimit)
+	}
+}
+
+func BenchmarkPrefixIndex_Add(b *testing.B) {
+	index := newBenchmarkPrefixIndex(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index.Add(models.Suggestion{ID: 10000 + i, MediaType: "movie", Title: fmt.Sprintf("Lost Story %d", i)}, 1)
+	}
+}
@@ -0,0 +1,386 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"movie-discovery-app/internal/models"
+)
+
+// Profile limits
+const (
+	maxProfiles            = 6 // Profiles per account, including the owner's
+	maxProfileNameLength   = 50
+	maxProfileAvatarLength = 500
+	ownerProfileName       = "Owner"
+	minOwnerPINLength      = 4
+	maxOwnerPINLength      = 8
+	maxOwnerPINAttempts    = 5 // Wrong PINs in a row before PIN entry is locked
+	ownerPINLockout        = 15 * time.Minute
+)
+
+var (
+	ErrProfileNotFound  = errors.New("profile not found")
+	ErrProfileLimit     = fmt.Errorf("no more than %d profiles per account", maxProfiles)
+	ErrProfileNameTaken = errors.New("another profile already has this name")
+	ErrOwnerPINNotSet   = errors.New("the account owner must set a PIN before adding kid profiles")
+
+	// ErrProfileForbidden is returned when the selected profile may not do
+	// something. It is wrapped with the reason.
+	ErrProfileForbidden = errors.New("not allowed for this profile")
+)
+
+// kidParentalControls are the parental controls a kid profile starts with
+var kidParentalControls = models.ParentalControls{
+	MovieCertifications: map[string]string{"US": "PG"},
+	TVCertifications:    map[string]string{"US": "TV-Y7"},
+}
+
+type profileContextKey struct{}
+
+// WithProfile returns a context for a request made as profile
+func WithProfile(ctx context.Context, profile models.Profile) context.Context {
+	return context.WithValue(ctx, profileContextKey{}, profile)
+}
+
+// ProfileFromContext returns the profile a request is made as, or the zero
+// profile if none was selected
+func ProfileFromContext(ctx context.Context) models.Profile {
+	profile, _ := ctx.Value(profileContextKey{}).(models.Profile)
+	return profile
+}
+
+// ProfileService keeps the profiles of each account. Every account has an
+// owner's profile, created on first use, which can't be deleted. Once the
+// owner sets a PIN, acting as the owner and leaving a kid profile require it.
+type ProfileService struct {
+	parentalService *ParentalControlService // For the controls kid profiles start with
+	now             func() time.Time
+
+	mu         sync.Mutex
+	nextID     int
+	households map[string]*household // accountID -> profiles
+}
+
+// household is an account's profiles, the one switched to last and the
+// owner's PIN
+type household struct {
+	profiles []models.Profile
+	active   int
+
+	pinHash        []byte // SHA-256 of the owner PIN; nil until one is set
+	failedPINs     int    // Wrong PINs since the last right one
+	pinLockedUntil time.Time
+}
+
+// NewProfileService creates a new profile service
+func NewProfileService(parentalService *ParentalControlService) *ProfileService {
+	return &ProfileService{
+		parentalService: parentalService,
+		now:             time.Now,
+		households:      make(map[string]*household),
+	}
+}
+
+// ParseOwnerPIN validates an owner PIN, which is 4 to 8 digits
+func ParseOwnerPIN(pin string) (string, error) {
+	if len(pin) < minOwnerPINLength || len(pin) > maxOwnerPINLength {
+		return "", fmt.Errorf("pin must be %d to %d digits", minOwnerPINLength, maxOwnerPINLength)
+	}
+	for _, r := range pin {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("pin must be %d to %d digits", minOwnerPINLength, maxOwnerPINLength)
+		}
+	}
+	return pin, nil
+}
+
+// ParseProfile validates a profile from a request. It must have a name of at
+// most 50 characters; the avatar is optional.
+func ParseProfile(profile models.Profile) (models.Profile, error) {
+	name := strings.TrimSpace(profile.Name)
+	if name == "" {
+		return profile, fmt.Errorf("name is required")
+	}
+	if utf8.RuneCountInString(name) > maxProfileNameLength {
+		return profile, fmt.Errorf("name must be at most %d characters", maxProfileNameLength)
+	}
+
+	avatar := strings.TrimSpace(profile.Avatar)
+	if len(avatar) > maxProfileAvatarLength {
+		return profile, fmt.Errorf("avatar must be at most %d characters", maxProfileAvatarLength)
+	}
+
+	return models.Profile{Name: name, Avatar: avatar, Kid: profile.Kid}, nil
+}
+
+// GetProfiles gets an account's profiles, the owner's first
+func (s *ProfileService) GetProfiles(accountID string) []models.Profile {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	house := s.household(accountID)
+	profiles := make([]models.Profile, len(house.profiles))
+	for i, profile := range house.profiles {
+		profiles[i] = house.withActive(profile)
+	}
+	return profiles
+}
+
+// GetProfile gets one of an account's profiles
+func (s *ProfileService) GetProfile(accountID string, id int) (models.Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	house := s.household(accountID)
+	i := house.find(id)
+	if i < 0 {
+		return models.Profile{}, ErrProfileNotFound
+	}
+	return house.withActive(house.profiles[i]), nil
+}
+
+// SelectProfile gets one of an account's profiles for a single request. While
+// a kid profile is active, selecting another profile takes the owner PIN.
+func (s *ProfileService) SelectProfile(accountID string, id int, pin string) (models.Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	house := s.household(accountID)
+	i := house.find(id)
+	if i < 0 {
+		return models.Profile{}, ErrProfileNotFound
+	}
+	if err := s.checkLeaveKidProfile(house, id, pin); err != nil {
+		return models.Profile{}, err
+	}
+	return house.withActive(house.profiles[i]), nil
+}
+
+// RequireOwner returns an error wrapping ErrProfileForbidden unless profile
+// is the account owner's and pin is the owner PIN, if one is set
+func (s *ProfileService) RequireOwner(accountID string, profile models.Profile, pin string) error {
+	if !profile.Owner {
+		return fmt.Errorf("%w: only the account owner's profile can do this", ErrProfileForbidden)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.checkPIN(s.household(accountID), pin)
+}
+
+// SetOwnerPIN replaces an account's owner PIN, which should have been
+// validated with ParseOwnerPIN. The caller checks RequireOwner first.
+func (s *ProfileService) SetOwnerPIN(accountID, pin string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	house := s.household(accountID)
+	hash := sha256.Sum256([]byte(pin))
+	house.pinHash = hash[:]
+	house.failedPINs = 0
+	house.pinLockedUntil = time.Time{}
+}
+
+// ActiveProfile gets the profile an account switched to last, which is the
+// owner's until another one is chosen
+func (s *ProfileService) ActiveProfile(accountID string) models.Profile {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	house := s.household(accountID)
+	return house.withActive(house.profiles[house.find(house.active)])
+}
+
+// CreateProfile adds a profile, which should have been validated with
+// ParseProfile, to an account. Kid profiles start with parental controls,
+// and can only be added once the owner has set a PIN.
+func (s *ProfileService) CreateProfile(accountID string, profile models.Profile) (models.Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	house := s.household(accountID)
+	if len(house.profiles) >= maxProfiles {
+		return models.Profile{}, ErrProfileLimit
+	}
+	if house.nameTaken(profile.Name, 0) {
+		return models.Profile{}, ErrProfileNameTaken
+	}
+	if profile.Kid && house.pinHash == nil {
+		return models.Profile{}, ErrOwnerPINNotSet
+	}
+
+	s.nextID++
+	profile.ID = s.nextID
+	profile.Owner = false
+	profile.CreatedAt = s.now()
+	house.profiles = append(house.profiles, profile)
+
+	if profile.Kid {
+		s.parentalService.SetControls(profile.Key(), kidParentalControls)
+	}
+
+	return house.withActive(profile), nil
+}
+
+// UpdateProfile changes the name, avatar and kid flag of a profile. The
+// owner's profile can't be a kid profile. A profile that becomes a kid
+// profile without parental controls gets the kid profile defaults.
+func (s *ProfileService) UpdateProfile(accountID string, id int, profile models.Profile) (models.Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	house := s.household(accountID)
+	i := house.find(id)
+	if i < 0 {
+		return models.Profile{}, ErrProfileNotFound
+	}
+	existing := &house.profiles[i]
+	if existing.Owner && profile.Kid {
+		return models.Profile{}, fmt.Errorf("%w: the owner's profile can't be a kid profile", ErrProfileForbidden)
+	}
+	if house.nameTaken(profile.Name, id) {
+		return models.Profile{}, ErrProfileNameTaken
+	}
+	if profile.Kid && house.pinHash == nil {
+		return models.Profile{}, ErrOwnerPINNotSet
+	}
+
+	if profile.Kid && !existing.Kid && !s.parentalService.GetControls(existing.Key()).Enabled() {
+		s.parentalService.SetControls(existing.Key(), kidParentalControls)
+	}
+	existing.Name = profile.Name
+	existing.Avatar = profile.Avatar
+	existing.Kid = profile.Kid
+
+	return house.withActive(*existing), nil
+}
+
+// DeleteProfile removes a profile from an account. The owner's profile
+// can't be deleted; if the active profile is, the owner's becomes active.
+// Removing the profile's data is up to the caller.
+func (s *ProfileService) DeleteProfile(accountID string, id int) (models.Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	house := s.household(accountID)
+	i := house.find(id)
+	if i < 0 {
+		return models.Profile{}, ErrProfileNotFound
+	}
+	profile := house.profiles[i]
+	if profile.Owner {
+		return models.Profile{}, fmt.Errorf("%w: the owner's profile can't be deleted", ErrProfileForbidden)
+	}
+
+	house.profiles = append(house.profiles[:i], house.profiles[i+1:]...)
+	if house.active == id {
+		house.active = house.profiles[0].ID
+	}
+	return profile, nil
+}
+
+// SwitchProfile makes a profile the account's active profile, used by
+// requests that don't select one. Switching away from a kid profile takes
+// the owner PIN.
+func (s *ProfileService) SwitchProfile(accountID string, id int, pin string) (models.Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	house := s.household(accountID)
+	i := house.find(id)
+	if i < 0 {
+		return models.Profile{}, ErrProfileNotFound
+	}
+	if err := s.checkLeaveKidProfile(house, id, pin); err != nil {
+		return models.Profile{}, err
+	}
+	house.active = id
+	return house.withActive(house.profiles[i]), nil
+}
+
+// checkLeaveKidProfile checks the owner PIN when a request made while a kid
+// profile is active asks for another profile. The caller holds mu.
+func (s *ProfileService) checkLeaveKidProfile(house *household, id int, pin string) error {
+	if id == house.active || !house.profiles[house.find(house.active)].Kid {
+		return nil
+	}
+	return s.checkPIN(house, pin)
+}
+
+// checkPIN checks the owner PIN, if one is set. Too many wrong PINs in a row
+// lock PIN entry for a while. The caller holds mu.
+func (s *ProfileService) checkPIN(house *household, pin string) error {
+	if house.pinHash == nil {
+		return nil
+	}
+	now := s.now()
+	if now.Before(house.pinLockedUntil) {
+		return fmt.Errorf("%w: too many wrong PINs, try again after %s", ErrProfileForbidden, house.pinLockedUntil.Format(time.RFC3339))
+	}
+	if pin == "" {
+		return fmt.Errorf("%w: the owner PIN is required", ErrProfileForbidden)
+	}
+
+	hash := sha256.Sum256([]byte(pin))
+	if subtle.ConstantTimeCompare(hash[:], house.pinHash) == 1 {
+		house.failedPINs = 0
+		return nil
+	}
+
+	house.failedPINs++
+	if house.failedPINs >= maxOwnerPINAttempts {
+		house.failedPINs = 0
+		house.pinLockedUntil = now.Add(ownerPINLockout)
+	}
+	return fmt.Errorf("%w: wrong owner PIN", ErrProfileForbidden)
+}
+
+// household returns an account's profiles, creating the owner's profile on
+// first use. The caller holds mu.
+func (s *ProfileService) household(accountID string) *household {
+	house, exists := s.households[accountID]
+	if !exists {
+		s.nextID++
+		owner := models.Profile{ID: s.nextID, Name: ownerProfileName, Owner: true, CreatedAt: s.now()}
+		house = &household{profiles: []models.Profile{owner}, active: owner.ID}
+		s.households[accountID] = house
+	}
+	return house
+}
+
+// find returns the index of a profile, or -1 if the account has no such
+// profile
+func (h *household) find(id int) int {
+	for i, profile := range h.profiles {
+		if profile.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// nameTaken reports whether a profile other than exceptID has name, ignoring
+// case
+func (h *household) nameTaken(name string, exceptID int) bool {
+	for _, profile := range h.profiles {
+		if profile.ID != exceptID && strings.EqualFold(profile.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// withActive returns a profile with its Active flag set
+func (h *household) withActive(profile models.Profile) models.Profile {
+	profile.Active = profile.ID == h.active
+	return profile
+}
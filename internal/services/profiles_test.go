@@ -0,0 +1,195 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
+)
+
+func newTestProfileService() (*ProfileService, *ParentalControlService) {
+	parental := NewParentalControlService(&configs.Config{}, newTestDiscoveryService("http://tmdb.invalid", "http://omdb.invalid"))
+	return NewProfileService(parental), parental
+}
+
+func TestParseProfile(t *testing.T) {
+	profile, err := ParseProfile(models.Profile{ID: 7, Name: "  Sam ", Avatar: " fox ", Kid: true, Owner: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if profile != (models.Profile{Name: "Sam", Avatar: "fox", Kid: true}) {
+		t.Errorf("Expected a trimmed profile without server-set fields, got %+v", profile)
+	}
+
+	for _, invalid := range []models.Profile{
+		{Name: " "},
+		{Name: strings.Repeat("a", 51)},
+		{Name: "Sam", Avatar: strings.Repeat("a", 501)},
+	} {
+		if _, err := ParseProfile(invalid); err == nil {
+			t.Errorf("%+v: expected an error", invalid)
+		}
+	}
+}
+
+func TestProfileService_Household(t *testing.T) {
+	service, parental := newTestProfileService()
+
+	// Every account starts with its owner's profile, active
+	profiles := service.GetProfiles("account")
+	if len(profiles) != 1 || !profiles[0].Owner || !profiles[0].Active {
+		t.Fatalf("Expected the owner's profile, got %+v", profiles)
+	}
+	owner := profiles[0]
+
+	// Kid profiles need an owner PIN to keep them in
+	if _, err := service.CreateProfile("account", models.Profile{Name: "Sam", Kid: true}); !errors.Is(err, ErrOwnerPINNotSet) {
+		t.Errorf("Expected ErrOwnerPINNotSet, got %v", err)
+	}
+	service.SetOwnerPIN("account", "2468")
+
+	kid, err := service.CreateProfile("account", models.Profile{Name: "Sam", Kid: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if kid.Owner || kid.Active || kid.ID == owner.ID {
+		t.Errorf("Expected a new inactive profile, got %+v", kid)
+	}
+	if controls := parental.GetControls(kid.Key()); controls.MovieCertifications["US"] != "PG" {
+		t.Errorf("Expected a kid profile to start with parental controls, got %+v", controls)
+	}
+
+	if _, err := service.CreateProfile("account", models.Profile{Name: "sam"}); !errors.Is(err, ErrProfileNameTaken) {
+		t.Errorf("Expected ErrProfileNameTaken, got %v", err)
+	}
+	if _, err := service.UpdateProfile("account", owner.ID, models.Profile{Name: "Owner", Kid: true}); !errors.Is(err, ErrProfileForbidden) {
+		t.Errorf("Expected the owner's profile not to become a kid profile, got %v", err)
+	}
+
+	// Accounts don't see each other's profiles
+	if _, err := service.GetProfile("other", kid.ID); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("Expected ErrProfileNotFound, got %v", err)
+	}
+
+	// Switching changes the profile used by default until it is deleted
+	if _, err := service.SwitchProfile("account", kid.ID, ""); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if active := service.ActiveProfile("account"); active.ID != kid.ID || !active.Active {
+		t.Errorf("Expected the kid profile to be active, got %+v", active)
+	}
+	if _, err := service.DeleteProfile("account", owner.ID); !errors.Is(err, ErrProfileForbidden) {
+		t.Errorf("Expected the owner's profile not to be deleted, got %v", err)
+	}
+	if _, err := service.DeleteProfile("account", kid.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if active := service.ActiveProfile("account"); active.ID != owner.ID {
+		t.Errorf("Expected the owner's profile to be active again, got %+v", active)
+	}
+
+	for i := len(service.GetProfiles("account")); i < maxProfiles; i++ {
+		if _, err := service.CreateProfile("account", models.Profile{Name: strings.Repeat("p", i)}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if _, err := service.CreateProfile("account", models.Profile{Name: "One too many"}); !errors.Is(err, ErrProfileLimit) {
+		t.Errorf("Expected ErrProfileLimit, got %v", err)
+	}
+}
+
+func TestProfileService_BecomeKid(t *testing.T) {
+	service, parental := newTestProfileService()
+	service.SetOwnerPIN("account", "2468")
+
+	teen, _ := service.CreateProfile("account", models.Profile{Name: "Alex"})
+	if parental.GetControls(teen.Key()).Enabled() {
+		t.Fatal("Expected no parental controls for a grown-up profile")
+	}
+
+	// Controls chosen for the profile are kept when it becomes a kid profile
+	parental.SetControls(teen.Key(), models.ParentalControls{MovieCertifications: map[string]string{"US": "PG-13"}})
+	if _, err := service.UpdateProfile("account", teen.ID, models.Profile{Name: "Alex", Kid: true}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if controls := parental.GetControls(teen.Key()); controls.MovieCertifications["US"] != "PG-13" {
+		t.Errorf("Expected the chosen limit to be kept, got %+v", controls)
+	}
+
+	other, _ := service.CreateProfile("account", models.Profile{Name: "Jo"})
+	if _, err := service.UpdateProfile("account", other.ID, models.Profile{Name: "Jo", Kid: true}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if controls := parental.GetControls(other.Key()); controls.TVCertifications["US"] != "TV-Y7" {
+		t.Errorf("Expected the kid profile defaults, got %+v", controls)
+	}
+}
+
+func TestProfileService_OwnerPIN(t *testing.T) {
+	service, _ := newTestProfileService()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	owner := service.ActiveProfile("account")
+
+	// Without a PIN the owner's profile is enough
+	if err := service.RequireOwner("account", owner, ""); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	service.SetOwnerPIN("account", "2468")
+	kid, _ := service.CreateProfile("account", models.Profile{Name: "Sam", Kid: true})
+	teen, _ := service.CreateProfile("account", models.Profile{Name: "Alex"})
+
+	if err := service.RequireOwner("account", owner, ""); !errors.Is(err, ErrProfileForbidden) {
+		t.Errorf("Expected the PIN to be required, got %v", err)
+	}
+	if err := service.RequireOwner("account", teen, "2468"); !errors.Is(err, ErrProfileForbidden) {
+		t.Errorf("Expected other profiles to be refused, got %v", err)
+	}
+	if err := service.RequireOwner("account", owner, "2468"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	// Anyone can switch to the kid profile, but leaving it takes the PIN
+	if _, err := service.SwitchProfile("account", kid.ID, ""); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := service.SelectProfile("account", kid.ID, ""); err != nil {
+		t.Errorf("Expected the kid profile to stay selectable, got %v", err)
+	}
+	for _, id := range []int{owner.ID, teen.ID} {
+		if _, err := service.SelectProfile("account", id, ""); !errors.Is(err, ErrProfileForbidden) {
+			t.Errorf("Expected selecting profile %d to need the PIN, got %v", id, err)
+		}
+		if _, err := service.SwitchProfile("account", id, "1357"); !errors.Is(err, ErrProfileForbidden) {
+			t.Errorf("Expected switching to profile %d with a wrong PIN to fail, got %v", id, err)
+		}
+	}
+	if _, err := service.SelectProfile("account", owner.ID, "2468"); err != nil {
+		t.Errorf("Expected the PIN to select the owner's profile, got %v", err)
+	}
+
+	// Wrong PINs in a row lock PIN entry, even for the right one
+	for i := 0; i < maxOwnerPINAttempts; i++ {
+		service.SwitchProfile("account", owner.ID, "0000")
+	}
+	if _, err := service.SwitchProfile("account", owner.ID, "2468"); !errors.Is(err, ErrProfileForbidden) {
+		t.Errorf("Expected PIN entry to be locked, got %v", err)
+	}
+	now = now.Add(ownerPINLockout)
+	if _, err := service.SwitchProfile("account", owner.ID, "2468"); err != nil {
+		t.Errorf("Expected the PIN to work after the lockout, got %v", err)
+	}
+}
+
+func TestParseOwnerPIN(t *testing.T) {
+	for pin, valid := range map[string]bool{"2468": true, "12345678": true, "123": false, "123456789": false, "12a4": false, "": false} {
+		if _, err := ParseOwnerPIN(pin); (err == nil) != valid {
+			t.Errorf("ParseOwnerPIN(%q): expected valid %v, got %v", pin, valid, err)
+		}
+	}
+}
@@ -1,100 +1,79 @@
 package services
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
-	"os"
 	"time"
 
+	"movie-discovery-app/configs"
 	"movie-discovery-app/internal/models"
 )
 
 // ProvidersService handles watch providers integration
 type ProvidersService struct {
-	tmdbAPIKey string
-	tmdbBaseURL string
-	client     *http.Client
+	tmdbClient *TMDBClient
+	upstream   *upstreamClient
 }
 
-// NewProvidersService creates a new providers service
-func NewProvidersService() *ProvidersService {
+// NewProvidersService creates a new providers service backed by a TMDB client.
+// Provider lookups share TMDB's rate limit but have their own circuit breaker
+// so an outage of the providers endpoint doesn't take down core TMDB calls.
+func NewProvidersService(tmdbClient *TMDBClient, breakerConfig *configs.BreakerConfig) *ProvidersService {
 	return &ProvidersService{
-		tmdbAPIKey:  os.Getenv("TMDB_API_KEY"),
-		tmdbBaseURL: os.Getenv("TMDB_BASE_URL"),
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		tmdbClient: tmdbClient,
+		upstream: newUpstreamClient("TMDB", 10*time.Second, tmdbClient.upstream.rateLimiter,
+			sharedCircuitBreaker("providers", breakerConfig), tmdbClient.upstream.retry),
 	}
 }
 
+// get fetches a TMDB watch providers path through the providers upstream
+func (s *ProvidersService) get(ctx context.Context, path string, out interface{}) error {
+	return s.upstream.getJSON(ctx, s.tmdbClient.requestURL(path, nil), out)
+}
+
 // GetMovieWatchProviders gets watch providers for a movie
-func (s *ProvidersService) GetMovieWatchProviders(movieID int) (*models.WatchProviders, error) {
-	if s.tmdbAPIKey == "" {
+func (s *ProvidersService) GetMovieWatchProviders(ctx context.Context, movieID int) (*models.WatchProviders, error) {
+	if !s.IsConfigured() {
 		return nil, fmt.Errorf("TMDB API key not configured")
 	}
 
-	url := fmt.Sprintf("%s/movie/%d/watch/providers?api_key=%s", s.tmdbBaseURL, movieID, s.tmdbAPIKey)
-	
-	resp, err := s.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get movie watch providers: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API error: %d", resp.StatusCode)
-	}
-
 	var providers models.WatchProviders
-	if err := json.NewDecoder(resp.Body).Decode(&providers); err != nil {
-		return nil, fmt.Errorf("failed to decode watch providers response: %w", err)
+	if err := s.get(ctx, fmt.Sprintf("/movie/%d/watch/providers", movieID), &providers); err != nil {
+		return nil, fmt.Errorf("failed to get movie watch providers: %w", err)
 	}
 
 	return &providers, nil
 }
 
 // GetTVWatchProviders gets watch providers for a TV show
-func (s *ProvidersService) GetTVWatchProviders(tvID int) (*models.WatchProviders, error) {
-	if s.tmdbAPIKey == "" {
+func (s *ProvidersService) GetTVWatchProviders(ctx context.Context, tvID int) (*models.WatchProviders, error) {
+	if !s.IsConfigured() {
 		return nil, fmt.Errorf("TMDB API key not configured")
 	}
 
-	url := fmt.Sprintf("%s/tv/%d/watch/providers?api_key=%s", s.tmdbBaseURL, tvID, s.tmdbAPIKey)
-	
-	resp, err := s.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get TV watch providers: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API error: %d", resp.StatusCode)
-	}
-
 	var providers models.WatchProviders
-	if err := json.NewDecoder(resp.Body).Decode(&providers); err != nil {
-		return nil, fmt.Errorf("failed to decode watch providers response: %w", err)
+	if err := s.get(ctx, fmt.Sprintf("/tv/%d/watch/providers", tvID), &providers); err != nil {
+		return nil, fmt.Errorf("failed to get TV watch providers: %w", err)
 	}
 
 	return &providers, nil
 }
 
 // GetWatchProviders gets watch providers for any media type
-func (s *ProvidersService) GetWatchProviders(mediaID int, mediaType string) (*models.WatchProviders, error) {
+func (s *ProvidersService) GetWatchProviders(ctx context.Context, mediaID int, mediaType string) (*models.WatchProviders, error) {
 	switch mediaType {
 	case "movie":
-		return s.GetMovieWatchProviders(mediaID)
+		return s.GetMovieWatchProviders(ctx, mediaID)
 	case "tv":
-		return s.GetTVWatchProviders(mediaID)
+		return s.GetTVWatchProviders(ctx, mediaID)
 	default:
 		return nil, fmt.Errorf("unsupported media type: %s", mediaType)
 	}
 }
 
 // GetProvidersForRegion gets watch providers for a specific region
-func (s *ProvidersService) GetProvidersForRegion(mediaID int, mediaType string, region string) (*models.WatchProviderRegion, error) {
-	providers, err := s.GetWatchProviders(mediaID, mediaType)
+func (s *ProvidersService) GetProvidersForRegion(ctx context.Context, mediaID int, mediaType string, region string) (*models.WatchProviderRegion, error) {
+	providers, err := s.GetWatchProviders(ctx, mediaID, mediaType)
 	if err != nil {
 		return nil, err
 	}
@@ -107,8 +86,8 @@ func (s *ProvidersService) GetProvidersForRegion(mediaID int, mediaType string,
 }
 
 // GetAvailableRegions gets all available regions for watch providers
-func (s *ProvidersService) GetAvailableRegions(mediaID int, mediaType string) ([]string, error) {
-	providers, err := s.GetWatchProviders(mediaID, mediaType)
+func (s *ProvidersService) GetAvailableRegions(ctx context.Context, mediaID int, mediaType string) ([]string, error) {
+	providers, err := s.GetWatchProviders(ctx, mediaID, mediaType)
 	if err != nil {
 		return nil, err
 	}
@@ -122,8 +101,8 @@ func (s *ProvidersService) GetAvailableRegions(mediaID int, mediaType string) ([
 }
 
 // GetStreamingServices gets only streaming/subscription services for a region
-func (s *ProvidersService) GetStreamingServices(mediaID int, mediaType string, region string) ([]models.WatchProvider, error) {
-	regionProviders, err := s.GetProvidersForRegion(mediaID, mediaType, region)
+func (s *ProvidersService) GetStreamingServices(ctx context.Context, mediaID int, mediaType string, region string) ([]models.WatchProvider, error) {
+	regionProviders, err := s.GetProvidersForRegion(ctx, mediaID, mediaType, region)
 	if err != nil {
 		return nil, err
 	}
@@ -132,8 +111,8 @@ func (s *ProvidersService) GetStreamingServices(mediaID int, mediaType string, r
 }
 
 // GetPurchaseOptions gets purchase and rental options for a region
-func (s *ProvidersService) GetPurchaseOptions(mediaID int, mediaType string, region string) (map[string][]models.WatchProvider, error) {
-	regionProviders, err := s.GetProvidersForRegion(mediaID, mediaType, region)
+func (s *ProvidersService) GetPurchaseOptions(ctx context.Context, mediaID int, mediaType string, region string) (map[string][]models.WatchProvider, error) {
+	regionProviders, err := s.GetProvidersForRegion(ctx, mediaID, mediaType, region)
 	if err != nil {
 		return nil, err
 	}
@@ -151,7 +130,7 @@ func (s *ProvidersService) GetPurchaseOptions(mediaID int, mediaType string, reg
 
 // IsConfigured checks if providers service is properly configured
 func (s *ProvidersService) IsConfigured() bool {
-	return s.tmdbAPIKey != "" && s.tmdbBaseURL != ""
+	return s.tmdbClient.config.APIKey != "" && s.tmdbClient.config.BaseURL != ""
 }
 
 // GetProviderLogoURL constructs the full URL for a provider logo
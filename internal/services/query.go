@@ -0,0 +1,315 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Search query length limits. TMDB rejects long search text, but structured
+// field terms don't count towards it.
+const (
+	maxSearchTextLength       = 100
+	maxStructuredQueryLength  = 500
+	maxQueryFieldEditDistance = 2
+)
+
+// Structured query fields
+const (
+	QueryFieldActor    = "actor"
+	QueryFieldDirector = "director"
+	QueryFieldGenre    = "genre"
+	QueryFieldLanguage = "language"
+	QueryFieldOn       = "on"
+	QueryFieldRating   = "rating"
+	QueryFieldRegion   = "region"
+	QueryFieldRuntime  = "runtime"
+	QueryFieldYear     = "year"
+)
+
+// queryFields maps field names and aliases to fields
+var queryFields = map[string]string{
+	"actor":    QueryFieldActor,
+	"cast":     QueryFieldActor,
+	"director": QueryFieldDirector,
+	"genre":    QueryFieldGenre,
+	"language": QueryFieldLanguage,
+	"lang":     QueryFieldLanguage,
+	"on":       QueryFieldOn,
+	"provider": QueryFieldOn,
+	"rating":   QueryFieldRating,
+	"region":   QueryFieldRegion,
+	"runtime":  QueryFieldRuntime,
+	"year":     QueryFieldYear,
+}
+
+// numericQueryFields take comparisons (year:>2015) and ranges (year:2010..2015)
+var numericQueryFields = map[string]bool{
+	QueryFieldRating:  true,
+	QueryFieldRuntime: true,
+	QueryFieldYear:    true,
+}
+
+// QueryError is a search query the user needs to fix
+type QueryError struct {
+	Message string
+}
+
+func (e *QueryError) Error() string {
+	return e.Message
+}
+
+func queryErrorf(format string, args ...interface{}) *QueryError {
+	return &QueryError{Message: fmt.Sprintf(format, args...)}
+}
+
+// QueryTerm is a field:value term of a search query. Numeric fields carry a
+// comparison operator and the parsed number.
+type QueryTerm struct {
+	Field  string
+	Op     string // =, >, >=, < or <= for numeric fields
+	Value  string
+	Number float64
+}
+
+// SearchQuery is a parsed search query: free text plus field terms
+type SearchQuery struct {
+	Text  string
+	Terms []QueryTerm
+}
+
+// Structured reports whether the query has field terms
+func (q *SearchQuery) Structured() bool {
+	return len(q.Terms) > 0
+}
+
+// ParseSearchQuery parses a search query such as
+//
+//	director:"Denis Villeneuve" year:>2015 genre:scifi rating:>7 on:netflix
+//
+// Words and "quoted phrases" outside of field terms are free text. A
+// name:value token is only a field term when name is a known field, so
+// titles like "Mission:Impossible" stay plain text; a name that is a typo
+// of a field is an error.
+func ParseSearchQuery(input string) (*SearchQuery, error) {
+	query := &SearchQuery{}
+	var text []string
+
+	for pos := 0; pos < len(input); {
+		r, size := utf8.DecodeRuneInString(input[pos:])
+		if unicode.IsSpace(r) {
+			pos += size
+			continue
+		}
+
+		// An unterminated phrase runs to the end, as plain text search would
+		if r == '"' {
+			phrase, next, err := readQuoted(input, pos)
+			if err != nil {
+				phrase, next = input[pos+1:], len(input)
+			}
+			text = append(text, strings.Fields(phrase)...)
+			pos = next
+			continue
+		}
+
+		name, value, next, isField, err := readFieldTerm(input, pos)
+		if err != nil {
+			return nil, err
+		}
+		if !isField {
+			word := readWord(input, pos)
+			text = append(text, word)
+			pos += len(word)
+			continue
+		}
+
+		terms, err := parseQueryTerm(name, value)
+		if err != nil {
+			return nil, err
+		}
+		query.Terms = append(query.Terms, terms...)
+		pos = next
+	}
+
+	query.Text = strings.Join(text, " ")
+	return query, nil
+}
+
+// readQuoted reads the quoted string starting at input[pos], returning its
+// contents and the position after the closing quote
+func readQuoted(input string, pos int) (string, int, error) {
+	end := strings.IndexByte(input[pos+1:], '"')
+	if end < 0 {
+		return "", 0, queryErrorf("unterminated quote at position %d", pos+1)
+	}
+	return input[pos+1 : pos+1+end], pos + end + 2, nil
+}
+
+// readWord reads up to the next whitespace
+func readWord(input string, pos int) string {
+	end := strings.IndexFunc(input[pos:], unicode.IsSpace)
+	if end < 0 {
+		return input[pos:]
+	}
+	return input[pos : pos+end]
+}
+
+// readFieldTerm reads a name:value token at input[pos]. isField is false
+// when the token is plain text: there is no name, no value right after the
+// colon, or the name is neither a field nor close to one.
+func readFieldTerm(input string, pos int) (name, value string, next int, isField bool, err error) {
+	end := pos
+	for end < len(input) && (isASCIILetter(input[end]) || input[end] == '_') {
+		end++
+	}
+	if end == pos || end+1 >= len(input) || input[end] != ':' {
+		return "", "", 0, false, nil
+	}
+	if r, _ := utf8.DecodeRuneInString(input[end+1:]); unicode.IsSpace(r) {
+		return "", "", 0, false, nil
+	}
+
+	name = strings.ToLower(input[pos:end])
+	field, known := queryFields[name]
+	if !known {
+		if suggestion := closestQueryField(name); suggestion != "" {
+			return "", "", 0, false, queryErrorf("unknown field %q (did you mean %q?)", name, suggestion)
+		}
+		return "", "", 0, false, nil
+	}
+
+	start := end + 1
+	if input[start] == '"' {
+		value, next, err = readQuoted(input, start)
+		if err != nil {
+			return "", "", 0, false, err
+		}
+	} else {
+		value = readWord(input, start)
+		next = start + len(value)
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", "", 0, false, queryErrorf("%s: needs a value", name)
+	}
+	return field, value, next, true, nil
+}
+
+// parseQueryTerm parses a field's value. A numeric range becomes a >= and a
+// <= term.
+func parseQueryTerm(field, value string) ([]QueryTerm, error) {
+	if !numericQueryFields[field] {
+		return []QueryTerm{{Field: field, Op: "=", Value: value}}, nil
+	}
+
+	if low, high, isRange := strings.Cut(value, ".."); isRange {
+		lowTerm, err := parseNumericTerm(field, ">=", low)
+		if err != nil {
+			return nil, err
+		}
+		highTerm, err := parseNumericTerm(field, "<=", high)
+		if err != nil {
+			return nil, err
+		}
+		if lowTerm.Number > highTerm.Number {
+			return nil, queryErrorf("%s: range %s is backwards", field, value)
+		}
+		return []QueryTerm{lowTerm, highTerm}, nil
+	}
+
+	op := "="
+	for _, candidate := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, candidate) {
+			op = candidate
+			value = value[len(candidate):]
+			break
+		}
+	}
+	term, err := parseNumericTerm(field, op, value)
+	if err != nil {
+		return nil, err
+	}
+	return []QueryTerm{term}, nil
+}
+
+// parseNumericTerm parses and range-checks a number for a numeric field
+func parseNumericTerm(field, op, value string) (QueryTerm, error) {
+	term := QueryTerm{Field: field, Op: op, Value: value}
+
+	switch field {
+	case QueryFieldYear:
+		year, err := strconv.Atoi(value)
+		if maxYear := maxReleaseYear(); err != nil || year < minReleaseYear || year > maxYear {
+			return term, queryErrorf("year: %q is not a year between %d and %d", value, minReleaseYear, maxYear)
+		}
+		term.Number = float64(year)
+	case QueryFieldRuntime:
+		minutes, err := strconv.Atoi(value)
+		if err != nil || minutes < 0 || minutes > 1000 {
+			return term, queryErrorf("runtime: %q is not a number of minutes", value)
+		}
+		term.Number = float64(minutes)
+	case QueryFieldRating:
+		rating, err := strconv.ParseFloat(value, 64)
+		if err != nil || !(rating >= 0 && rating <= 10) {
+			return term, queryErrorf("rating: %q is not a rating between 0 and 10", value)
+		}
+		term.Number = rating
+	}
+
+	return term, nil
+}
+
+// closestQueryField returns the field name a typo most likely meant, or ""
+// if no field name is close. Short names are never typos, so words like
+// "One:" in titles stay text.
+func closestQueryField(name string) string {
+	if len(name) < 4 {
+		return ""
+	}
+
+	names := make([]string, 0, len(queryFields))
+	for candidate := range queryFields {
+		names = append(names, candidate)
+	}
+	sort.Strings(names)
+
+	best, bestDistance := "", maxQueryFieldEditDistance+1
+	for _, candidate := range names {
+		if distance := editDistance(name, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two ASCII strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		text  string
+		terms []QueryTerm
+	}{
+		{
+			input: `director:"Denis Villeneuve" year:>2015 genre:scifi rating:>7 on:netflix`,
+			terms: []QueryTerm{
+				{Field: "director", Op: "=", Value: "Denis Villeneuve"},
+				{Field: "year", Op: ">", Value: "2015", Number: 2015},
+				{Field: "genre", Op: "=", Value: "scifi"},
+				{Field: "rating", Op: ">", Value: "7", Number: 7},
+				{Field: "on", Op: "=", Value: "netflix"},
+			},
+		},
+		{
+			input: `  dune   CAST:Zendaya "part two" year:2020..2024 lang:en  `,
+			text:  "dune part two",
+			terms: []QueryTerm{
+				{Field: "actor", Op: "=", Value: "Zendaya"},
+				{Field: "year", Op: ">=", Value: "2020", Number: 2020},
+				{Field: "year", Op: "<=", Value: "2024", Number: 2024},
+				{Field: "language", Op: "=", Value: "en"},
+			},
+		},
+		{input: "Mission: Impossible", text: "Mission: Impossible"},
+		{input: "Mission:Impossible", text: "Mission:Impossible"},
+		{input: "One:Piece", text: "One:Piece"},
+		{input: "2001: A Space Odyssey", text: "2001: A Space Odyssey"},
+		{input: `the "thing`, text: "the thing"},
+		{input: `5" crawl`, text: `5" crawl`},
+	}
+
+	for _, tt := range tests {
+		query, err := ParseSearchQuery(tt.input)
+		if err != nil {
+			t.Errorf("%q: expected no error, got %v", tt.input, err)
+			continue
+		}
+		if query.Text != tt.text {
+			t.Errorf("%q: expected text %q, got %q", tt.input, tt.text, query.Text)
+		}
+		if !reflect.DeepEqual(query.Terms, tt.terms) {
+			t.Errorf("%q: expected terms %+v, got %+v", tt.input, tt.terms, query.Terms)
+		}
+	}
+}
+
+func TestParseSearchQuery_Errors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"directr:Villeneuve", `unknown field "directr" (did you mean "director"?)`},
+		{"raiting:>7", `unknown field "raiting" (did you mean "rating"?)`},
+		{`director:"Denis Villeneuve`, "unterminated quote"},
+		{`director:""`, "director: needs a value"},
+		{"year:>twenty", "is not a year"},
+		{"year:1850", "is not a year"},
+		{"rating:11", "is not a rating"},
+		{"rating:NaN", "is not a rating"},
+		{"runtime:long", "is not a number of minutes"},
+		{"year:2020..2010", "is backwards"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseSearchQuery(tt.input)
+		var queryErr *QueryError
+		if !errors.As(err, &queryErr) {
+			t.Errorf("%q: expected a QueryError, got %v", tt.input, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%q: expected an error containing %q, got %q", tt.input, tt.message, err.Error())
+		}
+	}
+}
+
+func TestParseSearchQuery_YearBound(t *testing.T) {
+	// Announced titles a few years out are accepted, by queries and filters alike
+	latest := time.Now().Year() + releaseYearLookahead
+	if maxReleaseYear() != latest {
+		t.Fatalf("Expected the latest year to be %d, got %d", latest, maxReleaseYear())
+	}
+
+	if _, err := ParseSearchQuery(fmt.Sprintf("year:%d", latest)); err != nil {
+		t.Errorf("Expected %d to be accepted, got %v", latest, err)
+	}
+	if _, err := ParseSearchQuery(fmt.Sprintf("year:%d", latest+1)); err == nil || !strings.Contains(err.Error(), fmt.Sprintf("between 1900 and %d", latest)) {
+		t.Errorf("Expected %d to be rejected, got %v", latest+1, err)
+	}
+
+	filters := GetDefaultFilters()
+	if filters.MaxYear != latest {
+		t.Errorf("Expected the default max year to be %d, got %d", latest, filters.MaxYear)
+	}
+	filters.MinYear, filters.MaxYear = 1850, latest+10
+	if err := filters.Validate(); err != nil || filters.MinYear != 1900 || filters.MaxYear != latest {
+		t.Errorf("Expected the years to be clamped to 1900-%d, got %d-%d, %v", latest, filters.MinYear, filters.MaxYear, err)
+	}
+}
+
+func FuzzParseSearchQuery(f *testing.F) {
+	for _, seed := range []string{
+		`director:"Denis Villeneuve" year:>2015 genre:scifi rating:>7 on:netflix`,
+		`dune CAST:Zendaya "part two" year:2020..2024`,
+		"Mission: Impossible",
+		`the "thing`,
+		"rating:>=7.5 runtime:<=120",
+		"year:..",
+		"on:",
+		`director:"`,
+		"directr:x",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		query, err := ParseSearchQuery(input)
+		if err != nil {
+			var queryErr *QueryError
+			if !errors.As(err, &queryErr) {
+				t.Fatalf("Expected a QueryError, got %T: %v", err, err)
+			}
+			return
+		}
+
+		if query.Text != strings.Join(strings.Fields(query.Text), " ") {
+			t.Errorf("Text is not whitespace-normalized: %q", query.Text)
+		}
+		if len(query.Text) > len(input) {
+			t.Errorf("Text %q is longer than the input %q", query.Text, input)
+		}
+		if utf8.ValidString(input) && !utf8.ValidString(query.Text) {
+			t.Errorf("Text %q is not valid UTF-8", query.Text)
+		}
+
+		for _, term := range query.Terms {
+			if _, known := queryFields[term.Field]; !known || queryFields[term.Field] != term.Field {
+				t.Errorf("Term has a non-canonical field: %+v", term)
+			}
+			if term.Value == "" {
+				t.Errorf("Term has an empty value: %+v", term)
+			}
+			switch term.Op {
+			case "=", ">", ">=", "<", "<=":
+			default:
+				t.Errorf("Term has an unknown operator: %+v", term)
+			}
+		}
+
+		// Without colons there are no fields, and without quotes the text is
+		// the input's words
+		if !strings.ContainsAny(input, `:"`) {
+			if query.Structured() {
+				t.Errorf("Expected no terms for %q, got %+v", input, query.Terms)
+			}
+			if query.Text != strings.Join(strings.Fields(input), " ") {
+				t.Errorf("Expected the input's words for %q, got %q", input, query.Text)
+			}
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExhausted is returned when a call would exceed the daily quota
+var ErrQuotaExhausted = errors.New("daily quota exhausted")
+
+// YouTube Data API quota costs per call
+const (
+	youtubeSearchCost = 100
+	youtubeVideosCost = 1
+)
+
+// quotaLocation is where the YouTube quota day starts: it resets at midnight
+// Pacific Time
+var quotaLocation = loadQuotaLocation()
+
+func loadQuotaLocation() *time.Location {
+	if location, err := time.LoadLocation("America/Los_Angeles"); err == nil {
+		return location
+	}
+	// Without tzdata, ignore daylight saving time
+	return time.FixedZone("PST", -8*60*60)
+}
+
+// QuotaTracker tracks units spent against a daily API quota
+type QuotaTracker struct {
+	mu     sync.Mutex
+	budget int
+	used   int
+	day    string
+	now    func() time.Time
+}
+
+// QuotaSnapshot is a point-in-time view of a QuotaTracker
+type QuotaSnapshot struct {
+	Budget    int       `json:"budget"`
+	Used      int       `json:"used"`
+	Remaining int       `json:"remaining"`
+	Exhausted bool      `json:"exhausted"` // Too little left for a search
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// NewQuotaTracker creates a tracker for a daily budget of units
+func NewQuotaTracker(budget int) *QuotaTracker {
+	return &QuotaTracker{
+		budget: budget,
+		now:    time.Now,
+	}
+}
+
+// Reserve spends cost units if the budget has room for them. Units are
+// spent whether or not the call then succeeds, as the API charges for
+// failed calls too.
+func (q *QuotaTracker) Reserve(cost int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover()
+	if q.used+cost > q.budget {
+		return false
+	}
+	q.used += cost
+	return true
+}
+
+// Snapshot returns the quota spent so far today
+func (q *QuotaTracker) Snapshot() QuotaSnapshot {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover()
+	remaining := q.budget - q.used
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	now := q.now().In(quotaLocation)
+	return QuotaSnapshot{
+		Budget:    q.budget,
+		Used:      q.used,
+		Remaining: remaining,
+		Exhausted: remaining < youtubeSearchCost,
+		ResetsAt:  time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, quotaLocation).UTC(),
+	}
+}
+
+// rollover resets usage when a new quota day has started
+func (q *QuotaTracker) rollover() {
+	day := q.now().In(quotaLocation).Format("2006-01-02")
+	if day != q.day {
+		q.day = day
+		q.used = 0
+	}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"movie-discovery-app/configs"
+)
+
+// ErrRateLimitExceeded is returned when a request cannot be admitted before
+// the caller's deadline
+var ErrRateLimitExceeded = errors.New("rate limit exceeded")
+
+// RateLimiter implements a token-bucket rate limiter. Tokens refill
+// continuously at the configured rate up to the burst size, and Wait blocks
+// until a token is available or the caller's context is done.
+type RateLimiter struct {
+	mu         sync.Mutex
+	tokens     float64
+	burst      int
+	refillRate float64 // tokens per second
+	lastRefill time.Time
+}
+
+// NewRateLimiter creates a token-bucket limiter admitting requestsPerMinute
+// on average with bursts of up to burst requests
+func NewRateLimiter(requestsPerMinute int, burst int) *RateLimiter {
+	if requestsPerMinute <= 0 {
+		requestsPerMinute = 60
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		tokens:     float64(burst),
+		burst:      burst,
+		refillRate: float64(requestsPerMinute) / time.Minute.Seconds(),
+		lastRefill: time.Now(),
+	}
+}
+
+// sharedLimiter is a shared limiter and the config it was created from
+type sharedLimiter struct {
+	limiter *RateLimiter
+	config  configs.RateLimitConfig
+}
+
+// Shared limiters, one per upstream API, so that every client talking to the
+// same upstream draws from the same budget
+var (
+	sharedLimitersMu sync.Mutex
+	sharedLimiters   = make(map[string]sharedLimiter)
+)
+
+// sharedRateLimiter returns the process-wide limiter for the named upstream,
+// creating it from rateConfig on first use. The first config wins: a client
+// passing a different one still shares the existing limiter, and the
+// conflict is logged.
+func sharedRateLimiter(upstream string, rateConfig *configs.RateLimitConfig) *RateLimiter {
+	sharedLimitersMu.Lock()
+	defer sharedLimitersMu.Unlock()
+
+	if shared, exists := sharedLimiters[upstream]; exists {
+		if *rateConfig != shared.config {
+			log.Printf("Ignoring rate limit %+v for %s: its shared limiter already uses %+v", *rateConfig, upstream, shared.config)
+		}
+		return shared.limiter
+	}
+
+	limiter := NewRateLimiter(rateConfig.RequestsPerMinute, rateConfig.Burst)
+	sharedLimiters[upstream] = sharedLimiter{limiter: limiter, config: *rateConfig}
+	return limiter
+}
+
+// Wait blocks until a token is available or ctx is done. If ctx has a
+// deadline that will pass before the next token refills, Wait fails fast
+// with ErrRateLimitExceeded instead of sleeping.
+func (rl *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		rl.mu.Lock()
+		rl.refill(time.Now())
+		if rl.tokens >= 1 {
+			rl.tokens--
+			rl.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - rl.tokens) / rl.refillRate * float64(time.Second))
+		rl.mu.Unlock()
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return ErrRateLimitExceeded
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %v", ErrRateLimitExceeded, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// Remaining returns the number of whole tokens currently available
+func (rl *RateLimiter) Remaining() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.refill(time.Now())
+	return int(rl.tokens)
+}
+
+// Burst returns the maximum number of tokens the bucket can hold
+func (rl *RateLimiter) Burst() int {
+	return rl.burst
+}
+
+// refill adds the tokens accrued since the last refill. Callers must hold mu.
+func (rl *RateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(rl.lastRefill).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+
+	rl.tokens += elapsed * rl.refillRate
+	if rl.tokens > float64(rl.burst) {
+		rl.tokens = float64(rl.burst)
+	}
+	rl.lastRefill = now
+}
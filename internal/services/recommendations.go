@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"sort"
@@ -8,17 +9,25 @@ import (
 	"movie-discovery-app/internal/models"
 )
 
+// How much a recently viewed title counts towards preferences compared to a
+// watchlist item
+const recentViewWeight = 0.25
+
 // RecommendationService provides movie/TV show recommendations
 type RecommendationService struct {
 	discoveryService *DiscoveryService
 	watchlistService *WatchlistService
+	historyService   *HistoryService
+	parentalService  *ParentalControlService
 }
 
 // NewRecommendationService creates a new recommendation service
-func NewRecommendationService(discoveryService *DiscoveryService, watchlistService *WatchlistService) *RecommendationService {
+func NewRecommendationService(discoveryService *DiscoveryService, watchlistService *WatchlistService, historyService *HistoryService, parentalService *ParentalControlService) *RecommendationService {
 	return &RecommendationService{
 		discoveryService: discoveryService,
 		watchlistService: watchlistService,
+		historyService:   historyService,
+		parentalService:  parentalService,
 	}
 }
 
@@ -30,23 +39,26 @@ type RecommendationScore struct {
 }
 
 // GetRecommendations gets personalized recommendations for a user
-func (s *RecommendationService) GetRecommendations(userID string, limit int) ([]RecommendationScore, error) {
+func (s *RecommendationService) GetRecommendations(ctx context.Context, userID string, limit int) ([]RecommendationScore, error) {
 	// Get user's watchlist to understand preferences
 	watchlist, err := s.watchlistService.GetWatchlist(userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user watchlist: %w", err)
 	}
 
-	if len(watchlist) == 0 {
-		// No watchlist data, return trending content as fallback
-		return s.getTrendingRecommendations(limit)
+	// Recently viewed titles are a weak sign of interest
+	views := s.historyService.GetRecentViews(userID)
+
+	if len(watchlist) == 0 && len(views) == 0 {
+		// No watchlist or history data, return trending content as fallback
+		return s.getTrendingRecommendations(ctx, userID, limit)
 	}
 
 	// Analyze user preferences
-	preferences := s.analyzeUserPreferences(watchlist)
+	preferences := s.analyzeUserPreferences(watchlist, views)
 	
 	// Get recommendations based on preferences
-	recommendations := s.generateRecommendations(preferences, limit)
+	recommendations := s.generateRecommendations(ctx, userID, preferences, limit)
 	
 	return recommendations, nil
 }
@@ -60,15 +72,16 @@ type UserPreferences struct {
 	AverageRating     float64         // user's average rating
 }
 
-// analyzeUserPreferences analyzes user's watchlist to determine preferences
-func (s *RecommendationService) analyzeUserPreferences(watchlist []models.WatchlistItem) *UserPreferences {
+// analyzeUserPreferences analyzes user's watchlist and recently viewed
+// titles to determine preferences
+func (s *RecommendationService) analyzeUserPreferences(watchlist []models.WatchlistItem, views []models.RecentView) *UserPreferences {
 	preferences := &UserPreferences{
 		FavoriteGenres: make(map[int]float64),
 	}
 
 	var totalRating float64
 	var ratedItems int
-	var movieCount, tvCount int
+	var movieCount, tvCount float64
 
 	for _, item := range watchlist {
 		// Count movie vs TV preference
@@ -89,10 +102,23 @@ func (s *RecommendationService) analyzeUserPreferences(watchlist []models.Watchl
 		// to get genre information. For this demo, we'll use simplified logic.
 	}
 
+	// Recently viewed titles count for less than watchlist items
+	for _, view := range views {
+		if view.MediaType == "movie" {
+			movieCount += recentViewWeight
+		} else {
+			tvCount += recentViewWeight
+		}
+
+		for _, genreID := range view.GenreIDs {
+			preferences.FavoriteGenres[genreID] += recentViewWeight
+		}
+	}
+
 	// Calculate movie vs TV ratio
 	total := movieCount + tvCount
 	if total > 0 {
-		preferences.MovieVsTVRatio = float64(movieCount) / float64(total)
+		preferences.MovieVsTVRatio = movieCount / total
 	}
 
 	// Calculate average rating
@@ -104,16 +130,16 @@ func (s *RecommendationService) analyzeUserPreferences(watchlist []models.Watchl
 }
 
 // generateRecommendations generates recommendations based on user preferences
-func (s *RecommendationService) generateRecommendations(preferences *UserPreferences, limit int) []RecommendationScore {
+func (s *RecommendationService) generateRecommendations(ctx context.Context, userID string, preferences *UserPreferences, limit int) []RecommendationScore {
 	var recommendations []RecommendationScore
 
 	// For this demo, we'll use trending content and apply preference-based scoring
 	// In a real system, you'd use collaborative filtering, content-based filtering, etc.
 
 	// Get trending movies
-	trendingMovies, err := s.discoveryService.GetTrendingMovies("week", 1)
-	if err == nil && trendingMovies.Results != nil {
-		for _, item := range trendingMovies.Results {
+	trendingMovies, err := s.trendingMovies(ctx, userID)
+	if err == nil {
+		for _, item := range trendingMovies {
 			if movieData, ok := item.(map[string]interface{}); ok {
 				score := s.calculateRecommendationScore(movieData, preferences, "movie")
 				recommendations = append(recommendations, RecommendationScore{
@@ -166,24 +192,46 @@ func (s *RecommendationService) calculateRecommendationScore(item map[string]int
 		}
 	}
 
-	// Genre matching would go here if we had genre data
-	// For now, we'll use a simplified approach
+	// Genre matching, with genres known from recently viewed titles
+	if genreIDs, ok := item["genre_ids"].([]interface{}); ok {
+		score += genreAffinity(genreIDs, preferences.FavoriteGenres)
+	}
 
 	return score
 }
 
+// genreAffinity scores from 0 to 1 how much of the user's genre preference
+// an item's genres cover
+func genreAffinity(genreIDs []interface{}, favoriteGenres map[int]float64) float64 {
+	var total float64
+	for _, weight := range favoriteGenres {
+		total += weight
+	}
+	if total == 0 {
+		return 0
+	}
+
+	var matched float64
+	for _, genreID := range genreIDs {
+		if genreID, ok := genreID.(float64); ok {
+			matched += favoriteGenres[int(genreID)]
+		}
+	}
+	return matched / total
+}
+
 // getTrendingRecommendations returns trending content as fallback recommendations
-func (s *RecommendationService) getTrendingRecommendations(limit int) ([]RecommendationScore, error) {
+func (s *RecommendationService) getTrendingRecommendations(ctx context.Context, userID string, limit int) ([]RecommendationScore, error) {
 	var recommendations []RecommendationScore
 
 	// Get trending movies
-	trendingMovies, err := s.discoveryService.GetTrendingMovies("week", 1)
+	trendingMovies, err := s.trendingMovies(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get trending movies: %w", err)
 	}
 
-	if trendingMovies.Results != nil {
-		for i, item := range trendingMovies.Results {
+	if trendingMovies != nil {
+		for i, item := range trendingMovies {
 			if i >= limit {
 				break
 			}
@@ -210,18 +258,30 @@ func (s *RecommendationService) getTrendingRecommendations(limit int) ([]Recomme
 	return recommendations, nil
 }
 
+// trendingMovies gets this week's trending movies that the user's parental
+// controls allow
+func (s *RecommendationService) trendingMovies(ctx context.Context, userID string) ([]interface{}, error) {
+	trending, err := s.discoveryService.GetTrendingMovies(ctx, "week", 1)
+	if err != nil {
+		return nil, err
+	}
+	return s.parentalService.FilterResults(ctx, userID, "movie", trending.Results)
+}
+
 // GetSimilarMovies gets movies similar to a given movie
-func (s *RecommendationService) GetSimilarMovies(movieID int, limit int) ([]RecommendationScore, error) {
+func (s *RecommendationService) GetSimilarMovies(ctx context.Context, movieID int, limit int) ([]RecommendationScore, error) {
 	// In a real implementation, you'd use the TMDB "similar movies" endpoint
-	// For this demo, we'll return trending movies as similar content
-	return s.getTrendingRecommendations(limit)
+	// For this demo, we'll return trending movies as similar content, with no
+	// profile's parental controls applied
+	return s.getTrendingRecommendations(ctx, "", limit)
 }
 
 // GetRecommendationsByGenre gets recommendations for a specific genre
-func (s *RecommendationService) GetRecommendationsByGenre(genreID int, limit int) ([]RecommendationScore, error) {
+func (s *RecommendationService) GetRecommendationsByGenre(ctx context.Context, genreID int, limit int) ([]RecommendationScore, error) {
 	// In a real implementation, you'd filter by genre
-	// For this demo, we'll return trending content
-	return s.getTrendingRecommendations(limit)
+	// For this demo, we'll return trending content, with no profile's
+	// parental controls applied
+	return s.getTrendingRecommendations(ctx, "", limit)
 }
 
 // RecommendationExplanation provides explanation for why an item was recommended
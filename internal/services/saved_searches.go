@@ -0,0 +1,422 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
+)
+
+// Saved search errors
+var (
+	ErrSavedSearchNotFound = errors.New("saved search not found")
+	ErrSavedSearchLimit    = fmt.Errorf("no more than %d saved searches per user", maxSavedSearches)
+)
+
+// Saved search limits
+const (
+	maxSavedSearches           = 20
+	maxSavedSearchName         = 100
+	maxSavedSearchPages        = 3   // Result pages checked for new matches on each run
+	maxSavedSearchInbox        = 200 // New matches kept per user
+	savedSearchTick            = time.Minute
+	defaultSavedSearchInterval = 6 * time.Hour
+)
+
+// SavedSearchService keeps users' saved searches, re-runs them on a
+// schedule and collects titles that newly match them in each user's inbox
+type SavedSearchService struct {
+	discoveryService *DiscoveryService
+	interval         time.Duration // Time between runs of each saved search
+	clock            Clock
+
+	mu       sync.Mutex
+	nextID   int
+	searches map[string][]*savedSearch            // userID -> saved searches
+	inboxes  map[string][]models.SavedSearchMatch // userID -> new matches, newest first
+}
+
+// savedSearch is a saved search and the titles it has matched so far
+type savedSearch struct {
+	models.SavedSearch
+	seen     map[int]bool // IDs matched so far; nil until the first run
+	revision int          // Bumped when the query changes, to discard runs of the old one
+	running  bool
+}
+
+// NewSavedSearchService creates a new saved search service
+func NewSavedSearchService(config *configs.Config, discoveryService *DiscoveryService) *SavedSearchService {
+	interval := config.SavedSearches.Interval
+	if interval <= 0 {
+		interval = defaultSavedSearchInterval
+	}
+
+	return &SavedSearchService{
+		discoveryService: discoveryService,
+		interval:         interval,
+		clock:            realClock{},
+		searches:         make(map[string][]*savedSearch),
+		inboxes:          make(map[string][]models.SavedSearchMatch),
+	}
+}
+
+// ParseSavedSearch validates a saved search from a request. It must have a
+// name, a media type and either a search query or discover filters, and may
+// have a language and region to run in. Discover filters are normalized and
+// any page parameter is dropped.
+func (s *SavedSearchService) ParseSavedSearch(search models.SavedSearch) (models.SavedSearch, error) {
+	parsed := models.SavedSearch{
+		Name:      strings.TrimSpace(search.Name),
+		MediaType: search.MediaType,
+		Query:     strings.TrimSpace(search.Query),
+		Discover:  strings.TrimSpace(search.Discover),
+	}
+
+	if parsed.Name == "" {
+		return parsed, fmt.Errorf("name is required")
+	}
+	if len(parsed.Name) > maxSavedSearchName {
+		return parsed, fmt.Errorf("name too long (max %d characters)", maxSavedSearchName)
+	}
+	if parsed.MediaType != "movie" && parsed.MediaType != "tv" {
+		return parsed, fmt.Errorf("media_type must be movie or tv")
+	}
+	if (parsed.Query == "") == (parsed.Discover == "") {
+		return parsed, fmt.Errorf("either query or discover is required, but not both")
+	}
+
+	locale, err := ParseLocale(search.Language, search.Region, "")
+	if err != nil {
+		return parsed, err
+	}
+	parsed.Language, parsed.Region = locale.Language, locale.Region
+
+	if parsed.Query != "" {
+		if err := s.discoveryService.ValidateSearchQuery(parsed.Query); err != nil {
+			return parsed, err
+		}
+		if _, err := ParseSearchQuery(parsed.Query); err != nil {
+			return parsed, err
+		}
+		return parsed, nil
+	}
+
+	params, err := url.ParseQuery(parsed.Discover)
+	if err != nil {
+		return parsed, fmt.Errorf("discover must be URL query parameters")
+	}
+	params.Del("page")
+	if _, _, err := ParseDiscoveryFilters(parsed.MediaType, params); err != nil {
+		return parsed, err
+	}
+	parsed.Discover = params.Encode()
+
+	return parsed, nil
+}
+
+// CreateSavedSearch saves a search parsed by ParseSavedSearch. Its first run
+// is due straight away and records what already matches, so only titles
+// that match later are reported as new.
+func (s *SavedSearchService) CreateSavedSearch(userID string, search models.SavedSearch) (models.SavedSearch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.searches[userID]) >= maxSavedSearches {
+		return models.SavedSearch{}, ErrSavedSearchLimit
+	}
+
+	s.nextID++
+	now := s.clock.Now()
+	saved := &savedSearch{SavedSearch: models.SavedSearch{
+		ID:        s.nextID,
+		Name:      search.Name,
+		MediaType: search.MediaType,
+		Query:     search.Query,
+		Discover:  search.Discover,
+		Language:  search.Language,
+		Region:    search.Region,
+		CreatedAt: now,
+		NextRunAt: now,
+	}}
+	s.searches[userID] = append(s.searches[userID], saved)
+
+	return saved.SavedSearch, nil
+}
+
+// GetSavedSearches returns a user's saved searches in the order they were
+// saved
+func (s *SavedSearchService) GetSavedSearches(userID string) []models.SavedSearch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	searches := make([]models.SavedSearch, 0, len(s.searches[userID]))
+	for _, saved := range s.searches[userID] {
+		searches = append(searches, saved.SavedSearch)
+	}
+	return searches
+}
+
+// GetSavedSearch returns one of a user's saved searches
+func (s *SavedSearchService) GetSavedSearch(userID string, id int) (models.SavedSearch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := s.find(userID, id)
+	if saved == nil {
+		return models.SavedSearch{}, ErrSavedSearchNotFound
+	}
+	return saved.SavedSearch, nil
+}
+
+// UpdateSavedSearch replaces a saved search with one parsed by
+// ParseSavedSearch. Changing what it searches for starts it afresh, as if
+// it had just been saved.
+func (s *SavedSearchService) UpdateSavedSearch(userID string, id int, search models.SavedSearch) (models.SavedSearch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := s.find(userID, id)
+	if saved == nil {
+		return models.SavedSearch{}, ErrSavedSearchNotFound
+	}
+
+	// The language only changes how titles are named, not which match
+	saved.Name = search.Name
+	saved.Language = search.Language
+	if saved.MediaType != search.MediaType || saved.Query != search.Query || saved.Discover != search.Discover || saved.Region != search.Region {
+		saved.MediaType = search.MediaType
+		saved.Query = search.Query
+		saved.Discover = search.Discover
+		saved.Region = search.Region
+		saved.seen = nil
+		saved.revision++
+		saved.LastRunAt = nil
+		saved.LastError = ""
+		saved.NextRunAt = s.clock.Now()
+	}
+
+	return saved.SavedSearch, nil
+}
+
+// DeleteSavedSearch removes a saved search and its matches from the inbox
+func (s *SavedSearchService) DeleteSavedSearch(userID string, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	searches := s.searches[userID]
+	for i, saved := range searches {
+		if saved.ID == id {
+			s.searches[userID] = append(searches[:i], searches[i+1:]...)
+
+			inbox := s.inboxes[userID][:0]
+			for _, match := range s.inboxes[userID] {
+				if match.SearchID != id {
+					inbox = append(inbox, match)
+				}
+			}
+			s.inboxes[userID] = inbox
+			return nil
+		}
+	}
+	return ErrSavedSearchNotFound
+}
+
+// DeleteSavedSearches removes all of a user's saved searches and their inbox
+func (s *SavedSearchService) DeleteSavedSearches(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.searches, userID)
+	delete(s.inboxes, userID)
+}
+
+// GetInbox returns a user's new saved search matches, newest first
+func (s *SavedSearchService) GetInbox(userID string) models.SavedSearchInbox {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return models.SavedSearchInbox{Matches: append([]models.SavedSearchMatch{}, s.inboxes[userID]...)}
+}
+
+// ClearInbox empties a user's inbox
+func (s *SavedSearchService) ClearInbox(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.inboxes, userID)
+}
+
+// Run re-runs due saved searches every minute until ctx is done
+func (s *SavedSearchService) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(savedSearchTick):
+			s.runDue(ctx)
+		}
+	}
+}
+
+// dueSearch is a saved search due to run, copied so it can run unlocked
+type dueSearch struct {
+	userID   string
+	search   models.SavedSearch
+	revision int
+}
+
+// runDue runs every saved search whose next run is due, one at a time to
+// stay within the upstream rate limits
+func (s *SavedSearchService) runDue(ctx context.Context) {
+	s.mu.Lock()
+	now := s.clock.Now()
+	var due []dueSearch
+	for userID, searches := range s.searches {
+		for _, saved := range searches {
+			if !saved.running && !saved.NextRunAt.After(now) {
+				saved.running = true
+				due = append(due, dueSearch{userID: userID, search: saved.SavedSearch, revision: saved.revision})
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	// Run the longest-waiting searches first
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].search.NextRunAt.Equal(due[j].search.NextRunAt) {
+			return due[i].search.NextRunAt.Before(due[j].search.NextRunAt)
+		}
+		return due[i].search.ID < due[j].search.ID
+	})
+
+	for _, run := range due {
+		var matches []SearchDocument
+		err := ctx.Err()
+		if err == nil {
+			matches, err = s.fetchMatches(ctx, run.search)
+		}
+		s.recordRun(run, matches, err)
+	}
+}
+
+// fetchMatches returns the titles on the first pages of a saved search's
+// results. Results come straight from TMDB, leaving the OMDB budget to
+// interactive requests.
+func (s *SavedSearchService) fetchMatches(ctx context.Context, search models.SavedSearch) ([]SearchDocument, error) {
+	ctx = WithLocale(ctx, Locale{Language: search.Language, Region: search.Region})
+
+	var matches []SearchDocument
+	for page := 1; page <= maxSavedSearchPages; page++ {
+		var result *models.SearchResult
+		var err error
+		switch {
+		case search.Discover != "":
+			params, _ := url.ParseQuery(search.Discover)
+			params.Set("page", strconv.Itoa(page))
+			filters, _, parseErr := ParseDiscoveryFilters(search.MediaType, params)
+			if parseErr != nil {
+				return nil, parseErr
+			}
+			result, err = s.discoveryService.genreService.Discover(ctx, search.MediaType, page, filters)
+		default:
+			result, err = s.discoveryService.searchTMDB(ctx, search.MediaType, search.Query, page)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, result := range result.Results {
+			if item, ok := result.(map[string]interface{}); ok {
+				if doc := searchResultDocument(search.MediaType, item); doc.ID > 0 {
+					matches = append(matches, doc)
+				}
+			}
+		}
+		if page >= result.TotalPages {
+			break
+		}
+	}
+	return matches, nil
+}
+
+// recordRun records the outcome of a saved search run, adding titles not
+// matched before to the user's inbox. The first run only records what
+// already matches. Runs of a search that was since deleted or changed are
+// dropped.
+func (s *SavedSearchService) recordRun(run dueSearch, matches []SearchDocument, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := s.find(run.userID, run.search.ID)
+	if saved == nil {
+		return
+	}
+	saved.running = false
+	if saved.revision != run.revision {
+		return
+	}
+
+	now := s.clock.Now()
+	saved.NextRunAt = now.Add(s.interval)
+	if err != nil {
+		log.Printf("Saved search %d failed: %v", saved.ID, err)
+		saved.LastError = err.Error()
+		return
+	}
+	saved.LastRunAt = &now
+	saved.LastError = ""
+
+	baseline := saved.seen == nil
+	if baseline {
+		saved.seen = make(map[int]bool, len(matches))
+	}
+	var found []models.SavedSearchMatch
+	for _, doc := range matches {
+		if saved.seen[doc.ID] {
+			continue
+		}
+		saved.seen[doc.ID] = true
+		if baseline {
+			continue
+		}
+		found = append(found, models.SavedSearchMatch{
+			SearchID:    saved.ID,
+			SearchName:  saved.Name,
+			ID:          doc.ID,
+			MediaType:   saved.MediaType,
+			Title:       doc.Title,
+			ReleaseDate: doc.ReleaseDate,
+			PosterPath:  doc.PosterPath,
+			FoundAt:     now,
+		})
+	}
+	if len(found) == 0 {
+		return
+	}
+
+	saved.Matches += len(found)
+	inbox := append(found, s.inboxes[run.userID]...)
+	if len(inbox) > maxSavedSearchInbox {
+		inbox = inbox[:maxSavedSearchInbox]
+	}
+	s.inboxes[run.userID] = inbox
+}
+
+// find returns one of a user's saved searches. The caller holds mu.
+func (s *SavedSearchService) find(userID string, id int) *savedSearch {
+	for _, saved := range s.searches[userID] {
+		if saved.ID == id {
+			return saved
+		}
+	}
+	return nil
+}
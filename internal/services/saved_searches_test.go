@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
+)
+
+// fakeClock is a Clock whose time only moves when advanced
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward, firing the waits that are over
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, waiter := range c.waiters {
+		if waiter.at.After(c.now) {
+			waiters = append(waiters, waiter)
+			continue
+		}
+		waiter.ch <- c.now
+	}
+	c.waiters = waiters
+}
+
+// BlockUntil waits until n callers are waiting on the clock
+func (c *fakeClock) BlockUntil(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		waiting := len(c.waiters)
+		c.mu.Unlock()
+		if waiting >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %d waiters on the clock", n)
+}
+
+// newTestSavedSearchService creates a saved search service on a fake clock
+// against a TMDB serving the discover results in ids, and failing while
+// down is set
+func newTestSavedSearchService(t *testing.T, ids *atomic.Value, down *atomic.Bool) (*SavedSearchService, *fakeClock, *int32) {
+	var requests int32
+	tmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/discover/movie" {
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt32(&requests, 1)
+		if down.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var results []string
+		for _, id := range ids.Load().([]int) {
+			results = append(results, fmt.Sprintf(`{"id": %d, "title": "Movie %d", "release_date": "2024-06-01", "poster_path": "/%d.jpg"}`, id, id, id))
+		}
+		fmt.Fprintf(w, `{"page": 1, "results": [%s], "total_pages": 1, "total_results": %d}`, strings.Join(results, ","), len(results))
+	}))
+	t.Cleanup(tmdb.Close)
+
+	omdb := newFakeOMDB(func(string) time.Duration { return 0 })
+	t.Cleanup(omdb.Close)
+
+	clock := newFakeClock(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+	config := &configs.Config{SavedSearches: configs.SavedSearchConfig{Interval: 6 * time.Hour}}
+	service := NewSavedSearchService(config, newTestDiscoveryService(tmdb.URL, omdb.URL))
+	service.clock = clock
+	return service, clock, &requests
+}
+
+func TestSavedSearchService_ParseSavedSearch(t *testing.T) {
+	service := NewSavedSearchService(&configs.Config{}, newTestDiscoveryService("http://tmdb.invalid", "http://omdb.invalid"))
+
+	parsed, err := service.ParseSavedSearch(models.SavedSearch{Name: "  New sci-fi  ", MediaType: "movie", Discover: "page=3&min_rating=7.5&genres=878", Language: "pt_br", Region: "br"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if parsed.Name != "New sci-fi" || parsed.Discover != "genres=878&min_rating=7.5" || parsed.Language != "pt-BR" || parsed.Region != "BR" {
+		t.Errorf("Expected a trimmed name and normalized filters and locale, got %+v", parsed)
+	}
+
+	tests := []struct {
+		search  models.SavedSearch
+		message string
+	}{
+		{models.SavedSearch{MediaType: "movie", Query: "dune"}, "name is required"},
+		{models.SavedSearch{Name: strings.Repeat("x", 101), MediaType: "movie", Query: "dune"}, "name too long"},
+		{models.SavedSearch{Name: "Dune", MediaType: "person", Query: "dune"}, "media_type must be movie or tv"},
+		{models.SavedSearch{Name: "Dune", MediaType: "movie"}, "either query or discover"},
+		{models.SavedSearch{Name: "Dune", MediaType: "movie", Query: "dune", Discover: "genres=878"}, "either query or discover"},
+		{models.SavedSearch{Name: "Dune", MediaType: "movie", Query: "directr:Villeneuve"}, `did you mean "director"?`},
+		{models.SavedSearch{Name: "Dune", MediaType: "movie", Discover: "min_runtime=long"}, "min_runtime must be a whole number"},
+		{models.SavedSearch{Name: "Dune", MediaType: "tv", Discover: "cast=6193"}, "only supported for movies"},
+		{models.SavedSearch{Name: "Dune", MediaType: "movie", Discover: "%zz"}, "discover must be URL query parameters"},
+		{models.SavedSearch{Name: "Dune", MediaType: "movie", Query: "dune", Language: "german"}, "language must be an ISO 639-1 language code"},
+	}
+	for _, tt := range tests {
+		if _, err := service.ParseSavedSearch(tt.search); err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%+v: expected an error containing %q, got %v", tt.search, tt.message, err)
+		}
+	}
+}
+
+func TestSavedSearchService_CreateKeepsLocale(t *testing.T) {
+	service := NewSavedSearchService(&configs.Config{}, newTestDiscoveryService("http://tmdb.invalid", "http://omdb.invalid"))
+
+	parsed, err := service.ParseSavedSearch(models.SavedSearch{Name: "Krimis", MediaType: "movie", Discover: "genres=80", Language: "de", Region: "de"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	created, err := service.CreateSavedSearch("user", parsed)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	saved, err := service.GetSavedSearch("user", created.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if saved.Language != "de" || saved.Region != "DE" {
+		t.Errorf("Expected the search to keep language de and region DE, got %q and %q", saved.Language, saved.Region)
+	}
+}
+
+func TestSavedSearchService_Run(t *testing.T) {
+	var ids atomic.Value
+	var down atomic.Bool
+	ids.Store([]int{1, 2})
+	service, clock, requests := newTestSavedSearchService(t, &ids, &down)
+
+	// Clears cached discover results, which the real clock would expire
+	// long before the next run
+	resetCache := func() {
+		service.discoveryService.tmdbClient.cache = &Cache{data: make(map[string]CacheItem)}
+	}
+
+	saved, err := service.CreateSavedSearch("user", models.SavedSearch{Name: "Sci-fi", MediaType: "movie", Discover: "genres=878&min_rating=7.5"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// tick advances the clock and waits for the scheduler to finish running
+	// whatever became due
+	tick := func(d time.Duration) {
+		clock.BlockUntil(t, 1)
+		clock.Advance(d)
+		clock.BlockUntil(t, 1)
+	}
+
+	// The first run records what already matches without reporting it
+	tick(time.Minute)
+	saved, _ = service.GetSavedSearch("user", saved.ID)
+	if saved.LastRunAt == nil || !saved.NextRunAt.Equal(clock.Now().Add(6*time.Hour)) {
+		t.Fatalf("Expected the first run to be recorded, got %+v", saved)
+	}
+	if inbox := service.GetInbox("user"); len(inbox.Matches) != 0 {
+		t.Errorf("Expected no matches from the first run, got %+v", inbox.Matches)
+	}
+
+	// Nothing runs until the search is due again
+	ids.Store([]int{3, 1, 2})
+	resetCache()
+	tick(time.Hour)
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Errorf("Expected the search not to run again yet, got %d requests", got)
+	}
+
+	tick(5 * time.Hour)
+	inbox := service.GetInbox("user")
+	if len(inbox.Matches) != 1 {
+		t.Fatalf("Expected one new match, got %+v", inbox.Matches)
+	}
+	want := models.SavedSearchMatch{SearchID: saved.ID, SearchName: "Sci-fi", ID: 3, MediaType: "movie", Title: "Movie 3", ReleaseDate: "2024-06-01", PosterPath: "/3.jpg", FoundAt: clock.Now()}
+	if inbox.Matches[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, inbox.Matches[0])
+	}
+
+	// Titles are only reported once, and failed runs report nothing
+	ids.Store([]int{4, 3, 1, 2})
+	down.Store(true)
+	resetCache()
+	tick(6 * time.Hour)
+	saved, _ = service.GetSavedSearch("user", saved.ID)
+	if saved.LastError == "" || saved.Matches != 1 {
+		t.Errorf("Expected the failure to be recorded, got %+v", saved)
+	}
+
+	down.Store(false)
+	tick(6 * time.Hour)
+	inbox = service.GetInbox("user")
+	if len(inbox.Matches) != 2 || inbox.Matches[0].ID != 4 || inbox.Matches[1].ID != 3 {
+		t.Errorf("Expected Movie 4 then Movie 3, got %+v", inbox.Matches)
+	}
+	saved, _ = service.GetSavedSearch("user", saved.ID)
+	if saved.LastError != "" || saved.Matches != 2 {
+		t.Errorf("Expected the error to clear after a good run, got %+v", saved)
+	}
+
+	// Changing the query starts afresh; deleting the search clears its matches
+	if _, err := service.UpdateSavedSearch("user", saved.ID, models.SavedSearch{Name: "Sci-fi", MediaType: "movie", Discover: "genres=878"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resetCache()
+	tick(time.Minute)
+	if inbox := service.GetInbox("user"); len(inbox.Matches) != 2 {
+		t.Errorf("Expected the new query's first run to report nothing, got %+v", inbox.Matches)
+	}
+
+	if err := service.DeleteSavedSearch("user", saved.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if inbox := service.GetInbox("user"); len(inbox.Matches) != 0 {
+		t.Errorf("Expected the deleted search's matches to go, got %+v", inbox.Matches)
+	}
+	if err := service.DeleteSavedSearch("user", saved.ID); !errors.Is(err, ErrSavedSearchNotFound) {
+		t.Errorf("Expected ErrSavedSearchNotFound, got %v", err)
+	}
+}
+
+func TestSavedSearchService_RunsQueriesWithoutEnrichment(t *testing.T) {
+	tmdb := newFakeTMDBSearch(20)
+	defer tmdb.Close()
+
+	var omdbCalls int32
+	omdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&omdbCalls, 1)
+		w.Write([]byte(`{"Response": "False", "Error": "Movie not found!"}`))
+	}))
+	defer omdb.Close()
+
+	service := NewSavedSearchService(&configs.Config{}, newTestDiscoveryService(tmdb.URL, omdb.URL))
+	service.clock = newFakeClock(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	saved, err := service.CreateSavedSearch("user", models.SavedSearch{Name: "Movies", MediaType: "movie", Query: "movie"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	service.runDue(context.Background())
+
+	saved, _ = service.GetSavedSearch("user", saved.ID)
+	if saved.LastRunAt == nil || saved.LastError != "" {
+		t.Fatalf("Expected a successful run, got %+v", saved)
+	}
+	if got := atomic.LoadInt32(&omdbCalls); got != 0 {
+		t.Errorf("Expected no OMDB requests, got %d", got)
+	}
+}
+
+func TestSavedSearchService_Limits(t *testing.T) {
+	service := NewSavedSearchService(&configs.Config{}, newTestDiscoveryService("http://tmdb.invalid", "http://omdb.invalid"))
+
+	for i := 0; i < maxSavedSearches; i++ {
+		if _, err := service.CreateSavedSearch("user", models.SavedSearch{Name: "Dune", MediaType: "movie", Query: "dune"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if _, err := service.CreateSavedSearch("user", models.SavedSearch{Name: "Dune", MediaType: "movie", Query: "dune"}); !errors.Is(err, ErrSavedSearchLimit) {
+		t.Errorf("Expected ErrSavedSearchLimit, got %v", err)
+	}
+
+	// Other users have their own searches
+	if _, err := service.CreateSavedSearch("other", models.SavedSearch{Name: "Dune", MediaType: "movie", Query: "dune"}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if _, err := service.GetSavedSearch("other", 1); !errors.Is(err, ErrSavedSearchNotFound) {
+		t.Errorf("Expected another user's search to be hidden, got %v", err)
+	}
+}
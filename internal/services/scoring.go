@@ -0,0 +1,196 @@
+package services
+
+import (
+	"math"
+	"sort"
+
+	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
+)
+
+// Score sources used in composite score breakdowns
+const (
+	ScoreSourceTMDB           = "tmdb"
+	ScoreSourceIMDb           = "imdb"
+	ScoreSourceRottenTomatoes = "rotten_tomatoes"
+	ScoreSourceMetacritic     = "metacritic"
+)
+
+// SortByComposite sorts discovery results by composite score
+const SortByComposite = "composite"
+
+// scoreSourcesByRating maps OMDB rating sources to score sources
+var scoreSourcesByRating = map[string]string{
+	RatingSourceIMDb:           ScoreSourceIMDb,
+	RatingSourceRottenTomatoes: ScoreSourceRottenTomatoes,
+	RatingSourceMetacritic:     ScoreSourceMetacritic,
+}
+
+// votedScoreSources are audience scores averaged from user votes. They are
+// shrunk towards the prior according to their vote count; critic scores are
+// used as-is.
+var votedScoreSources = map[string]bool{
+	ScoreSourceTMDB: true,
+	ScoreSourceIMDb: true,
+}
+
+// ScoreInput is one source's rating on a 0-100 scale
+type ScoreInput struct {
+	Source string
+	Score  float64
+	Votes  int
+}
+
+// Scorer combines ratings from several sources into one composite score
+type Scorer struct {
+	weights    map[string]float64
+	priorMean  float64
+	priorVotes float64
+}
+
+// NewScorer creates a scorer from the configured formula
+func NewScorer(config *configs.ScoringConfig) *Scorer {
+	priorMean := config.PriorMean
+	if priorMean <= 0 || priorMean > 100 {
+		priorMean = 60
+	}
+	priorVotes := config.PriorVotes
+	if priorVotes < 0 {
+		priorVotes = 0
+	}
+
+	weights := map[string]float64{
+		ScoreSourceTMDB:           config.WeightTMDB,
+		ScoreSourceIMDb:           config.WeightIMDb,
+		ScoreSourceRottenTomatoes: config.WeightRottenTomatoes,
+		ScoreSourceMetacritic:     config.WeightMetacritic,
+	}
+
+	// With no weights configured, every source counts equally
+	if config.WeightTMDB <= 0 && config.WeightIMDb <= 0 && config.WeightRottenTomatoes <= 0 && config.WeightMetacritic <= 0 {
+		for source := range weights {
+			weights[source] = 1
+		}
+	}
+
+	return &Scorer{
+		weights:    weights,
+		priorMean:  priorMean,
+		priorVotes: float64(priorVotes),
+	}
+}
+
+// Score returns the weighted mean of the inputs after Bayesian shrinkage:
+// a voted score becomes (votes*score + priorVotes*priorMean) / (votes +
+// priorVotes), so a 9/10 from a handful of votes counts for less than a
+// 9/10 from thousands. Voted sources without votes and sources with no
+// weight are left out. It returns nil when nothing is left to score.
+func (s *Scorer) Score(inputs []ScoreInput) *models.CompositeScore {
+	var weightedSum, totalWeight float64
+	breakdown := make([]models.ScoreComponent, 0, len(inputs))
+
+	for _, input := range inputs {
+		weight := s.weights[input.Source]
+		if weight <= 0 {
+			continue
+		}
+
+		adjusted := input.Score
+		if votedScoreSources[input.Source] {
+			if input.Votes <= 0 {
+				continue
+			}
+			votes := float64(input.Votes)
+			adjusted = (votes*input.Score + s.priorVotes*s.priorMean) / (votes + s.priorVotes)
+		}
+
+		weightedSum += weight * adjusted
+		totalWeight += weight
+		breakdown = append(breakdown, models.ScoreComponent{
+			Source:   input.Source,
+			Score:    roundScore(input.Score),
+			Votes:    input.Votes,
+			Adjusted: roundScore(adjusted),
+			Weight:   weight,
+		})
+	}
+
+	if totalWeight == 0 {
+		return nil
+	}
+
+	return &models.CompositeScore{
+		Score:     roundScore(weightedSum / totalWeight),
+		Breakdown: breakdown,
+	}
+}
+
+// ScoreResult scores a TMDB search or discovery result from its TMDB vote
+// average and any OMDB ratings merged into it
+func (s *Scorer) ScoreResult(item map[string]interface{}) *models.CompositeScore {
+	voteAverage, _ := item["vote_average"].(float64)
+	voteCount, _ := item["vote_count"].(float64)
+	ratings, _ := item["ratings"].([]models.Rating)
+
+	return s.Score(append(tmdbScoreInputs(voteAverage, int(voteCount)), omdbScoreInputs(ratings)...))
+}
+
+// ScoreMovie scores a movie's TMDB and OMDB ratings
+func (s *Scorer) ScoreMovie(movie *models.Movie) *models.CompositeScore {
+	return s.Score(append(tmdbScoreInputs(movie.VoteAverage, movie.VoteCount), omdbScoreInputs(movie.Ratings)...))
+}
+
+// ScoreTVShow scores a TV show's TMDB and OMDB ratings
+func (s *Scorer) ScoreTVShow(tvShow *models.TVShow) *models.CompositeScore {
+	return s.Score(append(tmdbScoreInputs(tvShow.VoteAverage, tvShow.VoteCount), omdbScoreInputs(tvShow.Ratings)...))
+}
+
+// tmdbScoreInputs converts a TMDB 0-10 vote average to a score input
+func tmdbScoreInputs(voteAverage float64, voteCount int) []ScoreInput {
+	if voteCount <= 0 {
+		return nil
+	}
+	return []ScoreInput{{Source: ScoreSourceTMDB, Score: voteAverage * 10, Votes: voteCount}}
+}
+
+// omdbScoreInputs converts OMDB ratings to score inputs, skipping sources
+// the composite doesn't use
+func omdbScoreInputs(ratings []models.Rating) []ScoreInput {
+	inputs := make([]ScoreInput, 0, len(ratings))
+	for _, rating := range ratings {
+		if source, known := scoreSourcesByRating[rating.Source]; known {
+			inputs = append(inputs, ScoreInput{Source: source, Score: rating.Score, Votes: rating.Votes})
+		}
+	}
+	return inputs
+}
+
+// sortByComposite orders results by composite score, highest first. Results
+// without a score keep their relative order at the end.
+func sortByComposite(results []interface{}) {
+	score := func(result interface{}) (float64, bool) {
+		item, ok := result.(map[string]interface{})
+		if !ok {
+			return 0, false
+		}
+		composite, ok := item["composite"].(*models.CompositeScore)
+		if !ok || composite == nil {
+			return 0, false
+		}
+		return composite.Score, true
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		scoreI, okI := score(results[i])
+		scoreJ, okJ := score(results[j])
+		if okI != okJ {
+			return okI
+		}
+		return scoreI > scoreJ
+	})
+}
+
+// roundScore rounds a 0-100 score to one decimal place
+func roundScore(score float64) float64 {
+	return math.Round(score*10) / 10
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"movie-discovery-app/configs"
+	"movie-discovery-app/internal/models"
+)
+
+func TestScorer_Score(t *testing.T) {
+	scorer := NewScorer(&configs.ScoringConfig{
+		WeightTMDB:           1,
+		WeightIMDb:           2,
+		WeightRottenTomatoes: 1,
+		WeightMetacritic:     0,
+		PriorMean:            60,
+		PriorVotes:           500,
+	})
+
+	tests := []struct {
+		name     string
+		inputs   []ScoreInput
+		expected float64
+	}{
+		{
+			name:     "Many votes barely move",
+			inputs:   []ScoreInput{{Source: ScoreSourceTMDB, Score: 80, Votes: 99500}},
+			expected: 79.9, // (99500*80 + 500*60) / 100000
+		},
+		{
+			name:     "Few votes shrink towards the prior",
+			inputs:   []ScoreInput{{Source: ScoreSourceTMDB, Score: 90, Votes: 10}},
+			expected: 60.6, // (10*90 + 500*60) / 510
+		},
+		{
+			name: "Weighted mean across sources",
+			inputs: []ScoreInput{
+				{Source: ScoreSourceTMDB, Score: 80, Votes: 500},  // adjusted 70
+				{Source: ScoreSourceIMDb, Score: 90, Votes: 1500}, // adjusted 82.5, weight 2
+				{Source: ScoreSourceRottenTomatoes, Score: 40},    // critics are not shrunk
+				{Source: ScoreSourceMetacritic, Score: 100},       // weight 0, left out
+			},
+			expected: 68.8, // (70 + 2*82.5 + 40) / 4
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			composite := scorer.Score(tt.inputs)
+			if composite == nil {
+				t.Fatal("Expected a composite score, got nil")
+			}
+			if composite.Score != tt.expected {
+				t.Errorf("Expected score %v, got %v", tt.expected, composite.Score)
+			}
+		})
+	}
+}
+
+func TestScorer_Score_Breakdown(t *testing.T) {
+	scorer := NewScorer(&configs.ScoringConfig{PriorMean: 60, PriorVotes: 500})
+
+	composite := scorer.Score([]ScoreInput{
+		{Source: ScoreSourceTMDB, Score: 80, Votes: 500},
+		{Source: ScoreSourceIMDb, Score: 90, Votes: 0}, // no votes, no information
+		{Source: ScoreSourceMetacritic, Score: 74},
+	})
+
+	expected := []models.ScoreComponent{
+		{Source: ScoreSourceTMDB, Score: 80, Votes: 500, Adjusted: 70, Weight: 1},
+		{Source: ScoreSourceMetacritic, Score: 74, Adjusted: 74, Weight: 1},
+	}
+	if len(composite.Breakdown) != len(expected) {
+		t.Fatalf("Expected %d components, got %+v", len(expected), composite.Breakdown)
+	}
+	for i, component := range composite.Breakdown {
+		if component != expected[i] {
+			t.Errorf("Component %d: expected %+v, got %+v", i, expected[i], component)
+		}
+	}
+	if composite.Score != 72 {
+		t.Errorf("Expected score 72, got %v", composite.Score)
+	}
+}
+
+func TestScorer_Score_NothingToScore(t *testing.T) {
+	scorer := NewScorer(&configs.ScoringConfig{})
+
+	if composite := scorer.Score(nil); composite != nil {
+		t.Errorf("Expected nil for no inputs, got %+v", composite)
+	}
+	if composite := scorer.ScoreResult(map[string]interface{}{"vote_average": 0.0, "vote_count": 0.0}); composite != nil {
+		t.Errorf("Expected nil for an unrated result, got %+v", composite)
+	}
+}
+
+func TestGenreService_DiscoverMoviesByGenre_SortByComposite(t *testing.T) {
+	var sortBy string
+	tmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sortBy = r.URL.Query().Get("sort_by")
+		w.Write([]byte(`{"page": 1, "results": [
+			{"id": 1, "title": "Popular", "vote_average": 6.5, "vote_count": 20000},
+			{"id": 2, "title": "Unrated", "vote_average": 0, "vote_count": 0},
+			{"id": 3, "title": "Acclaimed", "vote_average": 8.4, "vote_count": 15000},
+			{"id": 4, "title": "Obscure", "vote_average": 9.8, "vote_count": 3}
+		], "total_pages": 1, "total_results": 4}`))
+	}))
+	defer tmdb.Close()
+
+	service := NewGenreService(&configs.Config{
+		TMDB:    configs.TMDBConfig{APIKey: "test_key", BaseURL: tmdb.URL},
+		Scoring: configs.ScoringConfig{PriorMean: 60, PriorVotes: 500},
+	})
+	service.tmdbClient.upstream.rateLimiter = NewRateLimiter(60000, 1000)
+	service.tmdbClient.upstream.breaker = NewCircuitBreaker("test", 100, time.Minute)
+
+	filters := GetDefaultFilters()
+	filters.SortBy = SortByComposite
+	filters.Validate()
+
+	result, err := service.DiscoverMoviesByGenre(context.Background(), 28, 1, filters)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if sortBy != "popularity.desc" {
+		t.Errorf("Expected TMDB to be asked for popularity.desc, got %q", sortBy)
+	}
+
+	expected := []string{"Acclaimed", "Popular", "Obscure", "Unrated"}
+	for i, title := range expected {
+		item := result.Results[i].(map[string]interface{})
+		if item["title"] != title {
+			t.Errorf("Position %d: expected %q, got %v", i, title, item["title"])
+		}
+	}
+}
@@ -0,0 +1,442 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// searchIndexVersion is the version of the saved index format
+const searchIndexVersion = 1
+
+// Search index limits
+const (
+	maxIndexedCast      = 10 // Top-billed cast members indexed per title
+	minPrefixTermLen    = 2  // Shortest last word matched as a prefix
+	minTypoTermLen      = 4  // Shortest word matched with a typo
+	longTypoTermLen     = 8  // Words this long may have two typos
+	searchIndexPageSize = 20 // Results per page
+)
+
+// Relevance of a word by the field it appears in
+const (
+	weightTitle         = 5.0
+	weightOriginalTitle = 4.0
+	weightDirector      = 3.0
+	weightCast          = 2.0
+	weightKeyword       = 2.0
+	weightOverview      = 1.0
+)
+
+// How much a word counts when it only matches a query word approximately
+const (
+	factorPrefix = 0.8
+	factorTypo1  = 0.6
+	factorTypo2  = 0.4
+)
+
+// SearchDocument is a movie or TV show in the local search index
+type SearchDocument struct {
+	ID            int      `json:"id"`
+	MediaType     string   `json:"media_type"`
+	Title         string   `json:"title"`
+	OriginalTitle string   `json:"original_title,omitempty"`
+	Overview      string   `json:"overview,omitempty"`
+	ReleaseDate   string   `json:"release_date,omitempty"` // First air date for TV shows
+	PosterPath    string   `json:"poster_path,omitempty"`
+	GenreIDs      []int    `json:"genre_ids,omitempty"`
+	VoteAverage   float64  `json:"vote_average,omitempty"`
+	VoteCount     int      `json:"vote_count,omitempty"`
+	Popularity    float64  `json:"popularity,omitempty"`
+	Cast          []string `json:"cast,omitempty"`
+	Directors     []string `json:"directors,omitempty"`
+	Keywords      []string `json:"keywords,omitempty"`
+}
+
+// savedSearchIndex is the on-disk form of the index. Only documents are
+// saved; the inverted index is rebuilt from them on load.
+type savedSearchIndex struct {
+	Version   int               `json:"version"`
+	Documents []*SearchDocument `json:"documents"`
+}
+
+// SearchIndex is a local full-text index over every movie and TV show the
+// app has fetched, used to search while TMDB is unavailable. Matching
+// tolerates typos and treats the last query word as a prefix; results are
+// ranked by which fields matched and how rare the matched words are.
+type SearchIndex struct {
+	mu        sync.RWMutex
+	documents map[string]*SearchDocument
+	postings  map[string]map[string]float64 // Word -> document key -> weight of the best field it appears in
+	dirty     bool                          // Changed since the last save
+}
+
+// NewSearchIndex creates an empty search index
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		documents: make(map[string]*SearchDocument),
+		postings:  make(map[string]map[string]float64),
+	}
+}
+
+// Len returns the number of indexed titles
+func (x *SearchIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.documents)
+}
+
+// Add indexes a title, or updates it if it is already indexed. Fields the
+// new document lacks keep their indexed values, so a search result doesn't
+// drop the cast and keywords learned from the title's details.
+func (x *SearchIndex) Add(doc SearchDocument) {
+	if doc.ID <= 0 || doc.Title == "" || (doc.MediaType != "movie" && doc.MediaType != "tv") {
+		return
+	}
+	if len(doc.Cast) > maxIndexedCast {
+		doc.Cast = doc.Cast[:maxIndexedCast]
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	key := watchlistKey(doc.MediaType, doc.ID)
+	if existing, exists := x.documents[key]; exists {
+		mergeSearchDocument(&doc, existing)
+		if reflect.DeepEqual(&doc, existing) {
+			return
+		}
+		x.unindex(key, existing)
+	}
+
+	x.documents[key] = &doc
+	x.index(key, &doc)
+	x.dirty = true
+}
+
+// Search returns a page of titles of mediaType matching the query, best
+// first, and the total number of matches
+func (x *SearchIndex) Search(mediaType, query string, page int) ([]SearchDocument, int) {
+	normalized := normalizeTitle(query)
+	words := strings.Fields(normalized)
+	if len(words) == 0 {
+		return nil, 0
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	type candidate struct {
+		doc     *SearchDocument
+		matched int
+		score   float64
+	}
+	candidates := make(map[string]*candidate)
+
+	for i, word := range words {
+		// Each query word scores its best match in a document
+		best := make(map[string]float64)
+		for term, factor := range x.expand(word, i == len(words)-1) {
+			idf := math.Log(1 + float64(len(x.documents))/float64(len(x.postings[term])))
+			for key, weight := range x.postings[term] {
+				if x.documents[key].MediaType != mediaType {
+					continue
+				}
+				best[key] = max(best[key], factor*weight*idf)
+			}
+		}
+		for key, score := range best {
+			c, exists := candidates[key]
+			if !exists {
+				c = &candidate{doc: x.documents[key]}
+				candidates[key] = c
+			}
+			c.matched++
+			c.score += score
+		}
+	}
+
+	// Titles matching every word win; when none do, those matching the most
+	mostMatched := 0
+	for _, c := range candidates {
+		mostMatched = max(mostMatched, c.matched)
+	}
+	var ranked []*candidate
+	for _, c := range candidates {
+		if c.matched < mostMatched {
+			continue
+		}
+		title := normalizeTitle(c.doc.Title)
+		switch {
+		case title == normalized:
+			c.score *= 2
+		case strings.HasPrefix(title, normalized):
+			c.score *= 1.5
+		}
+		ranked = append(ranked, c)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		if ranked[i].doc.Popularity != ranked[j].doc.Popularity {
+			return ranked[i].doc.Popularity > ranked[j].doc.Popularity
+		}
+		return ranked[i].doc.ID < ranked[j].doc.ID
+	})
+
+	start := (page - 1) * searchIndexPageSize
+	if page < 1 || start >= len(ranked) {
+		return []SearchDocument{}, len(ranked)
+	}
+	end := min(start+searchIndexPageSize, len(ranked))
+
+	results := make([]SearchDocument, 0, end-start)
+	for _, c := range ranked[start:end] {
+		results = append(results, *c.doc)
+	}
+	return results, len(ranked)
+}
+
+// expand returns the indexed words a query word matches and how much each
+// counts: exactly, as a prefix when it is the last word being typed, or
+// with a typo when it is long enough. The caller holds mu.
+func (x *SearchIndex) expand(word string, last bool) map[string]float64 {
+	terms := make(map[string]float64)
+	if _, exists := x.postings[word]; exists {
+		terms[word] = 1
+	}
+
+	maxTypos := 0
+	if len(word) >= longTypoTermLen {
+		maxTypos = 2
+	} else if len(word) >= minTypoTermLen {
+		maxTypos = 1
+	}
+	prefix := last && len(word) >= minPrefixTermLen
+	if !prefix && maxTypos == 0 {
+		return terms
+	}
+
+	for term := range x.postings {
+		if term == word {
+			continue
+		}
+		factor := 0.0
+		if prefix && strings.HasPrefix(term, word) {
+			factor = factorPrefix
+		}
+		if factor == 0 && maxTypos > 0 && abs(len(term)-len(word)) <= maxTypos {
+			switch editDistance(word, term) {
+			case 1:
+				factor = factorTypo1
+			case 2:
+				if maxTypos == 2 {
+					factor = factorTypo2
+				}
+			}
+		}
+		if factor > 0 {
+			terms[term] = factor
+		}
+	}
+	return terms
+}
+
+// index adds a document's words to the postings. The caller holds mu.
+func (x *SearchIndex) index(key string, doc *SearchDocument) {
+	for term, weight := range documentTerms(doc) {
+		if x.postings[term] == nil {
+			x.postings[term] = make(map[string]float64)
+		}
+		x.postings[term][key] = weight
+	}
+}
+
+// unindex removes a document's words from the postings. The caller holds mu.
+func (x *SearchIndex) unindex(key string, doc *SearchDocument) {
+	for term := range documentTerms(doc) {
+		delete(x.postings[term], key)
+		if len(x.postings[term]) == 0 {
+			delete(x.postings, term)
+		}
+	}
+}
+
+// Rebuild recreates the inverted index from the indexed documents
+func (x *SearchIndex) Rebuild() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.postings = make(map[string]map[string]float64)
+	for key, doc := range x.documents {
+		x.index(key, doc)
+	}
+}
+
+// Save writes the indexed documents to path if they changed since the last
+// save. The file is replaced atomically so a crash never leaves it
+// half-written.
+func (x *SearchIndex) Save(path string) error {
+	x.mu.Lock()
+	if !x.dirty {
+		x.mu.Unlock()
+		return nil
+	}
+	saved := savedSearchIndex{Version: searchIndexVersion, Documents: make([]*SearchDocument, 0, len(x.documents))}
+	for _, doc := range x.documents {
+		copied := *doc
+		saved.Documents = append(saved.Documents, &copied)
+	}
+	x.dirty = false
+	x.mu.Unlock()
+
+	sort.Slice(saved.Documents, func(i, j int) bool {
+		if saved.Documents[i].MediaType != saved.Documents[j].MediaType {
+			return saved.Documents[i].MediaType < saved.Documents[j].MediaType
+		}
+		return saved.Documents[i].ID < saved.Documents[j].ID
+	})
+
+	if err := writeSearchIndex(path, &saved); err != nil {
+		x.mu.Lock()
+		x.dirty = true
+		x.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// writeSearchIndex writes a saved index to path through a temporary file
+func writeSearchIndex(path string, saved *savedSearchIndex) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create search index directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create search index file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := json.NewEncoder(tmp).Encode(saved); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace search index: %w", err)
+	}
+	return nil
+}
+
+// LoadSearchIndex reads an index saved at path and rebuilds its inverted
+// index. A missing file gives an empty index.
+func LoadSearchIndex(path string) (*SearchIndex, error) {
+	index := NewSearchIndex()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return index, fmt.Errorf("failed to read search index: %w", err)
+	}
+
+	var saved savedSearchIndex
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return index, fmt.Errorf("failed to decode search index: %w", err)
+	}
+	if saved.Version != searchIndexVersion {
+		return index, fmt.Errorf("unsupported search index version %d", saved.Version)
+	}
+
+	for _, doc := range saved.Documents {
+		if doc == nil || doc.ID <= 0 || doc.Title == "" {
+			continue
+		}
+		index.documents[watchlistKey(doc.MediaType, doc.ID)] = doc
+	}
+	index.Rebuild()
+
+	return index, nil
+}
+
+// documentTerms returns a document's words with the weight of the best
+// field each appears in
+func documentTerms(doc *SearchDocument) map[string]float64 {
+	terms := make(map[string]float64)
+	add := func(text string, weight float64) {
+		for _, term := range strings.Fields(normalizeTitle(text)) {
+			terms[term] = max(terms[term], weight)
+		}
+	}
+
+	add(doc.Title, weightTitle)
+	add(doc.OriginalTitle, weightOriginalTitle)
+	add(doc.Overview, weightOverview)
+	for _, name := range doc.Directors {
+		add(name, weightDirector)
+	}
+	for _, name := range doc.Cast {
+		add(name, weightCast)
+	}
+	for _, keyword := range doc.Keywords {
+		add(keyword, weightKeyword)
+	}
+	return terms
+}
+
+// mergeSearchDocument fills the fields doc lacks from the indexed existing
+// document
+func mergeSearchDocument(doc, existing *SearchDocument) {
+	if doc.OriginalTitle == "" {
+		doc.OriginalTitle = existing.OriginalTitle
+	}
+	if doc.Overview == "" {
+		doc.Overview = existing.Overview
+	}
+	if doc.ReleaseDate == "" {
+		doc.ReleaseDate = existing.ReleaseDate
+	}
+	if doc.PosterPath == "" {
+		doc.PosterPath = existing.PosterPath
+	}
+	if len(doc.GenreIDs) == 0 {
+		doc.GenreIDs = existing.GenreIDs
+	}
+	if doc.VoteAverage == 0 {
+		doc.VoteAverage = existing.VoteAverage
+	}
+	if doc.VoteCount == 0 {
+		doc.VoteCount = existing.VoteCount
+	}
+	if doc.Popularity == 0 {
+		doc.Popularity = existing.Popularity
+	}
+	if len(doc.Cast) == 0 {
+		doc.Cast = existing.Cast
+	}
+	if len(doc.Directors) == 0 {
+		doc.Directors = existing.Directors
+	}
+	if len(doc.Keywords) == 0 {
+		doc.Keywords = existing.Keywords
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
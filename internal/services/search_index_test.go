@@ -0,0 +1,160 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newTestSearchIndex() *SearchIndex {
+	index := NewSearchIndex()
+	index.Add(SearchDocument{ID: 238, MediaType: "movie", Title: "The Godfather", ReleaseDate: "1972-03-14", Overview: "The aging patriarch of an organized crime dynasty transfers control to his reluctant son.", Directors: []string{"Francis Ford Coppola"}, Cast: []string{"Marlon Brando", "Al Pacino"}, Keywords: []string{"mafia"}, Popularity: 90})
+	index.Add(SearchDocument{ID: 240, MediaType: "movie", Title: "The Godfather Part II", ReleaseDate: "1974-12-20", Directors: []string{"Francis Ford Coppola"}, Cast: []string{"Al Pacino", "Robert De Niro"}, Keywords: []string{"mafia", "sequel"}, Popularity: 60})
+	index.Add(SearchDocument{ID: 155, MediaType: "movie", Title: "The Dark Knight", Overview: "Batman raises the stakes in his war on crime.", Directors: []string{"Christopher Nolan"}, Cast: []string{"Christian Bale", "Heath Ledger"}, Popularity: 80})
+	index.Add(SearchDocument{ID: 129, MediaType: "movie", Title: "Spirited Away", OriginalTitle: "千と千尋の神隠し", Directors: []string{"Hayao Miyazaki"}, Popularity: 70})
+	index.Add(SearchDocument{ID: 1, MediaType: "movie", Title: "Crime Story", Popularity: 5})
+	index.Add(SearchDocument{ID: 1396, MediaType: "tv", Title: "Breaking Bad", Overview: "A chemistry teacher turns to crime.", Cast: []string{"Bryan Cranston"}, Popularity: 100})
+	return index
+}
+
+func searchTitles(docs []SearchDocument) []string {
+	titles := make([]string, len(docs))
+	for i, doc := range docs {
+		titles[i] = doc.Title
+	}
+	return titles
+}
+
+func TestSearchIndex_Search(t *testing.T) {
+	index := newTestSearchIndex()
+
+	tests := []struct {
+		mediaType string
+		query     string
+		want      []string
+	}{
+		// Exact titles beat longer ones
+		{"movie", "the godfather", []string{"The Godfather", "The Godfather Part II"}},
+		// Typos and a partly typed last word
+		{"movie", "godfahter", []string{"The Godfather", "The Godfather Part II"}},
+		{"movie", "dark kni", []string{"The Dark Knight"}},
+		// People and keywords
+		{"movie", "coppola pacino", []string{"The Godfather", "The Godfather Part II"}},
+		{"movie", "de niro", []string{"The Godfather Part II"}},
+		{"movie", "nolan", []string{"The Dark Knight"}},
+		{"movie", "mafia sequel", []string{"The Godfather Part II"}},
+		// Original titles
+		{"movie", "千と千尋の神隠し", []string{"Spirited Away"}},
+		// A title match outranks overview matches
+		{"movie", "crime", []string{"Crime Story", "The Godfather", "The Dark Knight"}},
+		// Media types are kept apart
+		{"tv", "crime", []string{"Breaking Bad"}},
+		{"tv", "cranstn", []string{"Breaking Bad"}},
+		// When no title has every word, those with the most win
+		{"movie", "batman zzzz", []string{"The Dark Knight"}},
+		{"movie", "zzzz", nil},
+		{"movie", "  ", nil},
+	}
+
+	for _, tt := range tests {
+		docs, total := index.Search(tt.mediaType, tt.query, 1)
+		got := searchTitles(docs)
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) || total != len(tt.want) {
+			t.Errorf("%s %q: expected %v, got %v (total %d)", tt.mediaType, tt.query, tt.want, got, total)
+		}
+	}
+
+	if docs, total := index.Search("movie", "the godfather", 2); len(docs) != 0 || total != 2 {
+		t.Errorf("Expected an empty second page of 2 results, got %v (total %d)", searchTitles(docs), total)
+	}
+}
+
+func TestSearchIndex_Add(t *testing.T) {
+	index := newTestSearchIndex()
+
+	// A search result without credits keeps the indexed cast
+	index.Add(SearchDocument{ID: 155, MediaType: "movie", Title: "The Dark Knight", Overview: "Batman faces the Joker.", Popularity: 85})
+	if docs, _ := index.Search("movie", "ledger", 1); !reflect.DeepEqual(searchTitles(docs), []string{"The Dark Knight"}) {
+		t.Errorf("Expected the cast to be kept, got %v", searchTitles(docs))
+	}
+	if docs, _ := index.Search("movie", "joker", 1); !reflect.DeepEqual(searchTitles(docs), []string{"The Dark Knight"}) {
+		t.Errorf("Expected the new overview to be indexed, got %v", searchTitles(docs))
+	}
+	if docs, _ := index.Search("movie", "stakes", 1); len(docs) != 0 {
+		t.Errorf("Expected the old overview to be unindexed, got %v", searchTitles(docs))
+	}
+
+	// Incomplete documents are ignored
+	index.Add(SearchDocument{ID: 2, MediaType: "movie"})
+	index.Add(SearchDocument{ID: 3, MediaType: "person", Title: "Al Pacino"})
+	if index.Len() != 6 {
+		t.Errorf("Expected 6 titles, got %d", index.Len())
+	}
+}
+
+func TestSearchIndex_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "search_index.json")
+	index := newTestSearchIndex()
+
+	if err := index.Save(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected the index to be saved, got %v", err)
+	}
+
+	loaded, err := LoadSearchIndex(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if loaded.Len() != index.Len() {
+		t.Errorf("Expected %d titles, got %d", index.Len(), loaded.Len())
+	}
+	for _, query := range []string{"godfahter", "coppola pacino", "crime"} {
+		want, _ := index.Search("movie", query, 1)
+		got, _ := loaded.Search("movie", query, 1)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: expected %v after loading, got %v", query, searchTitles(want), searchTitles(got))
+		}
+	}
+
+	// Saving a loaded index writes the same file
+	loaded.Add(SearchDocument{ID: 1, MediaType: "movie", Title: "Crime Story", Popularity: 5})
+	loaded.dirty = true
+	if err := loaded.Save(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resaved, _ := os.ReadFile(path); string(resaved) != string(saved) {
+		t.Error("Expected saving to be stable")
+	}
+}
+
+func TestLoadSearchIndex_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	if index, err := LoadSearchIndex(filepath.Join(dir, "missing.json")); err != nil || index.Len() != 0 {
+		t.Errorf("Expected an empty index for a missing file, got %d titles and %v", index.Len(), err)
+	}
+
+	for name, content := range map[string]string{
+		"corrupt.json": `{"version": 1, "documents": [`,
+		"future.json":  `{"version": 99, "documents": [{"id": 1, "media_type": "movie", "title": "Alien"}]}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		index, err := LoadSearchIndex(path)
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		if index == nil || index.Len() != 0 {
+			t.Errorf("%s: expected an empty index to rebuild from", name)
+		}
+	}
+}
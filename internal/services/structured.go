@@ -0,0 +1,433 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"movie-discovery-app/internal/models"
+)
+
+// streamingProviders maps normalized provider names to TMDB watch provider
+// IDs. Amazon has separate IDs in and outside the US.
+var streamingProviders = map[string][]int{
+	"netflix":       {8},
+	"amazon":        {9, 119},
+	"prime":         {9, 119},
+	"primevideo":    {9, 119},
+	"disney":        {337},
+	"disneyplus":    {337},
+	"hulu":          {15},
+	"max":           {1899},
+	"hbo":           {1899},
+	"hbomax":        {1899},
+	"apple":         {350},
+	"appletv":       {350},
+	"appletvplus":   {350},
+	"paramount":     {531},
+	"paramountplus": {531},
+	"peacock":       {386},
+	"mubi":          {11},
+	"crunchyroll":   {283},
+}
+
+// genreAliases lists other normalized names to try for a genre. TMDB calls
+// it "Science Fiction" for movies and "Sci-Fi & Fantasy" for TV.
+var genreAliases = map[string][]string{
+	"scifi":          {"sciencefiction"},
+	"sciencefiction": {"scifi"},
+}
+
+// resultFilter keeps or drops a TMDB search or discover result
+type resultFilter func(item map[string]interface{}) bool
+
+// queryPlan is a structured query translated to TMDB
+type queryPlan struct {
+	filters     DiscoveryFilters
+	postFilters []resultFilter
+	searchable  bool // Every term can be checked on text search results
+}
+
+// searchStructured runs a structured query. With free text and only terms
+// that search results carry (genre, year, rating, language), TMDB text
+// search is filtered locally. Otherwise the terms become discover filters
+// and the text, if any, filters titles. Filtering happens after fetching, so
+// a page can have fewer results than TMDB's.
+func (s *DiscoveryService) searchStructured(ctx context.Context, mediaType string, query *SearchQuery, page int) (*models.SearchResult, error) {
+	tmdbResults, err := s.fetchStructured(ctx, mediaType, query, page)
+	if err != nil {
+		return nil, err
+	}
+
+	enrich := s.enhanceMovieWithOMDB
+	if mediaType == "tv" {
+		enrich = s.enhanceTVShowWithOMDB
+	}
+	results, degraded, err := s.enrichResults(ctx, tmdbResults.Results, s.omdbClient.Available, enrich)
+	if err != nil {
+		return nil, fmt.Errorf("structured search cancelled: %w", err)
+	}
+
+	s.scoreResults(results)
+	s.indexResults(ctx, mediaType, results)
+
+	response := *tmdbResults
+	response.Results = results
+	if degraded {
+		response.Degraded = []string{"omdb"}
+	}
+
+	return &response, nil
+}
+
+// fetchStructured gets the TMDB results matching a structured query, without
+// OMDB data
+func (s *DiscoveryService) fetchStructured(ctx context.Context, mediaType string, query *SearchQuery, page int) (*models.SearchResult, error) {
+	plan, err := s.planQuery(ctx, mediaType, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var tmdbResults *models.SearchResult
+	if query.Text != "" && plan.searchable {
+		if mediaType == "tv" {
+			tmdbResults, err = s.tmdbClient.SearchTVShows(ctx, query.Text, page)
+		} else {
+			tmdbResults, err = s.tmdbClient.SearchMovies(ctx, query.Text, page)
+		}
+	} else {
+		if query.Text != "" {
+			plan.postFilters = append(plan.postFilters, titleContains(query.Text))
+		}
+		tmdbResults, err = s.genreService.Discover(ctx, mediaType, page, plan.filters)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var matching []interface{}
+	for _, result := range tmdbResults.Results {
+		if item, ok := result.(map[string]interface{}); ok && matchesAll(item, plan.postFilters) {
+			matching = append(matching, item)
+		}
+	}
+
+	response := *tmdbResults
+	response.Results = matching
+	return &response, nil
+}
+
+// planQuery resolves genres, people and providers and translates each term
+// into discover filters and post-filters
+func (s *DiscoveryService) planQuery(ctx context.Context, mediaType string, query *SearchQuery) (*queryPlan, error) {
+	plan := &queryPlan{filters: GetDefaultFilters(), searchable: true}
+	filters := &plan.filters
+
+	dateField := "release_date"
+	if mediaType == "tv" {
+		dateField = "first_air_date"
+	}
+
+	for _, term := range query.Terms {
+		switch term.Field {
+		case QueryFieldGenre:
+			id, err := s.resolveGenre(ctx, mediaType, term.Value)
+			if err != nil {
+				return nil, err
+			}
+			filters.GenreIDs = append(filters.GenreIDs, id)
+			plan.postFilters = append(plan.postFilters, hasGenre(id))
+
+		case QueryFieldYear:
+			year := int(term.Number)
+			switch term.Op {
+			case "=":
+				filters.MinYear, filters.MaxYear = year, year
+			case ">":
+				filters.MinYear = year + 1
+			case ">=":
+				filters.MinYear = year
+			case "<":
+				filters.MaxYear = year - 1
+			case "<=":
+				filters.MaxYear = year
+			}
+			plan.postFilters = append(plan.postFilters, yearMatches(dateField, term.Op, term.Number))
+
+		case QueryFieldRating:
+			// rating:7 means at least 7; strict comparisons are checked locally
+			switch term.Op {
+			case "=", ">", ">=":
+				filters.MinRating = term.Number
+			case "<", "<=":
+				filters.MaxRating = term.Number
+			}
+			op := term.Op
+			if op == "=" {
+				op = ">="
+			}
+			plan.postFilters = append(plan.postFilters, numberMatches("vote_average", op, term.Number))
+
+		case QueryFieldRuntime:
+			minutes := int(term.Number)
+			switch term.Op {
+			case "=":
+				filters.MinRuntime, filters.MaxRuntime = minutes, minutes
+			case ">":
+				filters.MinRuntime = minutes + 1
+			case ">=":
+				filters.MinRuntime = minutes
+			case "<":
+				filters.MaxRuntime = max(minutes-1, 0)
+			case "<=":
+				filters.MaxRuntime = minutes
+			}
+			plan.searchable = false
+
+		case QueryFieldLanguage:
+			filters.Language = term.Value
+			language := strings.ToLower(term.Value)
+			plan.postFilters = append(plan.postFilters, func(item map[string]interface{}) bool {
+				original, _ := item["original_language"].(string)
+				return original == language
+			})
+
+		case QueryFieldRegion:
+			filters.Region = term.Value
+
+		case QueryFieldOn:
+			ids, known := streamingProviders[normalizeQueryName(term.Value)]
+			if !known {
+				return nil, queryErrorf("on: unknown provider %q; try one of: %s", term.Value, strings.Join(providerNames(), ", "))
+			}
+			for _, id := range ids {
+				if !containsInt(filters.ProviderIDs, id) {
+					filters.ProviderIDs = append(filters.ProviderIDs, id)
+				}
+			}
+			plan.searchable = false
+
+		case QueryFieldActor, QueryFieldDirector:
+			if mediaType == "tv" {
+				return nil, queryErrorf("%s: only supported when searching movies", term.Field)
+			}
+			department := "Acting"
+			if term.Field == QueryFieldDirector {
+				department = "Directing"
+			}
+			id, err := s.resolvePerson(ctx, term.Value, department)
+			if err != nil {
+				return nil, err
+			}
+			if term.Field == QueryFieldActor {
+				filters.CastIDs = append(filters.CastIDs, id)
+			} else {
+				filters.CrewIDs = append(filters.CrewIDs, id)
+			}
+			plan.searchable = false
+		}
+	}
+
+	// Providers only apply within a country
+	if len(filters.ProviderIDs) > 0 && filters.Region == "" {
+		filters.Region = defaultCalendarRegion
+	}
+	if err := filters.Validate(); err != nil {
+		return nil, &QueryError{Message: err.Error()}
+	}
+
+	return plan, nil
+}
+
+// resolveGenre finds a genre by name, ignoring case, spaces and punctuation.
+// A unique prefix also matches, so "doc" finds Documentary. Genres are named
+// in the request's language, and in English as well.
+func (s *DiscoveryService) resolveGenre(ctx context.Context, mediaType, name string) (int, error) {
+	genres, err := s.genres(ctx, mediaType)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := matchGenre(genres, name)
+	if err != nil && !LocaleFromContext(ctx).IsEnglish() {
+		if english, englishErr := s.genres(englishContext(ctx), mediaType); englishErr == nil {
+			if id, englishErr := matchGenre(english, name); englishErr == nil {
+				return id, nil
+			}
+		}
+	}
+	return id, err
+}
+
+// genres gets the movie or TV genres
+func (s *DiscoveryService) genres(ctx context.Context, mediaType string) ([]models.Genre, error) {
+	if mediaType == "tv" {
+		return s.genreService.GetTVGenres(ctx)
+	}
+	return s.genreService.GetMovieGenres(ctx)
+}
+
+// matchGenre finds a genre by name in genres
+func matchGenre(genres []models.Genre, name string) (int, error) {
+	wanted := normalizeQueryName(name)
+	candidates := append([]string{wanted}, genreAliases[wanted]...)
+
+	for _, candidate := range candidates {
+		for _, genre := range genres {
+			if normalizeQueryName(genre.Name) == candidate {
+				return genre.ID, nil
+			}
+		}
+	}
+
+	var names []string
+	for _, genre := range genres {
+		names = append(names, genre.Name)
+	}
+
+	for _, candidate := range candidates {
+		var matches []models.Genre
+		for _, genre := range genres {
+			if candidate != "" && strings.HasPrefix(normalizeQueryName(genre.Name), candidate) {
+				matches = append(matches, genre)
+			}
+		}
+		if len(matches) == 1 {
+			return matches[0].ID, nil
+		}
+		if len(matches) > 1 {
+			var matchNames []string
+			for _, genre := range matches {
+				matchNames = append(matchNames, genre.Name)
+			}
+			return 0, queryErrorf("genre: %q could be %s", name, strings.Join(matchNames, " or "))
+		}
+	}
+
+	return 0, queryErrorf("genre: unknown genre %q; try one of: %s", name, strings.Join(names, ", "))
+}
+
+// resolvePerson finds a person by name, preferring an exact name match in
+// the given department, then any exact name match, then TMDB's best match
+func (s *DiscoveryService) resolvePerson(ctx context.Context, name, department string) (int, error) {
+	result, err := s.tmdbClient.SearchPeople(ctx, name, 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(result.Results) == 0 {
+		return 0, queryErrorf("no person found matching %q", name)
+	}
+
+	for _, person := range result.Results {
+		if strings.EqualFold(person.Name, name) && person.KnownForDepartment == department {
+			return person.ID, nil
+		}
+	}
+	for _, person := range result.Results {
+		if strings.EqualFold(person.Name, name) {
+			return person.ID, nil
+		}
+	}
+	return result.Results[0].ID, nil
+}
+
+// normalizeQueryName lower-cases a name and drops everything but letters and
+// digits, spelling out "+" so "Disney+" and "disneyplus" match
+func normalizeQueryName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r == '+':
+			b.WriteString("plus")
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// providerNames lists the provider names on: accepts
+func providerNames() []string {
+	names := make([]string, 0, len(streamingProviders))
+	for name := range streamingProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// matchesAll reports whether every filter keeps the result
+func matchesAll(item map[string]interface{}, filters []resultFilter) bool {
+	for _, filter := range filters {
+		if !filter(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasGenre keeps results tagged with the genre
+func hasGenre(id int) resultFilter {
+	return func(item map[string]interface{}) bool {
+		genreIDs, _ := item["genre_ids"].([]interface{})
+		for _, genreID := range genreIDs {
+			if value, ok := genreID.(float64); ok && int(value) == id {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// yearMatches compares the year of a result's date field
+func yearMatches(dateField, op string, year float64) resultFilter {
+	return func(item map[string]interface{}) bool {
+		date, _ := item[dateField].(string)
+		if len(date) < 4 {
+			return false
+		}
+		value, err := strconv.Atoi(date[:4])
+		return err == nil && compareNumber(float64(value), op, year)
+	}
+}
+
+// numberMatches compares a numeric field of a result
+func numberMatches(field, op string, number float64) resultFilter {
+	return func(item map[string]interface{}) bool {
+		value, ok := item[field].(float64)
+		return ok && compareNumber(value, op, number)
+	}
+}
+
+// titleContains keeps results whose title or original title contains text,
+// ignoring case
+func titleContains(text string) resultFilter {
+	text = strings.ToLower(text)
+	return func(item map[string]interface{}) bool {
+		for _, field := range []string{"title", "original_title", "name", "original_name"} {
+			if title, ok := item[field].(string); ok && strings.Contains(strings.ToLower(title), text) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// compareNumber applies a query comparison operator
+func compareNumber(value float64, op string, target float64) bool {
+	switch op {
+	case ">":
+		return value > target
+	case ">=":
+		return value >= target
+	case "<":
+		return value < target
+	case "<=":
+		return value <= target
+	default:
+		return value == target
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newFakeTMDBStructured serves the TMDB endpoints structured queries use and
+// records the last discover and search parameters
+func newFakeTMDBStructured(t *testing.T) (*httptest.Server, func(path string) url.Values) {
+	var mu sync.Mutex
+	requests := make(map[string]url.Values)
+
+	tmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path] = r.URL.Query()
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/genre/movie/list":
+			w.Write([]byte(`{"genres": [{"id": 878, "name": "Science Fiction"}, {"id": 18, "name": "Drama"}, {"id": 99, "name": "Documentary"}, {"id": 10749, "name": "Romance"}, {"id": 10752, "name": "War"}]}`))
+		case "/genre/tv/list":
+			w.Write([]byte(`{"genres": [{"id": 10765, "name": "Sci-Fi & Fantasy"}, {"id": 18, "name": "Drama"}]}`))
+		case "/search/person":
+			w.Write([]byte(`{"page": 1, "results": [
+				{"id": 1, "name": "Denis Villeneuve", "known_for_department": "Acting"},
+				{"id": 137427, "name": "Denis Villeneuve", "known_for_department": "Directing"}
+			]}`))
+		case "/discover/movie":
+			w.Write([]byte(`{"page": 1, "results": [
+				{"id": 438631, "title": "Dune", "release_date": "2021-09-15", "vote_average": 7.8, "vote_count": 12000, "genre_ids": [878, 12]},
+				{"id": 335984, "title": "Blade Runner 2049", "release_date": "2017-10-04", "vote_average": 7.0, "vote_count": 13000, "genre_ids": [878, 18]}
+			], "total_pages": 1, "total_results": 2}`))
+		case "/discover/tv":
+			w.Write([]byte(`{"page": 1, "results": [], "total_pages": 0, "total_results": 0}`))
+		case "/search/movie":
+			w.Write([]byte(`{"page": 1, "results": [
+				{"id": 438631, "title": "Dune", "release_date": "2021-09-15", "vote_average": 7.8, "genre_ids": [878, 12], "original_language": "en"},
+				{"id": 841, "title": "Dune", "release_date": "1984-12-14", "vote_average": 6.2, "genre_ids": [878, 12], "original_language": "en"},
+				{"id": 1, "title": "Dune Drifter", "release_date": "2020-03-01", "vote_average": 4.0, "genre_ids": [28], "original_language": "en"}
+			], "total_pages": 1, "total_results": 3}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(tmdb.Close)
+
+	return tmdb, func(path string) url.Values {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests[path]
+	}
+}
+
+func resultTitles(results []interface{}) []string {
+	var titles []string
+	for _, result := range results {
+		item := result.(map[string]interface{})
+		titles = append(titles, item["title"].(string)+" "+item["release_date"].(string)[:4])
+	}
+	return titles
+}
+
+func TestDiscoveryService_SearchMovies_Structured(t *testing.T) {
+	tmdb, request := newFakeTMDBStructured(t)
+	omdb := newFakeOMDB(func(string) time.Duration { return 0 })
+	defer omdb.Close()
+	service := newTestDiscoveryService(tmdb.URL, omdb.URL)
+
+	result, err := service.SearchMovies(context.Background(), `director:"Denis Villeneuve" year:>2015 genre:scifi rating:>7 on:netflix`, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	params := request("/discover/movie")
+	for name, value := range map[string]string{
+		"with_crew":                "137427",
+		"with_genres":              "878",
+		"primary_release_date.gte": "2016-01-01",
+		"vote_average.gte":         "7.0",
+		"with_watch_providers":     "8",
+		"watch_region":             "US",
+	} {
+		if params.Get(name) != value {
+			t.Errorf("Expected %s=%q, got %q", name, value, params.Get(name))
+		}
+	}
+
+	// Blade Runner 2049 is rated exactly 7, so rating:>7 drops it
+	if titles := resultTitles(result.Results); strings.Join(titles, ", ") != "Dune 2021" {
+		t.Errorf("Expected only Dune, got %v", titles)
+	}
+}
+
+func TestDiscoveryService_SearchMovies_StructuredText(t *testing.T) {
+	tmdb, request := newFakeTMDBStructured(t)
+	omdb := newFakeOMDB(func(string) time.Duration { return 0 })
+	defer omdb.Close()
+	service := newTestDiscoveryService(tmdb.URL, omdb.URL)
+
+	// Text with fields search results carry uses text search
+	result, err := service.SearchMovies(context.Background(), "dune genre:science-fiction year:>=2000", 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if query := request("/search/movie").Get("query"); query != "dune" {
+		t.Errorf("Expected a text search for dune, got %q", query)
+	}
+	if titles := resultTitles(result.Results); strings.Join(titles, ", ") != "Dune 2021" {
+		t.Errorf("Expected only the 2021 Dune, got %v", titles)
+	}
+
+	// Text with a person goes through discover and filters titles
+	result, err = service.SearchMovies(context.Background(), `blade director:"Denis Villeneuve"`, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if titles := resultTitles(result.Results); strings.Join(titles, ", ") != "Blade Runner 2049 2017" {
+		t.Errorf("Expected only Blade Runner 2049, got %v", titles)
+	}
+}
+
+func TestDiscoveryService_SearchStructured_Errors(t *testing.T) {
+	tmdb, request := newFakeTMDBStructured(t)
+	omdb := newFakeOMDB(func(string) time.Duration { return 0 })
+	defer omdb.Close()
+	service := newTestDiscoveryService(tmdb.URL, omdb.URL)
+
+	tests := []struct {
+		tv      bool
+		query   string
+		message string
+	}{
+		{false, "genre:western", `unknown genre "western"`},
+		{false, "genre:d", `could be Drama or Documentary`},
+		{false, "on:betamax", `unknown provider "betamax"`},
+		{false, "language:english", "language must be an ISO 639-1 language code"},
+		{true, `director:"Denis Villeneuve"`, "only supported when searching movies"},
+	}
+
+	for _, tt := range tests {
+		var err error
+		if tt.tv {
+			_, err = service.SearchTVShows(context.Background(), tt.query, 1)
+		} else {
+			_, err = service.SearchMovies(context.Background(), tt.query, 1)
+		}
+		var queryErr *QueryError
+		if !errors.As(err, &queryErr) || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%q: expected a QueryError containing %q, got %v", tt.query, tt.message, err)
+		}
+	}
+
+	// TV genres resolve by prefix
+	if _, err := service.SearchTVShows(context.Background(), "genre:scifi", 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if genres := request("/discover/tv").Get("with_genres"); genres != "10765" {
+		t.Errorf("Expected genre:scifi to find Sci-Fi & Fantasy, got %q", genres)
+	}
+}
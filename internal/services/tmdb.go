@@ -1,12 +1,11 @@
 package services
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,10 +15,9 @@ import (
 
 // TMDBClient handles TMDB API interactions
 type TMDBClient struct {
-	config      *configs.TMDBConfig
-	httpClient  *http.Client
-	cache       *Cache
-	rateLimiter *RateLimiter
+	config   *configs.TMDBConfig
+	cache    *Cache
+	upstream *upstreamClient
 }
 
 // Cache represents a simple in-memory cache
@@ -34,34 +32,46 @@ type CacheItem struct {
 	ExpiresAt time.Time
 }
 
-// RateLimiter implements rate limiting
-type RateLimiter struct {
-	requests chan time.Time
-	limit    int
-	window   time.Duration
-}
-
 // NewTMDBClient creates a new TMDB API client
-func NewTMDBClient(config *configs.TMDBConfig, cacheConfig *configs.CacheConfig, rateConfig *configs.RateLimitConfig) *TMDBClient {
+func NewTMDBClient(config *configs.TMDBConfig, cacheConfig *configs.CacheConfig, rateConfig *configs.RateLimitConfig, retryConfig *configs.RetryConfig, breakerConfig *configs.BreakerConfig) *TMDBClient {
 	return &TMDBClient{
 		config: config,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
 		cache: &Cache{
 			data: make(map[string]CacheItem),
 		},
-		rateLimiter: &RateLimiter{
-			requests: make(chan time.Time, rateConfig.RequestsPerMinute),
-			limit:    rateConfig.RequestsPerMinute,
-			window:   time.Minute,
-		},
+		upstream: newUpstreamClient("TMDB", 30*time.Second, sharedRateLimiter("tmdb", rateConfig), sharedCircuitBreaker("tmdb", breakerConfig), newRetryPolicy(retryConfig)),
+	}
+}
+
+// get fetches a TMDB API path and decodes the JSON response into out
+func (c *TMDBClient) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	return c.upstream.getJSON(ctx, c.requestURL(path, params), out)
+}
+
+// getLocalized fetches a TMDB API path in the language and region of the
+// request's locale
+func (c *TMDBClient) getLocalized(ctx context.Context, path string, params url.Values, out interface{}) error {
+	return c.get(ctx, path, localeParams(ctx, params), out)
+}
+
+// requestURL builds an authenticated TMDB API URL
+func (c *TMDBClient) requestURL(path string, params url.Values) string {
+	if params == nil {
+		params = url.Values{}
 	}
+	params.Set("api_key", c.config.APIKey)
+
+	return fmt.Sprintf("%s%s?%s", c.config.BaseURL, path, params.Encode())
+}
+
+// Available reports whether TMDB calls are currently admitted by its circuit breaker
+func (c *TMDBClient) Available() bool {
+	return c.upstream.Available()
 }
 
 // SearchMovies searches for movies using TMDB API
-func (c *TMDBClient) SearchMovies(query string, page int) (*models.SearchResult, error) {
-	cacheKey := fmt.Sprintf("search_movies_%s_%d", query, page)
+func (c *TMDBClient) SearchMovies(ctx context.Context, query string, page int) (*models.SearchResult, error) {
+	cacheKey := fmt.Sprintf("search_movies_%s_%d", query, page) + localeCacheKey(ctx)
 
 	// Check cache first
 	if cached := c.cache.Get(cacheKey); cached != nil {
@@ -70,38 +80,53 @@ func (c *TMDBClient) SearchMovies(query string, page int) (*models.SearchResult,
 		}
 	}
 
-	// Rate limiting
-	if err := c.rateLimiter.Wait(); err != nil {
-		return nil, err
-	}
-
-	// Build URL
 	params := url.Values{}
-	params.Add("api_key", c.config.APIKey)
 	params.Add("query", query)
 	params.Add("page", strconv.Itoa(page))
 
-	url := fmt.Sprintf("%s/search/movie?%s", c.config.BaseURL, params.Encode())
-
-	// Make request
-	resp, err := c.httpClient.Get(url)
-	if err != nil {
+	var result models.SearchResult
+	if err := c.getLocalized(ctx, "/search/movie", params, &result); err != nil {
 		return nil, fmt.Errorf("failed to search movies: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API error: %d", resp.StatusCode)
+	// Fill in overviews TMDB has no translation of
+	if needsEnglishOverviews(ctx, result.Results) {
+		if english, err := c.SearchMovies(englishContext(ctx), query, page); err == nil {
+			fillEnglishOverviews(result.Results, english.Results)
+		}
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	// Cache the result
+	c.cache.Set(cacheKey, &result, 30*time.Minute)
+
+	return &result, nil
+}
+
+// SearchTVShows searches for TV shows using TMDB API
+func (c *TMDBClient) SearchTVShows(ctx context.Context, query string, page int) (*models.SearchResult, error) {
+	cacheKey := fmt.Sprintf("search_tv_%s_%d", query, page) + localeCacheKey(ctx)
+
+	// Check cache first
+	if cached := c.cache.Get(cacheKey); cached != nil {
+		if result, ok := cached.(*models.SearchResult); ok {
+			return result, nil
+		}
 	}
 
+	params := url.Values{}
+	params.Add("query", query)
+	params.Add("page", strconv.Itoa(page))
+
 	var result models.SearchResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := c.getLocalized(ctx, "/search/tv", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to search TV shows: %w", err)
+	}
+
+	// Fill in overviews TMDB has no translation of
+	if needsEnglishOverviews(ctx, result.Results) {
+		if english, err := c.SearchTVShows(englishContext(ctx), query, page); err == nil {
+			fillEnglishOverviews(result.Results, english.Results)
+		}
 	}
 
 	// Cache the result
@@ -110,197 +135,442 @@ func (c *TMDBClient) SearchMovies(query string, page int) (*models.SearchResult,
 	return &result, nil
 }
 
-// SearchTVShows searches for TV shows using TMDB API
-func (c *TMDBClient) SearchTVShows(query string, page int) (*models.SearchResult, error) {
-	cacheKey := fmt.Sprintf("search_tv_%s_%d", query, page)
+// GetMovieDetails gets detailed movie information, with the given sections
+// (see MovieDetailSections) fetched in the same request
+func (c *TMDBClient) GetMovieDetails(ctx context.Context, movieID int, sections ...string) (*models.Movie, error) {
+	cacheKey := fmt.Sprintf("movie_details_%d_%s", movieID, strings.Join(sections, ",")) + localeCacheKey(ctx)
 
 	// Check cache first
 	if cached := c.cache.Get(cacheKey); cached != nil {
-		if result, ok := cached.(*models.SearchResult); ok {
-			return result, nil
+		if movie, ok := cached.(*models.Movie); ok {
+			return movie, nil
 		}
 	}
 
-	// Rate limiting
-	if err := c.rateLimiter.Wait(); err != nil {
-		return nil, err
+	var movie models.Movie
+	if err := c.getLocalized(ctx, fmt.Sprintf("/movie/%d", movieID), appendToResponse(sections), &movie); err != nil {
+		return nil, fmt.Errorf("failed to get movie details: %w", err)
+	}
+
+	// Fall back to the English overview when there is no translation
+	if movie.Overview == "" && !LocaleFromContext(ctx).IsEnglish() {
+		if english, err := c.GetMovieDetails(englishContext(ctx), movieID); err == nil {
+			movie.Overview = english.Overview
+		}
 	}
 
-	// Build URL
-	params := url.Values{}
-	params.Add("api_key", c.config.APIKey)
-	params.Add("query", query)
-	params.Add("page", strconv.Itoa(page))
+	// Cache the result
+	c.cache.Set(cacheKey, &movie, 30*time.Minute)
 
-	url := fmt.Sprintf("%s/search/tv?%s", c.config.BaseURL, params.Encode())
+	return &movie, nil
+}
 
-	// Make request
-	resp, err := c.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search TV shows: %w", err)
+// GetTVShowDetails gets detailed TV show information, with the given sections
+// (see TVDetailSections) fetched in the same request
+func (c *TMDBClient) GetTVShowDetails(ctx context.Context, tvID int, sections ...string) (*models.TVShow, error) {
+	cacheKey := fmt.Sprintf("tv_details_%d_%s", tvID, strings.Join(sections, ",")) + localeCacheKey(ctx)
+
+	// Check cache first
+	if cached := c.cache.Get(cacheKey); cached != nil {
+		if tvShow, ok := cached.(*models.TVShow); ok {
+			return tvShow, nil
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API error: %d", resp.StatusCode)
+	var tvShow models.TVShow
+	if err := c.getLocalized(ctx, fmt.Sprintf("/tv/%d", tvID), appendToResponse(sections), &tvShow); err != nil {
+		return nil, fmt.Errorf("failed to get TV show details: %w", err)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	// Fall back to the English overview when there is no translation
+	if tvShow.Overview == "" && !LocaleFromContext(ctx).IsEnglish() {
+		if english, err := c.GetTVShowDetails(englishContext(ctx), tvID); err == nil {
+			tvShow.Overview = english.Overview
+		}
 	}
 
-	var result models.SearchResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	// Cache the result
+	c.cache.Set(cacheKey, &tvShow, 30*time.Minute)
+
+	return &tvShow, nil
+}
+
+// appendToResponse builds the query parameters that fetch extra sections
+// along with a details object
+func appendToResponse(sections []string) url.Values {
+	if len(sections) == 0 {
+		return nil
+	}
+
+	params := url.Values{}
+	params.Add("append_to_response", strings.Join(sections, ","))
+	return params
+}
+
+// GetMovieExternalIDs gets the IDs of a movie on other sites, including IMDb
+func (c *TMDBClient) GetMovieExternalIDs(ctx context.Context, movieID int) (*models.ExternalIDs, error) {
+	return c.getExternalIDs(ctx, "movie", movieID)
+}
+
+// GetTVExternalIDs gets the IDs of a TV show on other sites, including IMDb
+func (c *TMDBClient) GetTVExternalIDs(ctx context.Context, tvID int) (*models.ExternalIDs, error) {
+	return c.getExternalIDs(ctx, "tv", tvID)
+}
+
+// getExternalIDs fetches /{mediaType}/{id}/external_ids. IDs rarely change,
+// so they are cached for a day and repeated searches cost no TMDB calls.
+func (c *TMDBClient) getExternalIDs(ctx context.Context, mediaType string, id int) (*models.ExternalIDs, error) {
+	cacheKey := fmt.Sprintf("external_ids_%s_%d", mediaType, id)
+
+	// Check cache first
+	if cached := c.cache.Get(cacheKey); cached != nil {
+		if externalIDs, ok := cached.(*models.ExternalIDs); ok {
+			return externalIDs, nil
+		}
+	}
+
+	var externalIDs models.ExternalIDs
+	if err := c.get(ctx, fmt.Sprintf("/%s/%d/external_ids", mediaType, id), nil, &externalIDs); err != nil {
+		return nil, fmt.Errorf("failed to get external IDs: %w", err)
 	}
 
 	// Cache the result
-	c.cache.Set(cacheKey, &result, 30*time.Minute)
+	c.cache.Set(cacheKey, &externalIDs, 24*time.Hour)
 
-	return &result, nil
+	return &externalIDs, nil
 }
 
-// GetMovieDetails gets detailed movie information
-func (c *TMDBClient) GetMovieDetails(movieID int) (*models.Movie, error) {
-	cacheKey := fmt.Sprintf("movie_details_%d", movieID)
+// GetMovieVideos gets the trailers, teasers and clips TMDB lists for a movie
+func (c *TMDBClient) GetMovieVideos(ctx context.Context, movieID int) (*models.TrailerResponse, error) {
+	return c.getVideos(ctx, "movie", movieID)
+}
+
+// GetTVVideos gets the trailers, teasers and clips TMDB lists for a TV show
+func (c *TMDBClient) GetTVVideos(ctx context.Context, tvID int) (*models.TrailerResponse, error) {
+	return c.getVideos(ctx, "tv", tvID)
+}
+
+// getVideos fetches /{mediaType}/{id}/videos. Videos in every language are
+// requested so trailers can be ranked by language afterwards.
+func (c *TMDBClient) getVideos(ctx context.Context, mediaType string, id int) (*models.TrailerResponse, error) {
+	cacheKey := fmt.Sprintf("videos_%s_%d", mediaType, id)
 
 	// Check cache first
 	if cached := c.cache.Get(cacheKey); cached != nil {
-		if movie, ok := cached.(*models.Movie); ok {
-			return movie, nil
+		if videos, ok := cached.(*models.TrailerResponse); ok {
+			return videos, nil
 		}
 	}
 
-	// Rate limiting
-	if err := c.rateLimiter.Wait(); err != nil {
-		return nil, err
+	var videos models.TrailerResponse
+	if err := c.get(ctx, fmt.Sprintf("/%s/%d/videos", mediaType, id), nil, &videos); err != nil {
+		return nil, fmt.Errorf("failed to get videos: %w", err)
+	}
+
+	// Cache the result
+	c.cache.Set(cacheKey, &videos, 30*time.Minute)
+
+	return &videos, nil
+}
+
+// GetUpcomingMovies gets movies coming soon to theaters in a region
+func (c *TMDBClient) GetUpcomingMovies(ctx context.Context, region string, page int) (*models.MovieListResponse, error) {
+	return c.getMovieList(ctx, "upcoming", region, page)
+}
+
+// GetNowPlayingMovies gets movies currently in theaters in a region
+func (c *TMDBClient) GetNowPlayingMovies(ctx context.Context, region string, page int) (*models.MovieListResponse, error) {
+	return c.getMovieList(ctx, "now_playing", region, page)
+}
+
+// getMovieList fetches /movie/{list} for a region
+func (c *TMDBClient) getMovieList(ctx context.Context, list, region string, page int) (*models.MovieListResponse, error) {
+	cacheKey := fmt.Sprintf("movie_list_%s_%s_%d", list, region, page) + localeCacheKey(ctx)
+
+	// Check cache first
+	if cached := c.cache.Get(cacheKey); cached != nil {
+		if result, ok := cached.(*models.MovieListResponse); ok {
+			return result, nil
+		}
 	}
 
-	// Build URL
 	params := url.Values{}
-	params.Add("api_key", c.config.APIKey)
+	params.Add("page", strconv.Itoa(page))
+	if region != "" {
+		params.Add("region", region)
+	}
 
-	url := fmt.Sprintf("%s/movie/%d?%s", c.config.BaseURL, movieID, params.Encode())
+	var result models.MovieListResponse
+	if err := c.getLocalized(ctx, fmt.Sprintf("/movie/%s", list), params, &result); err != nil {
+		return nil, fmt.Errorf("failed to get %s movies: %w", strings.ReplaceAll(list, "_", " "), err)
+	}
 
-	// Make request
-	resp, err := c.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get movie details: %w", err)
+	// Cache the result
+	c.cache.Set(cacheKey, &result, 30*time.Minute)
+
+	return &result, nil
+}
+
+// GetMovieReleaseDates gets a movie's release dates and certifications in
+// every country
+func (c *TMDBClient) GetMovieReleaseDates(ctx context.Context, movieID int) (*models.ReleaseDates, error) {
+	cacheKey := fmt.Sprintf("release_dates_%d", movieID)
+
+	// Check cache first
+	if cached := c.cache.Get(cacheKey); cached != nil {
+		if releaseDates, ok := cached.(*models.ReleaseDates); ok {
+			return releaseDates, nil
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API error: %d", resp.StatusCode)
+	var releaseDates models.ReleaseDates
+	if err := c.get(ctx, fmt.Sprintf("/movie/%d/release_dates", movieID), nil, &releaseDates); err != nil {
+		return nil, fmt.Errorf("failed to get release dates: %w", err)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	// Cache the result
+	c.cache.Set(cacheKey, &releaseDates, 30*time.Minute)
+
+	return &releaseDates, nil
+}
+
+// GetTVContentRatings gets a TV show's content rating in every country
+func (c *TMDBClient) GetTVContentRatings(ctx context.Context, tvID int) (*models.ContentRatings, error) {
+	cacheKey := fmt.Sprintf("content_ratings_%d", tvID)
+
+	// Check cache first
+	if cached := c.cache.Get(cacheKey); cached != nil {
+		if contentRatings, ok := cached.(*models.ContentRatings); ok {
+			return contentRatings, nil
+		}
 	}
 
-	var movie models.Movie
-	if err := json.Unmarshal(body, &movie); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	var contentRatings models.ContentRatings
+	if err := c.get(ctx, fmt.Sprintf("/tv/%d/content_ratings", tvID), nil, &contentRatings); err != nil {
+		return nil, fmt.Errorf("failed to get content ratings: %w", err)
 	}
 
 	// Cache the result
-	c.cache.Set(cacheKey, &movie, 30*time.Minute)
+	c.cache.Set(cacheKey, &contentRatings, 30*time.Minute)
 
-	return &movie, nil
+	return &contentRatings, nil
 }
 
-// GetTVShowDetails gets detailed TV show information
-func (c *TMDBClient) GetTVShowDetails(tvID int) (*models.TVShow, error) {
-	cacheKey := fmt.Sprintf("tv_details_%d", tvID)
+// GetCollection gets a collection and the movies in it
+func (c *TMDBClient) GetCollection(ctx context.Context, collectionID int) (*models.Collection, error) {
+	cacheKey := fmt.Sprintf("collection_%d", collectionID) + localeCacheKey(ctx)
 
 	// Check cache first
 	if cached := c.cache.Get(cacheKey); cached != nil {
-		if tvShow, ok := cached.(*models.TVShow); ok {
-			return tvShow, nil
+		if collection, ok := cached.(*models.Collection); ok {
+			return collection, nil
 		}
 	}
 
-	// Rate limiting
-	if err := c.rateLimiter.Wait(); err != nil {
-		return nil, err
+	var collection models.Collection
+	if err := c.getLocalized(ctx, fmt.Sprintf("/collection/%d", collectionID), nil, &collection); err != nil {
+		return nil, fmt.Errorf("failed to get collection: %w", err)
 	}
 
-	// Build URL
-	params := url.Values{}
-	params.Add("api_key", c.config.APIKey)
+	// Fall back to English overviews when there is no translation
+	if !LocaleFromContext(ctx).IsEnglish() && collectionMissingOverviews(&collection) {
+		if english, err := c.GetCollection(englishContext(ctx), collectionID); err == nil {
+			fillCollectionOverviews(&collection, english)
+		}
+	}
 
-	url := fmt.Sprintf("%s/tv/%d?%s", c.config.BaseURL, tvID, params.Encode())
+	// Cache the result
+	c.cache.Set(cacheKey, &collection, 30*time.Minute)
 
-	// Make request
-	resp, err := c.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get TV show details: %w", err)
+	return &collection, nil
+}
+
+// collectionMissingOverviews reports whether a collection or any of its
+// movies has no overview
+func collectionMissingOverviews(collection *models.Collection) bool {
+	if collection.Overview == "" {
+		return true
+	}
+	for _, part := range collection.Parts {
+		if part.Overview == "" {
+			return true
+		}
 	}
-	defer resp.Body.Close()
+	return false
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API error: %d", resp.StatusCode)
+// fillCollectionOverviews copies overviews from the English collection into
+// the localized one where it has none
+func fillCollectionOverviews(collection, english *models.Collection) {
+	if collection.Overview == "" {
+		collection.Overview = english.Overview
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	overviews := make(map[int]string)
+	for _, part := range english.Parts {
+		overviews[part.ID] = part.Overview
 	}
+	for i := range collection.Parts {
+		if collection.Parts[i].Overview == "" {
+			collection.Parts[i].Overview = overviews[collection.Parts[i].ID]
+		}
+	}
+}
 
-	var tvShow models.TVShow
-	if err := json.Unmarshal(body, &tvShow); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+// SearchPeople searches for actors, directors and other crew
+func (c *TMDBClient) SearchPeople(ctx context.Context, query string, page int) (*models.PersonSearchResult, error) {
+	cacheKey := fmt.Sprintf("search_people_%s_%d", query, page) + localeCacheKey(ctx)
+
+	// Check cache first
+	if cached := c.cache.Get(cacheKey); cached != nil {
+		if result, ok := cached.(*models.PersonSearchResult); ok {
+			return result, nil
+		}
+	}
+
+	params := url.Values{}
+	params.Add("query", query)
+	params.Add("page", strconv.Itoa(page))
+
+	var result models.PersonSearchResult
+	if err := c.getLocalized(ctx, "/search/person", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to search people: %w", err)
 	}
 
 	// Cache the result
-	c.cache.Set(cacheKey, &tvShow, 30*time.Minute)
+	c.cache.Set(cacheKey, &result, 30*time.Minute)
 
-	return &tvShow, nil
+	return &result, nil
 }
 
-// GetTrendingMovies gets trending movies
-func (c *TMDBClient) GetTrendingMovies(timeWindow string, page int) (*models.TrendingResponse, error) {
-	cacheKey := fmt.Sprintf("trending_movies_%s_%d", timeWindow, page)
+// SearchKeywords searches TMDB keywords by name
+func (c *TMDBClient) SearchKeywords(ctx context.Context, query string, page int) (*models.KeywordSearchResult, error) {
+	cacheKey := fmt.Sprintf("search_keywords_%s_%d", query, page)
 
 	// Check cache first
 	if cached := c.cache.Get(cacheKey); cached != nil {
-		if result, ok := cached.(*models.TrendingResponse); ok {
+		if result, ok := cached.(*models.KeywordSearchResult); ok {
 			return result, nil
 		}
 	}
 
-	// Rate limiting
-	if err := c.rateLimiter.Wait(); err != nil {
-		return nil, err
+	params := url.Values{}
+	params.Add("query", query)
+	params.Add("page", strconv.Itoa(page))
+
+	var result models.KeywordSearchResult
+	if err := c.get(ctx, "/search/keyword", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to search keywords: %w", err)
+	}
+
+	// Cache the result
+	c.cache.Set(cacheKey, &result, 30*time.Minute)
+
+	return &result, nil
+}
+
+// SearchMulti searches movies, TV shows and people in one call
+func (c *TMDBClient) SearchMulti(ctx context.Context, query string, page int) (*models.MultiSearchResult, error) {
+	cacheKey := fmt.Sprintf("search_multi_%s_%d", query, page) + localeCacheKey(ctx)
+
+	// Check cache first
+	if cached := c.cache.Get(cacheKey); cached != nil {
+		if result, ok := cached.(*models.MultiSearchResult); ok {
+			return result, nil
+		}
 	}
 
-	// Build URL
 	params := url.Values{}
-	params.Add("api_key", c.config.APIKey)
+	params.Add("query", query)
 	params.Add("page", strconv.Itoa(page))
+	params.Add("include_adult", "false")
 
-	url := fmt.Sprintf("%s/trending/movie/%s?%s", c.config.BaseURL, timeWindow, params.Encode())
+	var result models.MultiSearchResult
+	if err := c.getLocalized(ctx, "/search/multi", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
 
-	// Make request
-	resp, err := c.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get trending movies: %w", err)
+	// Cache the result
+	c.cache.Set(cacheKey, &result, 30*time.Minute)
+
+	return &result, nil
+}
+
+// GetPersonDetails gets a person's biography and external IDs
+func (c *TMDBClient) GetPersonDetails(ctx context.Context, personID int) (*models.Person, error) {
+	cacheKey := fmt.Sprintf("person_details_%d", personID) + localeCacheKey(ctx)
+
+	// Check cache first
+	if cached := c.cache.Get(cacheKey); cached != nil {
+		if person, ok := cached.(*models.Person); ok {
+			return person, nil
+		}
+	}
+
+	var person models.Person
+	if err := c.getLocalized(ctx, fmt.Sprintf("/person/%d", personID), nil, &person); err != nil {
+		return nil, fmt.Errorf("failed to get person details: %w", err)
+	}
+
+	// Fall back to the English biography when there is no translation
+	if person.Biography == "" && !LocaleFromContext(ctx).IsEnglish() {
+		if english, err := c.GetPersonDetails(englishContext(ctx), personID); err == nil {
+			person.Biography = english.Biography
+		}
+	}
+
+	// Cache the result
+	c.cache.Set(cacheKey, &person, 30*time.Minute)
+
+	return &person, nil
+}
+
+// GetPersonCombinedCredits gets a person's movie and TV credits
+func (c *TMDBClient) GetPersonCombinedCredits(ctx context.Context, personID int) (*models.CombinedCredits, error) {
+	cacheKey := fmt.Sprintf("person_credits_%d", personID) + localeCacheKey(ctx)
+
+	// Check cache first
+	if cached := c.cache.Get(cacheKey); cached != nil {
+		if credits, ok := cached.(*models.CombinedCredits); ok {
+			return credits, nil
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API error: %d", resp.StatusCode)
+	var credits models.CombinedCredits
+	if err := c.getLocalized(ctx, fmt.Sprintf("/person/%d/combined_credits", personID), nil, &credits); err != nil {
+		return nil, fmt.Errorf("failed to get person credits: %w", err)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	// Cache the result
+	c.cache.Set(cacheKey, &credits, 30*time.Minute)
+
+	return &credits, nil
+}
+
+// GetTrendingMovies gets trending movies
+func (c *TMDBClient) GetTrendingMovies(ctx context.Context, timeWindow string, page int) (*models.TrendingResponse, error) {
+	cacheKey := fmt.Sprintf("trending_movies_%s_%d", timeWindow, page) + localeCacheKey(ctx)
+
+	// Check cache first
+	if cached := c.cache.Get(cacheKey); cached != nil {
+		if result, ok := cached.(*models.TrendingResponse); ok {
+			return result, nil
+		}
 	}
 
+	params := url.Values{}
+	params.Add("page", strconv.Itoa(page))
+
 	var result models.TrendingResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := c.getLocalized(ctx, fmt.Sprintf("/trending/movie/%s", timeWindow), params, &result); err != nil {
+		return nil, fmt.Errorf("failed to get trending movies: %w", err)
+	}
+
+	// Fill in overviews TMDB has no translation of
+	if needsEnglishOverviews(ctx, result.Results) {
+		if english, err := c.GetTrendingMovies(englishContext(ctx), timeWindow, page); err == nil {
+			fillEnglishOverviews(result.Results, english.Results)
+		}
 	}
 
 	// Cache the result
@@ -331,40 +601,3 @@ func (c *Cache) Set(key string, data interface{}, duration time.Duration) {
 		ExpiresAt: time.Now().Add(duration),
 	}
 }
-
-// RateLimiter methods
-func (rl *RateLimiter) Wait() error {
-	now := time.Now()
-
-	// Remove old requests outside the window
-	for {
-		select {
-		case t := <-rl.requests:
-			if now.Sub(t) < rl.window {
-				// Put it back if it's still within the window
-				select {
-				case rl.requests <- t:
-				default:
-					return fmt.Errorf("rate limit exceeded")
-				}
-				goto checkLimit
-			}
-		default:
-			goto checkLimit
-		}
-	}
-
-checkLimit:
-	// Check if we can make a new request
-	if len(rl.requests) >= rl.limit {
-		return fmt.Errorf("rate limit exceeded")
-	}
-
-	// Add current request
-	select {
-	case rl.requests <- now:
-		return nil
-	default:
-		return fmt.Errorf("rate limit exceeded")
-	}
-}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"movie-discovery-app/internal/models"
+)
+
+// ErrNoTrailer is returned when neither TMDB nor YouTube has a trailer
+var ErrNoTrailer = errors.New("no trailers found")
+
+// Trailer sources
+const (
+	TrailerSourceTMDB    = "tmdb"
+	TrailerSourceYouTube = "youtube"
+)
+
+// defaultTrailerLanguage is the language preferred when ranking trailers
+const defaultTrailerLanguage = "en"
+
+// trailerTypeRank orders the TMDB video types used as trailers
+var trailerTypeRank = map[string]int{
+	"Trailer": 0,
+	"Teaser":  1,
+}
+
+// rankTrailers picks the trailers and teasers hosted on YouTube out of a
+// title's TMDB videos and orders them best first: official before
+// unofficial, the preferred language before others, trailers before teasers,
+// then newest first
+func rankTrailers(videos []models.Trailer, language string) []models.Trailer {
+	trailers := make([]models.Trailer, 0, len(videos))
+	for _, video := range videos {
+		if _, ok := trailerTypeRank[video.Type]; !ok || video.Site != "YouTube" || video.Key == "" {
+			continue
+		}
+		trailers = append(trailers, video)
+	}
+
+	sort.SliceStable(trailers, func(i, j int) bool {
+		a, b := trailers[i], trailers[j]
+		if a.Official != b.Official {
+			return a.Official
+		}
+		if langA, langB := a.ISO639_1 == language, b.ISO639_1 == language; langA != langB {
+			return langA
+		}
+		if trailerTypeRank[a.Type] != trailerTypeRank[b.Type] {
+			return trailerTypeRank[a.Type] < trailerTypeRank[b.Type]
+		}
+		// TMDB timestamps are ISO 8601 in UTC, so they sort as strings
+		return a.PublishedAt > b.PublishedAt
+	})
+
+	return trailers
+}
+
+// trailerVideo converts a TMDB video to the trailer shape the API returns
+func trailerVideo(video models.Trailer) models.YouTubeVideo {
+	return models.YouTubeVideo{
+		VideoID:     video.Key,
+		Title:       video.Name,
+		Thumbnail:   fmt.Sprintf("https://i.ytimg.com/vi/%s/hqdefault.jpg", video.Key),
+		PublishedAt: video.PublishedAt,
+		Source:      TrailerSourceTMDB,
+		Type:        video.Type,
+		Official:    video.Official,
+		Language:    video.ISO639_1,
+	}
+}
+
+// getTrailers returns a title's trailers, best first. TMDB's curated videos
+// are used when there are any; otherwise YouTube is searched by title and
+// year if it is configured and has quota left.
+func (s *DiscoveryService) getTrailers(ctx context.Context, mediaID int, mediaType string) ([]models.YouTubeVideo, error) {
+	var videos *models.TrailerResponse
+	var err error
+	switch mediaType {
+	case "movie":
+		videos, err = s.tmdbClient.GetMovieVideos(ctx, mediaID)
+	case "tv":
+		videos, err = s.tmdbClient.GetTVVideos(ctx, mediaID)
+	default:
+		return nil, fmt.Errorf("unsupported media type: %s", mediaType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if ranked := rankTrailers(videos.Results, defaultTrailerLanguage); len(ranked) > 0 {
+		trailers := make([]models.YouTubeVideo, len(ranked))
+		for i, video := range ranked {
+			trailers[i] = trailerVideo(video)
+		}
+		return trailers, nil
+	}
+
+	// Without a key or quota left, there is nothing to fall back to
+	if !s.youtubeService.IsConfigured() || s.youtubeService.Quota().Exhausted {
+		return []models.YouTubeVideo{}, nil
+	}
+
+	title, year, err := s.titleAndYear(ctx, mediaID, mediaType)
+	if err != nil {
+		return nil, err
+	}
+
+	trailers, err := s.youtubeService.SearchTrailers(ctx, title, year, mediaType)
+	if errors.Is(err, ErrQuotaExhausted) {
+		return []models.YouTubeVideo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range trailers {
+		trailers[i].Source = TrailerSourceYouTube
+	}
+	return trailers, nil
+}
+
+// titleAndYear looks up the title and release year used to search YouTube
+func (s *DiscoveryService) titleAndYear(ctx context.Context, mediaID int, mediaType string) (string, string, error) {
+	if mediaType == "tv" {
+		tvShow, err := s.tmdbClient.GetTVShowDetails(ctx, mediaID)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get TV show details: %w", err)
+		}
+		return tvShow.Name, releaseYear(tvShow.FirstAirDate), nil
+	}
+
+	movie, err := s.tmdbClient.GetMovieDetails(ctx, mediaID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get movie details: %w", err)
+	}
+	return movie.Title, releaseYear(movie.ReleaseDate), nil
+}
+
+// releaseYear returns the year of a TMDB date, or "" if there is none
+func releaseYear(date string) string {
+	if len(date) < 4 {
+		return ""
+	}
+	return date[:4]
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"movie-discovery-app/internal/models"
+)
+
+func TestRankTrailers(t *testing.T) {
+	videos := []models.Trailer{
+		{Key: "clip", Name: "Clip", Site: "YouTube", Type: "Clip", Official: true, ISO639_1: "en"},
+		{Key: "fan", Name: "Fan Trailer", Site: "YouTube", Type: "Trailer", ISO639_1: "en", PublishedAt: "2024-01-01T00:00:00.000Z"},
+		{Key: "teaser", Name: "Teaser", Site: "YouTube", Type: "Teaser", Official: true, ISO639_1: "en", PublishedAt: "2023-06-01T00:00:00.000Z"},
+		{Key: "vimeo", Name: "Trailer", Site: "Vimeo", Type: "Trailer", Official: true, ISO639_1: "en"},
+		{Key: "german", Name: "Trailer (German)", Site: "YouTube", Type: "Trailer", Official: true, ISO639_1: "de", PublishedAt: "2023-10-01T00:00:00.000Z"},
+		{Key: "first", Name: "Trailer 1", Site: "YouTube", Type: "Trailer", Official: true, ISO639_1: "en", PublishedAt: "2023-08-01T00:00:00.000Z"},
+		{Key: "final", Name: "Final Trailer", Site: "YouTube", Type: "Trailer", Official: true, ISO639_1: "en", PublishedAt: "2023-10-01T00:00:00.000Z"},
+	}
+
+	ranked := rankTrailers(videos, "en")
+
+	expected := []string{"final", "first", "teaser", "german", "fan"}
+	if len(ranked) != len(expected) {
+		t.Fatalf("Expected %d trailers, got %+v", len(expected), ranked)
+	}
+	for i, key := range expected {
+		if ranked[i].Key != key {
+			t.Errorf("Position %d: expected %q, got %q", i, key, ranked[i].Key)
+		}
+	}
+}
+
+func TestDiscoveryService_GetOfficialTrailer_FromTMDB(t *testing.T) {
+	var paths []string
+	tmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`{"id": 27205, "results": [
+			{"iso_639_1": "en", "key": "teaser", "name": "Teaser", "site": "YouTube", "type": "Teaser", "official": true, "published_at": "2009-08-01T00:00:00.000Z"},
+			{"iso_639_1": "en", "key": "YoHD9XEInc0", "name": "Official Trailer", "site": "YouTube", "type": "Trailer", "official": true, "published_at": "2010-05-11T00:00:00.000Z"}
+		]}`))
+	}))
+	defer tmdb.Close()
+
+	// No YouTube key is configured
+	service := newTestDiscoveryService(tmdb.URL, "")
+
+	trailer, err := service.GetOfficialTrailer(context.Background(), 27205, "movie")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if trailer.VideoID != "YoHD9XEInc0" || trailer.Source != TrailerSourceTMDB || !trailer.Official {
+		t.Errorf("Expected the official TMDB trailer, got %+v", trailer)
+	}
+	if len(paths) != 1 || paths[0] != "/movie/27205/videos" {
+		t.Errorf("Expected a single request for /movie/27205/videos, got %v", paths)
+	}
+}
+
+func TestDiscoveryService_GetTrailers_YouTubeFallback(t *testing.T) {
+	tmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/videos") {
+			w.Write([]byte(`{"id": 1399, "results": []}`))
+			return
+		}
+		w.Write([]byte(`{"id": 1399, "name": "Game of Thrones", "first_air_date": "2011-04-17"}`))
+	}))
+	defer tmdb.Close()
+
+	var query string
+	youtube := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" {
+			w.Write([]byte(`{"items": []}`))
+			return
+		}
+		query = r.URL.Query().Get("q")
+		w.Write([]byte(`{"items": [{"id": {"videoId": "KPLWWIOCOOQ"}, "snippet": {"title": "Game of Thrones Season 1 Trailer"}}]}`))
+	}))
+	defer youtube.Close()
+
+	service := newTestDiscoveryService(tmdb.URL, "")
+
+	// Without a YouTube key there is nothing to fall back to
+	if _, err := service.GetOfficialTrailer(context.Background(), 1399, "tv"); !errors.Is(err, ErrNoTrailer) {
+		t.Fatalf("Expected ErrNoTrailer, got %v", err)
+	}
+
+	service.youtubeService = newTestYouTubeService(youtube.URL, 10000)
+
+	trailers, err := service.GetTVTrailers(context.Background(), 1399)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if query != "Game of Thrones trailer 2011 tv series" {
+		t.Errorf("Unexpected YouTube query %q", query)
+	}
+	if len(trailers) != 1 || trailers[0].VideoID != "KPLWWIOCOOQ" || trailers[0].Source != TrailerSourceYouTube {
+		t.Errorf("Expected the YouTube search result, got %+v", trailers)
+	}
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"movie-discovery-app/configs"
+)
+
+// Upstream error kinds. Use errors.Is to classify an error returned by any
+// upstream-backed service method.
+var (
+	ErrUpstreamNotFound    = errors.New("not found")
+	ErrUpstreamRateLimited = errors.New("rate limited")
+	ErrUpstreamUnavailable = errors.New("unavailable")
+)
+
+// UpstreamError describes a failed call to an upstream API
+type UpstreamError struct {
+	Service    string        // Upstream name, e.g. "TMDB"
+	StatusCode int           // HTTP status, 0 for network failures
+	RetryAfter time.Duration // Server-requested delay, if any
+	Kind       error         // One of the ErrUpstream* kinds, or nil
+	Err        error         // Underlying cause, if any
+}
+
+func (e *UpstreamError) Error() string {
+	msg := fmt.Sprintf("%s API error", e.Service)
+	if e.StatusCode != 0 {
+		msg = fmt.Sprintf("%s: %d", msg, e.StatusCode)
+	}
+	if e.Kind != nil {
+		msg = fmt.Sprintf("%s (%v)", msg, e.Kind)
+	}
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+// Unwrap exposes both the error kind and the underlying cause to errors.Is
+func (e *UpstreamError) Unwrap() []error {
+	var errs []error
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// RetryPolicy controls how idempotent upstream requests are retried
+type RetryPolicy struct {
+	MaxAttempts int           // Total attempts including the first
+	BaseDelay   time.Duration // Backoff before the first retry
+	MaxDelay    time.Duration // Upper bound for a single backoff
+	MaxElapsed  time.Duration // Total time budget across all attempts
+}
+
+// newRetryPolicy builds a retry policy from configuration, filling in
+// defaults for unset values
+func newRetryPolicy(retryConfig *configs.RetryConfig) RetryPolicy {
+	policy := RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		MaxElapsed:  15 * time.Second,
+	}
+	if retryConfig == nil {
+		return policy
+	}
+
+	if retryConfig.MaxAttempts > 0 {
+		policy.MaxAttempts = retryConfig.MaxAttempts
+	}
+	if retryConfig.BaseDelay > 0 {
+		policy.BaseDelay = retryConfig.BaseDelay
+	}
+	if retryConfig.MaxDelay > 0 {
+		policy.MaxDelay = retryConfig.MaxDelay
+	}
+	if retryConfig.MaxElapsed > 0 {
+		policy.MaxElapsed = retryConfig.MaxElapsed
+	}
+	return policy
+}
+
+// backoff returns a jittered exponential delay for the given retry number
+// (1 for the first retry)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	// Full jitter keeps concurrent clients from retrying in lockstep
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// upstreamClient is the shared HTTP layer for upstream APIs. It applies rate
+// limiting, retries idempotent GETs on 429/5xx and network errors, and
+// classifies failures into UpstreamError values. A circuit breaker
+// short-circuits calls while the upstream is failing.
+type upstreamClient struct {
+	name        string
+	httpClient  *http.Client
+	rateLimiter *RateLimiter
+	breaker     *CircuitBreaker
+	retry       RetryPolicy
+}
+
+// newUpstreamClient creates an upstream client with the given request timeout
+func newUpstreamClient(name string, timeout time.Duration, rateLimiter *RateLimiter, breaker *CircuitBreaker, retry RetryPolicy) *upstreamClient {
+	return &upstreamClient{
+		name: name,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+		rateLimiter: rateLimiter,
+		breaker:     breaker,
+		retry:       retry,
+	}
+}
+
+// UpstreamStatus describes the health of one upstream dependency
+type UpstreamStatus struct {
+	BreakerSnapshot
+	RateLimitRemaining int `json:"rate_limit_remaining"`
+	RateLimitBurst     int `json:"rate_limit_burst"`
+}
+
+// Available reports whether the upstream's circuit breaker admits calls
+func (u *upstreamClient) Available() bool {
+	return u.breaker.Available()
+}
+
+// status returns the upstream's breaker and rate limiter state
+func (u *upstreamClient) status() UpstreamStatus {
+	return UpstreamStatus{
+		BreakerSnapshot:    u.breaker.Snapshot(),
+		RateLimitRemaining: u.rateLimiter.Remaining(),
+		RateLimitBurst:     u.rateLimiter.Burst(),
+	}
+}
+
+// getJSON performs a GET request and decodes a 200 response body into out
+func (u *upstreamClient) getJSON(ctx context.Context, requestURL string, out interface{}) error {
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		err := u.doJSON(ctx, requestURL, out)
+		if err == nil {
+			return nil
+		}
+
+		var upstreamErr *UpstreamError
+		if !errors.As(err, &upstreamErr) || !isRetryable(upstreamErr) || ctx.Err() != nil {
+			return err
+		}
+		if attempt >= u.retry.MaxAttempts {
+			return err
+		}
+
+		delay := u.retry.backoff(attempt)
+		if upstreamErr.RetryAfter > delay {
+			delay = upstreamErr.RetryAfter
+		}
+		if time.Since(start)+delay > u.retry.MaxElapsed {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// doJSON performs a single attempt of getJSON
+func (u *upstreamClient) doJSON(ctx context.Context, requestURL string, out interface{}) error {
+	// Check the breaker first so an open circuit fails fast without
+	// spending a rate limit token
+	if err := u.breaker.Allow(); err != nil {
+		return &UpstreamError{Service: u.name, Kind: ErrUpstreamUnavailable, Err: err}
+	}
+
+	if err := u.rateLimiter.Wait(ctx); err != nil {
+		u.breaker.RecordIgnored()
+		return &UpstreamError{Service: u.name, Kind: ErrUpstreamRateLimited, Err: err}
+	}
+
+	body, err := u.do(ctx, requestURL)
+	switch {
+	case err == nil:
+		u.breaker.RecordSuccess()
+	case ctx.Err() != nil:
+		u.breaker.RecordIgnored()
+	case errors.Is(err, ErrUpstreamUnavailable):
+		u.breaker.RecordFailure()
+	default:
+		// The upstream answered, e.g. with a 404 or 429, so it is healthy
+		u.breaker.RecordSuccess()
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
+
+// do sends a single GET request and returns the body of a 200 response
+func (u *upstreamClient) do(ctx context.Context, requestURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return nil, &UpstreamError{Service: u.name, Kind: ErrUpstreamUnavailable, Err: stripURL(err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return nil, &UpstreamError{
+			Service:    u.name,
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			Kind:       kindForStatus(resp.StatusCode),
+		}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &UpstreamError{Service: u.name, Kind: ErrUpstreamUnavailable, Err: fmt.Errorf("failed to read response: %w", err)}
+	}
+
+	return body, nil
+}
+
+// kindForStatus maps an HTTP status code to an upstream error kind
+func kindForStatus(statusCode int) error {
+	switch {
+	case statusCode == http.StatusNotFound:
+		return ErrUpstreamNotFound
+	case statusCode == http.StatusTooManyRequests:
+		return ErrUpstreamRateLimited
+	case statusCode >= 500:
+		return ErrUpstreamUnavailable
+	default:
+		return nil
+	}
+}
+
+// isRetryable reports whether a failed attempt is worth retrying. Local rate
+// limiter rejections are not retried since they already waited for a token,
+// and open breakers are not retried since they are meant to fail fast.
+func isRetryable(err *UpstreamError) bool {
+	if errors.Is(err, ErrRateLimitExceeded) || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	return errors.Is(err, ErrUpstreamRateLimited) || errors.Is(err, ErrUpstreamUnavailable)
+}
+
+// parseRetryAfter parses a Retry-After header given either as delay seconds
+// or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
+
+// stripURL removes the request URL from transport errors so API keys in
+// query strings don't leak into logs and responses
+func stripURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestUpstream(maxAttempts int) *upstreamClient {
+	return newUpstreamClient("TEST", 5*time.Second, NewRateLimiter(6000, 100), NewCircuitBreaker("test", 100, time.Minute), RetryPolicy{
+		MaxAttempts: maxAttempts,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+		MaxElapsed:  5 * time.Second,
+	})
+}
+
+func TestUpstreamClient_RetriesServerErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"page": 2}`))
+	}))
+	defer server.Close()
+
+	var result struct {
+		Page int `json:"page"`
+	}
+	if err := newTestUpstream(3).getJSON(context.Background(), server.URL, &result); err != nil {
+		t.Fatalf("Expected success after retries, got %v", err)
+	}
+
+	if result.Page != 2 {
+		t.Errorf("Expected page 2, got %d", result.Page)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestUpstreamClient_CapsAttempts(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	var result map[string]interface{}
+	err := newTestUpstream(2).getJSON(context.Background(), server.URL, &result)
+	if !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Errorf("Expected ErrUpstreamUnavailable, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 attempts, got %d", calls)
+	}
+}
+
+func TestUpstreamClient_NotFoundIsNotRetried(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	var result map[string]interface{}
+	err := newTestUpstream(3).getJSON(context.Background(), server.URL, &result)
+	if !errors.Is(err, ErrUpstreamNotFound) {
+		t.Errorf("Expected ErrUpstreamNotFound, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 attempt, got %d", calls)
+	}
+}
+
+func TestUpstreamClient_HonoursRetryAfter(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	var result map[string]interface{}
+	if err := newTestUpstream(3).getJSON(context.Background(), server.URL, &result); err != nil {
+		t.Fatalf("Expected success after Retry-After, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Expected retry to wait for Retry-After, took %v", elapsed)
+	}
+}
+
+func TestUpstreamClient_RetryAfterBeyondBudget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	var result map[string]interface{}
+	err := newTestUpstream(3).getJSON(context.Background(), server.URL, &result)
+	if !errors.Is(err, ErrUpstreamRateLimited) {
+		t.Fatalf("Expected ErrUpstreamRateLimited, got %v", err)
+	}
+
+	var upstreamErr *UpstreamError
+	if !errors.As(err, &upstreamErr) || upstreamErr.RetryAfter != 120*time.Second {
+		t.Errorf("Expected RetryAfter of 120s to be surfaced, got %v", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "Empty", value: "", want: 0},
+		{name: "Seconds", value: "30", want: 30 * time.Second},
+		{name: "Negative", value: "-5", want: 0},
+		{name: "Past date", value: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0},
+		{name: "Garbage", value: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value); got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(future); got <= 0 || got > time.Minute {
+		t.Errorf("parseRetryAfter(%q) = %v, want about a minute", future, got)
+	}
+}
+
+func TestCircuitBreaker_OpensAndRecovers(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker("test", 3, 30*time.Second)
+	breaker.now = func() time.Time { return now }
+
+	// Consecutive failures below the threshold keep the breaker closed
+	for i := 0; i < 2; i++ {
+		breaker.RecordFailure()
+	}
+	if breaker.State() != BreakerClosed {
+		t.Fatalf("Expected closed below threshold, got %s", breaker.State())
+	}
+
+	breaker.RecordFailure()
+	if breaker.State() != BreakerOpen {
+		t.Fatalf("Expected open at threshold, got %s", breaker.State())
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen while open, got %v", err)
+	}
+
+	// After the cooldown a single probe is admitted
+	now = now.Add(31 * time.Second)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Expected probe to be admitted after cooldown, got %v", err)
+	}
+	if breaker.State() != BreakerHalfOpen {
+		t.Errorf("Expected half_open, got %s", breaker.State())
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected second call during probe to be rejected, got %v", err)
+	}
+
+	// A failed probe re-opens the breaker for another cooldown
+	breaker.RecordFailure()
+	if breaker.State() != BreakerOpen {
+		t.Fatalf("Expected open after failed probe, got %s", breaker.State())
+	}
+
+	now = now.Add(31 * time.Second)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Expected probe to be admitted, got %v", err)
+	}
+	breaker.RecordSuccess()
+	if breaker.State() != BreakerClosed {
+		t.Errorf("Expected closed after successful probe, got %s", breaker.State())
+	}
+
+	snapshot := breaker.Snapshot()
+	if snapshot.Opens != 2 || snapshot.Rejected != 2 {
+		t.Errorf("Expected 2 opens and 2 rejections, got %d and %d", snapshot.Opens, snapshot.Rejected)
+	}
+}
+
+func TestUpstreamClient_OpenBreakerShortCircuits(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	upstream := newTestUpstream(1)
+	upstream.breaker = NewCircuitBreaker("test", 2, time.Minute)
+
+	var result map[string]interface{}
+	for i := 0; i < 2; i++ {
+		upstream.getJSON(context.Background(), server.URL, &result)
+	}
+
+	err := upstream.getJSON(context.Background(), server.URL, &result)
+	if !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Errorf("Expected open circuit error, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected no request while the breaker is open, got %d requests", calls)
+	}
+	if upstream.Available() {
+		t.Error("Expected upstream to report unavailable")
+	}
+}
+
+func TestUpstreamClient_DeadlineCancelsRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	upstream := newTestUpstream(3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	var result map[string]interface{}
+	err := upstream.getJSON(ctx, server.URL, &result)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the request to stop at the deadline, took %v", elapsed)
+	}
+
+	// An abandoned call says nothing about upstream health
+	if snapshot := upstream.breaker.Snapshot(); snapshot.Failures != 0 {
+		t.Errorf("Expected no breaker failures, got %d", snapshot.Failures)
+	}
+}
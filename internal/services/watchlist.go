@@ -14,11 +14,11 @@ import (
 	"github.com/jung-kurt/gofpdf/v2"
 )
 
-// WatchlistService manages user watchlists
+// WatchlistService manages each profile's watchlist
 // Note: In a real application, this would be backed by a database
 // For this demo, we're using in-memory storage that simulates localStorage
 type WatchlistService struct {
-	watchlists map[string][]models.WatchlistItem // userID -> watchlist items
+	watchlists map[string][]models.WatchlistItem // profileID -> watchlist items
 	mu         sync.RWMutex
 }
 
@@ -29,8 +29,8 @@ func NewWatchlistService() *WatchlistService {
 	}
 }
 
-// AddToWatchlist adds an item to user's watchlist
-func (s *WatchlistService) AddToWatchlist(userID string, item models.WatchlistItem) error {
+// AddToWatchlist adds an item to a profile's watchlist
+func (s *WatchlistService) AddToWatchlist(profileID string, item models.WatchlistItem) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -42,8 +42,8 @@ func (s *WatchlistService) AddToWatchlist(userID string, item models.WatchlistIt
 	// Set added timestamp
 	item.AddedAt = time.Now()
 
-	// Get user's watchlist
-	watchlist, exists := s.watchlists[userID]
+	// Get the profile's watchlist
+	watchlist, exists := s.watchlists[profileID]
 	if !exists {
 		watchlist = []models.WatchlistItem{}
 	}
@@ -57,17 +57,45 @@ func (s *WatchlistService) AddToWatchlist(userID string, item models.WatchlistIt
 
 	// Add item to watchlist
 	watchlist = append(watchlist, item)
-	s.watchlists[userID] = watchlist
+	s.watchlists[profileID] = watchlist
 
 	return nil
 }
 
-// RemoveFromWatchlist removes an item from user's watchlist
-func (s *WatchlistService) RemoveFromWatchlist(userID, itemID, itemType string) error {
+// AddAllToWatchlist adds several items to a profile's watchlist. Every item
+// is checked before any is added, so on error the watchlist is unchanged.
+func (s *WatchlistService) AddAllToWatchlist(profileID string, items []models.WatchlistItem) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	watchlist, exists := s.watchlists[userID]
+	// Validate items, including against each other
+	existing := indexWatchlist(s.watchlists[profileID])
+	for _, item := range items {
+		if err := s.validateWatchlistItem(item); err != nil {
+			return fmt.Errorf("%s %s: %w", item.Type, item.ID, err)
+		}
+		key := item.Type + ":" + item.ID
+		if _, ok := existing[key]; ok {
+			return fmt.Errorf("%s %s: item already in watchlist", item.Type, item.ID)
+		}
+		existing[key] = item
+	}
+
+	addedAt := time.Now()
+	for _, item := range items {
+		item.AddedAt = addedAt
+		s.watchlists[profileID] = append(s.watchlists[profileID], item)
+	}
+
+	return nil
+}
+
+// RemoveFromWatchlist removes an item from a profile's watchlist
+func (s *WatchlistService) RemoveFromWatchlist(profileID, itemID, itemType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	watchlist, exists := s.watchlists[profileID]
 	if !exists {
 		return fmt.Errorf("watchlist not found")
 	}
@@ -77,7 +105,7 @@ func (s *WatchlistService) RemoveFromWatchlist(userID, itemID, itemType string)
 		if item.ID == itemID && item.Type == itemType {
 			// Remove item from slice
 			watchlist = append(watchlist[:i], watchlist[i+1:]...)
-			s.watchlists[userID] = watchlist
+			s.watchlists[profileID] = watchlist
 			return nil
 		}
 	}
@@ -85,12 +113,12 @@ func (s *WatchlistService) RemoveFromWatchlist(userID, itemID, itemType string)
 	return fmt.Errorf("item not found in watchlist")
 }
 
-// GetWatchlist gets user's complete watchlist
-func (s *WatchlistService) GetWatchlist(userID string) ([]models.WatchlistItem, error) {
+// GetWatchlist gets a profile's complete watchlist
+func (s *WatchlistService) GetWatchlist(profileID string) ([]models.WatchlistItem, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	watchlist, exists := s.watchlists[userID]
+	watchlist, exists := s.watchlists[profileID]
 	if !exists {
 		return []models.WatchlistItem{}, nil
 	}
@@ -102,12 +130,12 @@ func (s *WatchlistService) GetWatchlist(userID string) ([]models.WatchlistItem,
 	return result, nil
 }
 
-// MarkAsWatched marks an item as watched in user's watchlist
-func (s *WatchlistService) MarkAsWatched(userID, itemID, itemType string, rating float64) error {
+// MarkAsWatched marks an item as watched in a profile's watchlist
+func (s *WatchlistService) MarkAsWatched(profileID, itemID, itemType string, rating float64) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	watchlist, exists := s.watchlists[userID]
+	watchlist, exists := s.watchlists[profileID]
 	if !exists {
 		return fmt.Errorf("watchlist not found")
 	}
@@ -119,7 +147,7 @@ func (s *WatchlistService) MarkAsWatched(userID, itemID, itemType string, rating
 			if rating > 0 && rating <= 10 {
 				watchlist[i].Rating = rating
 			}
-			s.watchlists[userID] = watchlist
+			s.watchlists[profileID] = watchlist
 			return nil
 		}
 	}
@@ -127,12 +155,12 @@ func (s *WatchlistService) MarkAsWatched(userID, itemID, itemType string, rating
 	return fmt.Errorf("item not found in watchlist")
 }
 
-// MarkAsUnwatched marks an item as unwatched in user's watchlist
-func (s *WatchlistService) MarkAsUnwatched(userID, itemID, itemType string) error {
+// MarkAsUnwatched marks an item as unwatched in a profile's watchlist
+func (s *WatchlistService) MarkAsUnwatched(profileID, itemID, itemType string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	watchlist, exists := s.watchlists[userID]
+	watchlist, exists := s.watchlists[profileID]
 	if !exists {
 		return fmt.Errorf("watchlist not found")
 	}
@@ -142,7 +170,7 @@ func (s *WatchlistService) MarkAsUnwatched(userID, itemID, itemType string) erro
 		if item.ID == itemID && item.Type == itemType {
 			watchlist[i].Watched = false
 			watchlist[i].Rating = 0
-			s.watchlists[userID] = watchlist
+			s.watchlists[profileID] = watchlist
 			return nil
 		}
 	}
@@ -150,9 +178,9 @@ func (s *WatchlistService) MarkAsUnwatched(userID, itemID, itemType string) erro
 	return fmt.Errorf("item not found in watchlist")
 }
 
-// GetWatchedItems gets only watched items from user's watchlist
-func (s *WatchlistService) GetWatchedItems(userID string) ([]models.WatchlistItem, error) {
-	watchlist, err := s.GetWatchlist(userID)
+// GetWatchedItems gets only watched items from a profile's watchlist
+func (s *WatchlistService) GetWatchedItems(profileID string) ([]models.WatchlistItem, error) {
+	watchlist, err := s.GetWatchlist(profileID)
 	if err != nil {
 		return nil, err
 	}
@@ -167,9 +195,9 @@ func (s *WatchlistService) GetWatchedItems(userID string) ([]models.WatchlistIte
 	return watched, nil
 }
 
-// GetUnwatchedItems gets only unwatched items from user's watchlist
-func (s *WatchlistService) GetUnwatchedItems(userID string) ([]models.WatchlistItem, error) {
-	watchlist, err := s.GetWatchlist(userID)
+// GetUnwatchedItems gets only unwatched items from a profile's watchlist
+func (s *WatchlistService) GetUnwatchedItems(profileID string) ([]models.WatchlistItem, error) {
+	watchlist, err := s.GetWatchlist(profileID)
 	if err != nil {
 		return nil, err
 	}
@@ -184,12 +212,12 @@ func (s *WatchlistService) GetUnwatchedItems(userID string) ([]models.WatchlistI
 	return unwatched, nil
 }
 
-// IsInWatchlist checks if an item is in user's watchlist
-func (s *WatchlistService) IsInWatchlist(userID, itemID, itemType string) bool {
+// IsInWatchlist checks if an item is in a profile's watchlist
+func (s *WatchlistService) IsInWatchlist(profileID, itemID, itemType string) bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	watchlist, exists := s.watchlists[userID]
+	watchlist, exists := s.watchlists[profileID]
 	if !exists {
 		return false
 	}
@@ -203,13 +231,59 @@ func (s *WatchlistService) IsInWatchlist(userID, itemID, itemType string) bool {
 	return false
 }
 
-// GetWatchlistStats gets statistics about user's watchlist
-func (s *WatchlistService) GetWatchlistStats(userID string) (map[string]interface{}, error) {
-	watchlist, err := s.GetWatchlist(userID)
+// GetWatchlistStats gets statistics about a profile's watchlist
+func (s *WatchlistService) GetWatchlistStats(profileID string) (map[string]interface{}, error) {
+	watchlist, err := s.GetWatchlist(profileID)
 	if err != nil {
 		return nil, err
 	}
 
+	return watchlistStats(watchlist), nil
+}
+
+// GetHouseholdStats gets the watchlist statistics of each of an account's
+// profiles and of their watchlists combined. The totals also count the
+// distinct titles and the titles on more than one profile's watchlist.
+func (s *WatchlistService) GetHouseholdStats(profiles []models.Profile) (*models.HouseholdStats, error) {
+	household := &models.HouseholdStats{Profiles: make([]models.ProfileStats, 0, len(profiles))}
+
+	var combined []models.WatchlistItem
+	watchers := make(map[string]int) // Title -> profiles with it on their watchlist
+	for _, profile := range profiles {
+		watchlist, err := s.GetWatchlist(profile.Key())
+		if err != nil {
+			return nil, err
+		}
+
+		household.Profiles = append(household.Profiles, models.ProfileStats{
+			ProfileID: profile.ID,
+			Name:      profile.Name,
+			Kid:       profile.Kid,
+			Stats:     watchlistStats(watchlist),
+		})
+		combined = append(combined, watchlist...)
+		for key := range indexWatchlist(watchlist) {
+			watchers[key]++
+		}
+	}
+
+	sharedTitles := 0
+	for _, count := range watchers {
+		if count > 1 {
+			sharedTitles++
+		}
+	}
+
+	household.Totals = watchlistStats(combined)
+	household.Totals["profiles"] = len(profiles)
+	household.Totals["unique_titles"] = len(watchers)
+	household.Totals["shared_titles"] = sharedTitles
+
+	return household, nil
+}
+
+// watchlistStats computes the statistics of a watchlist
+func watchlistStats(watchlist []models.WatchlistItem) map[string]interface{} {
 	stats := map[string]interface{}{
 		"total_items":      len(watchlist),
 		"watched_items":    0,
@@ -252,12 +326,20 @@ func (s *WatchlistService) GetWatchlistStats(userID string) (map[string]interfac
 		stats["highest_rated"] = highestRating
 	}
 
-	return stats, nil
+	return stats
+}
+
+// DeleteWatchlist removes a profile's watchlist
+func (s *WatchlistService) DeleteWatchlist(profileID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.watchlists, profileID)
 }
 
-// ExportWatchlist exports user's watchlist as JSON
-func (s *WatchlistService) ExportWatchlist(userID string) ([]byte, error) {
-	watchlist, err := s.GetWatchlist(userID)
+// ExportWatchlist exports a profile's watchlist as JSON
+func (s *WatchlistService) ExportWatchlist(profileID string) ([]byte, error) {
+	watchlist, err := s.GetWatchlist(profileID)
 	if err != nil {
 		return nil, err
 	}
@@ -266,7 +348,7 @@ func (s *WatchlistService) ExportWatchlist(userID string) ([]byte, error) {
 }
 
 // ImportWatchlist imports a watchlist from JSON
-func (s *WatchlistService) ImportWatchlist(userID string, data []byte, merge bool) error {
+func (s *WatchlistService) ImportWatchlist(profileID string, data []byte, merge bool) error {
 	var importedWatchlist []models.WatchlistItem
 	if err := json.Unmarshal(data, &importedWatchlist); err != nil {
 		return fmt.Errorf("invalid watchlist format: %w", err)
@@ -277,12 +359,12 @@ func (s *WatchlistService) ImportWatchlist(userID string, data []byte, merge boo
 
 	if !merge {
 		// Replace existing watchlist
-		s.watchlists[userID] = importedWatchlist
+		s.watchlists[profileID] = importedWatchlist
 		return nil
 	}
 
 	// Merge with existing watchlist
-	existingWatchlist, exists := s.watchlists[userID]
+	existingWatchlist, exists := s.watchlists[profileID]
 	if !exists {
 		existingWatchlist = []models.WatchlistItem{}
 	}
@@ -304,13 +386,13 @@ func (s *WatchlistService) ImportWatchlist(userID string, data []byte, merge boo
 		}
 	}
 
-	s.watchlists[userID] = existingWatchlist
+	s.watchlists[profileID] = existingWatchlist
 	return nil
 }
 
-// ExportWatchlistAsCSV exports user's watchlist as CSV
-func (s *WatchlistService) ExportWatchlistAsCSV(userID string) ([]byte, error) {
-	watchlist, err := s.GetWatchlist(userID)
+// ExportWatchlistAsCSV exports a profile's watchlist as CSV
+func (s *WatchlistService) ExportWatchlistAsCSV(profileID string) ([]byte, error) {
+	watchlist, err := s.GetWatchlist(profileID)
 	if err != nil {
 		return nil, err
 	}
@@ -348,9 +430,9 @@ func (s *WatchlistService) ExportWatchlistAsCSV(userID string) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-// ExportWatchlistAsPDF exports user's watchlist as PDF
-func (s *WatchlistService) ExportWatchlistAsPDF(userID string) ([]byte, error) {
-	watchlist, err := s.GetWatchlist(userID)
+// ExportWatchlistAsPDF exports a profile's watchlist as PDF
+func (s *WatchlistService) ExportWatchlistAsPDF(profileID string) ([]byte, error) {
+	watchlist, err := s.GetWatchlist(profileID)
 	if err != nil {
 		return nil, err
 	}
@@ -365,7 +447,7 @@ func (s *WatchlistService) ExportWatchlistAsPDF(userID string) ([]byte, error) {
 	pdf.Ln(15)
 
 	// Add stats
-	stats, _ := s.GetWatchlistStats(userID)
+	stats, _ := s.GetWatchlistStats(profileID)
 	pdf.SetFont("Arial", "", 10)
 	pdf.Cell(0, 6, fmt.Sprintf("Total Items: %d | Movies: %d | TV Shows: %d | Watched: %d | To Watch: %d",
 		stats["total_items"], stats["movies"], stats["tv_shows"], stats["watched_items"], stats["unwatched_items"]))
@@ -450,3 +532,17 @@ func (s *WatchlistService) validateWatchlistItem(item models.WatchlistItem) erro
 
 	return nil
 }
+
+// indexWatchlist indexes watchlist items by watchlistKey
+func indexWatchlist(watchlist []models.WatchlistItem) map[string]models.WatchlistItem {
+	items := make(map[string]models.WatchlistItem, len(watchlist))
+	for _, item := range watchlist {
+		items[item.Type+":"+item.ID] = item
+	}
+	return items
+}
+
+// watchlistKey identifies a title in an indexed watchlist
+func watchlistKey(mediaType string, id int) string {
+	return mediaType + ":" + strconv.Itoa(id)
+}
@@ -43,6 +43,34 @@ func TestWatchlistService_AddToWatchlist(t *testing.T) {
 	}
 }
 
+func TestWatchlistService_AddAllToWatchlist(t *testing.T) {
+	service := NewWatchlistService()
+	userID := "test_user"
+	service.AddToWatchlist(userID, models.WatchlistItem{ID: "1", Type: "movie", Title: "Already There"})
+
+	// Any invalid or duplicate item leaves the watchlist unchanged
+	for _, items := range [][]models.WatchlistItem{
+		{{ID: "2", Type: "movie", Title: "New"}, {ID: "3", Type: "movie"}},
+		{{ID: "2", Type: "movie", Title: "New"}, {ID: "1", Type: "movie", Title: "Already There"}},
+		{{ID: "2", Type: "movie", Title: "New"}, {ID: "2", Type: "movie", Title: "New"}},
+	} {
+		if err := service.AddAllToWatchlist(userID, items); err == nil {
+			t.Errorf("Expected an error adding %+v", items)
+		}
+		if watchlist, _ := service.GetWatchlist(userID); len(watchlist) != 1 {
+			t.Errorf("Expected the watchlist to be unchanged, got %+v", watchlist)
+		}
+	}
+
+	items := []models.WatchlistItem{{ID: "2", Type: "movie", Title: "New"}, {ID: "2", Type: "tv", Title: "New Show"}}
+	if err := service.AddAllToWatchlist(userID, items); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !service.IsInWatchlist(userID, "2", "movie") || !service.IsInWatchlist(userID, "2", "tv") {
+		t.Error("Expected both items to be added")
+	}
+}
+
 func TestWatchlistService_RemoveFromWatchlist(t *testing.T) {
 	service := NewWatchlistService()
 	userID := "test_user"
@@ -333,6 +361,41 @@ func TestWatchlistService_GetWatchlistStats(t *testing.T) {
 	}
 }
 
+func TestWatchlistService_GetHouseholdStats(t *testing.T) {
+	service := NewWatchlistService()
+	parent := models.Profile{ID: 1, Name: "Parent", Owner: true}
+	kid := models.Profile{ID: 2, Name: "Kid", Kid: true}
+	guest := models.Profile{ID: 3, Name: "Guest"}
+
+	service.AddToWatchlist(parent.Key(), models.WatchlistItem{ID: "123", Type: "movie", Title: "Movie 1", Watched: true, Rating: 6.0})
+	service.AddToWatchlist(parent.Key(), models.WatchlistItem{ID: "789", Type: "tv", Title: "TV Show 1"})
+	service.AddToWatchlist(kid.Key(), models.WatchlistItem{ID: "123", Type: "movie", Title: "Movie 1", Watched: true, Rating: 9.0})
+
+	stats, err := service.GetHouseholdStats([]models.Profile{parent, kid, guest})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(stats.Profiles) != 3 || stats.Profiles[1].Name != "Kid" || !stats.Profiles[1].Kid || stats.Profiles[1].Stats["total_items"] != 1 || stats.Profiles[2].Stats["total_items"] != 0 {
+		t.Errorf("Expected each profile's stats, got %+v", stats.Profiles)
+	}
+
+	expectedTotals := map[string]interface{}{
+		"profiles":       3,
+		"total_items":    3,
+		"unique_titles":  2,
+		"shared_titles":  1,
+		"watched_items":  2,
+		"average_rating": 7.5,
+		"highest_rated":  9.0,
+	}
+	for key, expected := range expectedTotals {
+		if stats.Totals[key] != expected {
+			t.Errorf("Expected %s to be %v, got %v", key, expected, stats.Totals[key])
+		}
+	}
+}
+
 func TestWatchlistService_validateWatchlistItem(t *testing.T) {
 	service := NewWatchlistService()
 
@@ -1,32 +1,40 @@
 package services
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
+	"log"
 	"net/url"
-	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"movie-discovery-app/configs"
 	"movie-discovery-app/internal/models"
 )
 
 // YouTubeService handles YouTube API interactions
 type YouTubeService struct {
-	apiKey  string
-	baseURL string
-	client  *http.Client
+	apiKey        string
+	baseURL       string
+	upstream      *upstreamClient
+	cache         *Cache
+	cacheDuration time.Duration
+	quota         *QuotaTracker
 }
 
 // NewYouTubeService creates a new YouTube service
-func NewYouTubeService() *YouTubeService {
+func NewYouTubeService(config *configs.Config) *YouTubeService {
 	return &YouTubeService{
-		apiKey:  os.Getenv("YOUTUBE_API_KEY"),
-		baseURL: os.Getenv("YOUTUBE_BASE_URL"),
-		client: &http.Client{
-			Timeout: 10 * time.Second,
+		apiKey:  config.YouTube.APIKey,
+		baseURL: config.YouTube.BaseURL,
+		upstream: newUpstreamClient("YouTube", 10*time.Second, sharedRateLimiter("youtube", &config.Rate),
+			sharedCircuitBreaker("youtube", &config.Breaker), newRetryPolicy(&config.Retry)),
+		cache: &Cache{
+			data: make(map[string]CacheItem),
 		},
+		cacheDuration: config.Cache.Duration,
+		quota:         NewQuotaTracker(config.YouTube.DailyQuota),
 	}
 }
 
@@ -69,12 +77,49 @@ type YouTubeThumbnail struct {
 	Height int    `json:"height"`
 }
 
-// SearchTrailers searches for movie/TV show trailers on YouTube
-func (s *YouTubeService) SearchTrailers(title string, year string, mediaType string) ([]models.YouTubeVideo, error) {
+// YouTubeVideosResponse represents YouTube videos API response
+type YouTubeVideosResponse struct {
+	Items []YouTubeVideoItem `json:"items"`
+}
+
+// YouTubeVideoItem represents a single video's details and statistics
+type YouTubeVideoItem struct {
+	ID             string                `json:"id"`
+	ContentDetails YouTubeContentDetails `json:"contentDetails"`
+	Statistics     YouTubeStatistics     `json:"statistics"`
+}
+
+// YouTubeContentDetails represents YouTube video content details
+type YouTubeContentDetails struct {
+	Duration string `json:"duration"` // ISO 8601, e.g. PT2M30S
+}
+
+// YouTubeStatistics represents YouTube video statistics. Counts are
+// returned as strings.
+type YouTubeStatistics struct {
+	ViewCount string `json:"viewCount"`
+}
+
+// SearchTrailers searches for movie/TV show trailers on YouTube. Results are
+// cached per title, and each search spends 101 units of the daily quota.
+func (s *YouTubeService) SearchTrailers(ctx context.Context, title string, year string, mediaType string) ([]models.YouTubeVideo, error) {
 	if s.apiKey == "" {
 		return nil, fmt.Errorf("YouTube API key not configured")
 	}
 
+	cacheKey := fmt.Sprintf("trailers_%s_%s_%s", mediaType, strings.ToLower(title), year)
+
+	// Check cache first
+	if cached := s.cache.Get(cacheKey); cached != nil {
+		if trailers, ok := cached.([]models.YouTubeVideo); ok {
+			return append([]models.YouTubeVideo(nil), trailers...), nil
+		}
+	}
+
+	if !s.quota.Reserve(youtubeSearchCost) {
+		return nil, fmt.Errorf("failed to search YouTube: %w", ErrQuotaExhausted)
+	}
+
 	// Build search query
 	query := fmt.Sprintf("%s trailer", title)
 	if year != "" {
@@ -96,20 +141,9 @@ func (s *YouTubeService) SearchTrailers(title string, year string, mediaType str
 	requestURL := fmt.Sprintf("%s/search?%s", s.baseURL, params.Encode())
 
 	// Make API request
-	resp, err := s.client.Get(requestURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search YouTube: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("YouTube API error: %d", resp.StatusCode)
-	}
-
-	// Parse response
 	var searchResponse YouTubeSearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&searchResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode YouTube response: %w", err)
+	if err := s.upstream.getJSON(ctx, requestURL, &searchResponse); err != nil {
+		return nil, fmt.Errorf("failed to search YouTube: %w", err)
 	}
 
 	// Convert to our model
@@ -139,34 +173,145 @@ func (s *YouTubeService) SearchTrailers(title string, year string, mediaType str
 		trailers = append(trailers, trailer)
 	}
 
-	return trailers, nil
+	// Durations and view counts are nice to have; the search results are
+	// still useful without them
+	if err := s.fillVideoDetails(ctx, trailers); err != nil {
+		log.Printf("Failed to get YouTube video details: %v", err)
+	}
+
+	// Cache the result
+	s.cache.Set(cacheKey, trailers, s.cacheDuration)
+
+	return append([]models.YouTubeVideo(nil), trailers...), nil
+}
+
+// fillVideoDetails fills in the duration and view count of search results
+// with a single videos call
+func (s *YouTubeService) fillVideoDetails(ctx context.Context, trailers []models.YouTubeVideo) error {
+	if len(trailers) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(trailers))
+	for i, trailer := range trailers {
+		ids[i] = trailer.VideoID
+	}
+
+	if !s.quota.Reserve(youtubeVideosCost) {
+		return ErrQuotaExhausted
+	}
+
+	params := url.Values{}
+	params.Set("part", "contentDetails,statistics")
+	params.Set("id", strings.Join(ids, ","))
+	params.Set("key", s.apiKey)
+
+	requestURL := fmt.Sprintf("%s/videos?%s", s.baseURL, params.Encode())
+
+	var videosResponse YouTubeVideosResponse
+	if err := s.upstream.getJSON(ctx, requestURL, &videosResponse); err != nil {
+		return err
+	}
+
+	details := make(map[string]YouTubeVideoItem, len(videosResponse.Items))
+	for _, item := range videosResponse.Items {
+		details[item.ID] = item
+	}
+
+	for i := range trailers {
+		item, ok := details[trailers[i].VideoID]
+		if !ok {
+			continue
+		}
+		trailers[i].Duration = item.ContentDetails.Duration
+		if seconds, err := parseISODuration(item.ContentDetails.Duration); err == nil {
+			trailers[i].DurationSeconds = seconds
+		}
+		if views, err := strconv.ParseInt(item.Statistics.ViewCount, 10, 64); err == nil {
+			trailers[i].ViewCount = views
+		}
+	}
+
+	return nil
+}
+
+// parseISODuration converts an ISO 8601 duration such as PT1H2M3S or P1DT2H
+// to seconds. Years and months have no fixed length and are rejected.
+func parseISODuration(duration string) (int, error) {
+	if !strings.HasPrefix(duration, "P") || len(duration) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", duration)
+	}
+
+	units := map[byte]int{'D': 24 * 60 * 60, 'H': 60 * 60, 'M': 60, 'S': 1, 'W': 7 * 24 * 60 * 60}
+
+	total := 0
+	inTime := false
+	number := ""
+	for i := 1; i < len(duration); i++ {
+		c := duration[i]
+		switch {
+		case c >= '0' && c <= '9':
+			number += string(c)
+		case c == 'T' && !inTime && number == "":
+			inTime = true
+		default:
+			unit, known := units[c]
+			// M means months before the T and minutes after it
+			if !known || number == "" || (c == 'M' && !inTime) || (inTime && (c == 'D' || c == 'W')) || (!inTime && (c == 'H' || c == 'S')) {
+				return 0, fmt.Errorf("invalid duration %q", duration)
+			}
+			value, err := strconv.Atoi(number)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", duration)
+			}
+			total += value * unit
+			number = ""
+		}
+	}
+
+	if number != "" {
+		return 0, fmt.Errorf("invalid duration %q", duration)
+	}
+
+	return total, nil
 }
 
 // GetOfficialTrailer searches for the most relevant official trailer
-func (s *YouTubeService) GetOfficialTrailer(title string, year string, mediaType string) (*models.YouTubeVideo, error) {
-	trailers, err := s.SearchTrailers(title, year, mediaType)
+func (s *YouTubeService) GetOfficialTrailer(ctx context.Context, title string, year string, mediaType string) (*models.YouTubeVideo, error) {
+	trailers, err := s.SearchTrailers(ctx, title, year, mediaType)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(trailers) == 0 {
-		return nil, fmt.Errorf("no trailers found")
+		return nil, ErrNoTrailer
 	}
 
+	return pickOfficialTrailer(trailers), nil
+}
+
+// pickOfficialTrailer returns the search result most likely to be the
+// official trailer
+func pickOfficialTrailer(trailers []models.YouTubeVideo) *models.YouTubeVideo {
 	// Find the most likely official trailer
 	for _, trailer := range trailers {
 		titleLower := strings.ToLower(trailer.Title)
 		if strings.Contains(titleLower, "official") && 
 		   strings.Contains(titleLower, "trailer") {
-			return &trailer, nil
+			return &trailer
 		}
 	}
 
 	// If no official trailer found, return the first one
-	return &trailers[0], nil
+	return &trailers[0]
 }
 
 // IsConfigured checks if YouTube service is properly configured
 func (s *YouTubeService) IsConfigured() bool {
 	return s.apiKey != "" && s.baseURL != ""
 }
+
+// Quota reports the daily quota spent so far
+func (s *YouTubeService) Quota() QuotaSnapshot {
+	return s.quota.Snapshot()
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"movie-discovery-app/configs"
+)
+
+func TestParseISODuration(t *testing.T) {
+	tests := []struct {
+		duration string
+		expected int
+		wantErr  bool
+	}{
+		{"PT2M30S", 150, false},
+		{"PT1H2M3S", 3723, false},
+		{"PT45S", 45, false},
+		{"P1DT2H", 93600, false},
+		{"P0D", 0, false},
+		{"P1M", 0, true}, // months have no fixed length
+		{"PT", 0, true},
+		{"PT5", 0, true},
+		{"2M30S", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		seconds, err := parseISODuration(tt.duration)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseISODuration(%q) error = %v, wantErr %v", tt.duration, err, tt.wantErr)
+			continue
+		}
+		if seconds != tt.expected {
+			t.Errorf("parseISODuration(%q) = %d, want %d", tt.duration, seconds, tt.expected)
+		}
+	}
+}
+
+// newTestYouTubeService creates a YouTube service against a fake API with
+// the given daily quota
+func newTestYouTubeService(baseURL string, dailyQuota int) *YouTubeService {
+	service := NewYouTubeService(&configs.Config{
+		YouTube: configs.YouTubeConfig{APIKey: "test_key", BaseURL: baseURL, DailyQuota: dailyQuota},
+		Cache:   configs.CacheConfig{Duration: 30 * time.Minute},
+	})
+	service.upstream.rateLimiter = NewRateLimiter(60000, 1000)
+	service.upstream.breaker = NewCircuitBreaker("test", 100, time.Minute)
+	service.upstream.retry = RetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, MaxElapsed: time.Second}
+	return service
+}
+
+func newFakeYouTube(calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		switch r.URL.Path {
+		case "/search":
+			w.Write([]byte(`{"items": [
+				{"id": {"videoId": "abc"}, "snippet": {"title": "Official Trailer"}},
+				{"id": {"videoId": "def"}, "snippet": {"title": "Teaser"}}
+			]}`))
+		case "/videos":
+			if r.URL.Query().Get("id") != "abc,def" || r.URL.Query().Get("part") != "contentDetails,statistics" {
+				http.Error(w, "unexpected query", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"items": [
+				{"id": "abc", "contentDetails": {"duration": "PT2M30S"}, "statistics": {"viewCount": "1234567"}},
+				{"id": "def", "contentDetails": {"duration": "PT58S"}, "statistics": {"viewCount": "890"}}
+			]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestYouTubeService_SearchTrailers_DetailsAndCache(t *testing.T) {
+	var calls int32
+	youtube := newFakeYouTube(&calls)
+	defer youtube.Close()
+
+	service := newTestYouTubeService(youtube.URL, 10000)
+
+	trailers, err := service.SearchTrailers(context.Background(), "Inception", "2010", "movie")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(trailers) != 2 {
+		t.Fatalf("Expected 2 trailers, got %d", len(trailers))
+	}
+	if trailers[0].Duration != "PT2M30S" || trailers[0].DurationSeconds != 150 || trailers[0].ViewCount != 1234567 {
+		t.Errorf("Expected duration and view count to be filled, got %+v", trailers[0])
+	}
+	if trailers[1].DurationSeconds != 58 || trailers[1].ViewCount != 890 {
+		t.Errorf("Expected duration and view count to be filled, got %+v", trailers[1])
+	}
+
+	// The same title is served from cache
+	if _, err := service.SearchTrailers(context.Background(), "Inception", "2010", "movie"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 API calls (search and videos), got %d", calls)
+	}
+
+	if quota := service.Quota(); quota.Used != youtubeSearchCost+youtubeVideosCost {
+		t.Errorf("Expected %d quota units used, got %d", youtubeSearchCost+youtubeVideosCost, quota.Used)
+	}
+}
+
+func TestYouTubeService_SearchTrailers_QuotaExhausted(t *testing.T) {
+	var calls int32
+	youtube := newFakeYouTube(&calls)
+	defer youtube.Close()
+
+	service := newTestYouTubeService(youtube.URL, 150)
+
+	if _, err := service.SearchTrailers(context.Background(), "Inception", "2010", "movie"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	_, err := service.SearchTrailers(context.Background(), "Interstellar", "2014", "movie")
+	if !errors.Is(err, ErrQuotaExhausted) {
+		t.Fatalf("Expected ErrQuotaExhausted, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected no API call once the quota is spent, got %d calls", calls)
+	}
+
+	quota := service.Quota()
+	if !quota.Exhausted || quota.Remaining != 49 {
+		t.Errorf("Expected exhausted quota with 49 units left, got %+v", quota)
+	}
+}
+
+func TestQuotaTracker_ResetsAtMidnightPacific(t *testing.T) {
+	now := time.Date(2024, 3, 1, 7, 30, 0, 0, time.UTC) // 23:30 the day before in Los Angeles
+	tracker := NewQuotaTracker(200)
+	tracker.now = func() time.Time { return now }
+
+	if !tracker.Reserve(youtubeSearchCost) || !tracker.Reserve(youtubeSearchCost) {
+		t.Fatal("Expected the budget to cover two searches")
+	}
+	if tracker.Reserve(youtubeVideosCost) {
+		t.Error("Expected the budget to be spent")
+	}
+
+	snapshot := tracker.Snapshot()
+	if snapshot.ResetsAt.Format(time.RFC3339) != "2024-03-01T08:00:00Z" {
+		t.Errorf("Expected reset at midnight Pacific, got %v", snapshot.ResetsAt)
+	}
+
+	now = now.Add(time.Hour)
+	if !tracker.Reserve(youtubeSearchCost) {
+		t.Error("Expected the budget to reset on a new day")
+	}
+	if used := tracker.Snapshot().Used; used != youtubeSearchCost {
+		t.Errorf("Expected %d units used after the reset, got %d", youtubeSearchCost, used)
+	}
+}
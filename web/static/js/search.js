@@ -76,36 +76,23 @@ MovieDiscoveryApp.prototype.getSearchSuggestions = async function(query) {
     if (query.length < 2) return [];
 
     try {
-        // Get suggestions from both movies and TV shows using silent API requests
-        const [movieData, tvData] = await Promise.all([
-            this.silentApiRequest(`/api/v1/search/movies?q=${encodeURIComponent(query)}&page=1`),
-            this.silentApiRequest(`/api/v1/search/tv?q=${encodeURIComponent(query)}&page=1`)
-        ]);
+        // Autocomplete skips OMDB and answers recently seen titles locally
+        const data = await this.silentApiRequest(`/api/v1/autocomplete?q=${encodeURIComponent(query)}&limit=6`);
 
         const suggestions = [];
-        
-        // Add top 3 movies
-        if (movieData.results) {
-            movieData.results.slice(0, 3).forEach(movie => {
-                suggestions.push({
-                    title: movie.title,
-                    year: movie.release_date ? movie.release_date.substring(0, 4) : '',
-                    type: 'movie',
-                    id: movie.id
-                });
-            });
-        }
 
-        // Add top 3 TV shows
-        if (tvData.results) {
-            tvData.results.slice(0, 3).forEach(show => {
-                suggestions.push({
-                    title: show.name,
-                    year: show.first_air_date ? show.first_air_date.substring(0, 4) : '',
-                    type: 'tv',
-                    id: show.id
+        // Only titles can be opened from the dropdown
+        if (data.suggestions) {
+            data.suggestions
+                .filter(suggestion => suggestion.media_type === 'movie' || suggestion.media_type === 'tv')
+                .forEach(suggestion => {
+                    suggestions.push({
+                        title: suggestion.title,
+                        year: suggestion.year || '',
+                        type: suggestion.media_type,
+                        id: suggestion.id
+                    });
                 });
-            });
         }
 
         return suggestions;